@@ -2,14 +2,39 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"simple-database/pkg/command"
 	"simple-database/pkg/database"
+	"simple-database/pkg/pubsub"
+	"simple-database/pkg/server"
 )
 
 func main() {
-	db := database.New()
+	listen := flag.String("listen", "", "serve clients over TCP on this address (e.g. :6380) instead of reading stdin")
+	databases := flag.Int("databases", database.DefaultDatabases, "number of databases clients can SELECT")
+	bufferSize := flag.Int("pubsub-buffer", pubsub.DefaultBufferSize, "number of published messages a subscriber can fall behind by")
+	slowPolicy := flag.String("slow-subscribers", "disconnect", "what to do with subscribers whose buffer is full: disconnect or drop (their messages)")
+	flag.Parse()
+
+	db := database.NewWithDatabases(*databases)
+
+	if *listen != "" {
+		policy, ok := pubsub.ParsePolicy(*slowPolicy)
+		if !ok {
+			fmt.Println("Unknown -slow-subscribers policy:", *slowPolicy)
+			os.Exit(2)
+		}
+
+		broker := pubsub.NewBroker(*bufferSize, policy)
+		if err := server.NewWithBroker(db, broker).ListenAndServe(*listen); err != nil {
+			fmt.Println("Error serving:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	executor := command.NewExecutor(db)
 	scanner := bufio.NewScanner(os.Stdin)
 
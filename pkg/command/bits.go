@@ -0,0 +1,111 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseBitCommand parses the bitmap command family
+func parseBitCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "SETBIT":
+		if len(args) == 3 && isCount(args[1]) && isBit(args[2]) {
+			return Command{Type: CmdSetBit, Args: args}
+		}
+	case "GETBIT":
+		if len(args) == 2 && isCount(args[1]) {
+			return Command{Type: CmdGetBit, Args: args}
+		}
+	case "BITCOUNT":
+		if len(args) == 1 || len(args) == 3 || len(args) == 4 {
+			return parseBitRange(CmdBitCount, args[:1], args[1:])
+		}
+	case "BITPOS":
+		if len(args) >= 2 && len(args) <= 5 && isBit(args[1]) {
+			return parseBitRange(CmdBitPos, args[:2], args[2:])
+		}
+	case "BITOP":
+		if len(args) >= 3 {
+			return parseBitOp(args)
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// parseBitRange parses the optional [start [end [BYTE|BIT]]] that follows
+// the leading arguments, filling in start 0 and end -1 when they are
+// missing and keeping BIT as the last argument when bit positions are used
+func parseBitRange(cmdType CommandType, leading, rest []string) Command {
+	start, end := "0", "-1"
+	if len(rest) > 0 {
+		start = rest[0]
+	}
+	if len(rest) > 1 {
+		end = rest[1]
+	}
+	if !isInteger(start) || !isInteger(end) {
+		return Command{Type: CmdInvalid}
+	}
+
+	args := append(append([]string{}, leading...), start, end)
+	if len(rest) > 2 {
+		switch unit := strings.ToUpper(rest[2]); unit {
+		case "BIT":
+			args = append(args, unit)
+		case "BYTE":
+		default:
+			return Command{Type: CmdInvalid}
+		}
+	}
+	return Command{Type: cmdType, Args: args}
+}
+
+// parseBitOp parses BITOP op destination key [key ...], normalizing op to
+// uppercase. NOT takes exactly one source key.
+func parseBitOp(args []string) Command {
+	op := strings.ToUpper(args[0])
+	switch op {
+	case "AND", "OR", "XOR":
+	case "NOT":
+		if len(args) != 3 {
+			return Command{Type: CmdInvalid}
+		}
+	default:
+		return Command{Type: CmdInvalid}
+	}
+	return Command{Type: CmdBitOp, Args: append([]string{op}, args[1:]...)}
+}
+
+// isBit reports whether arg is 0 or 1
+func isBit(arg string) bool {
+	return arg == "0" || arg == "1"
+}
+
+// executeBits runs a command from the bitmap family
+func (ce *Executor) executeBits(cmd Command) string {
+	switch cmd.Type {
+	case CmdSetBit:
+		offset, _ := strconv.Atoi(cmd.Args[1])
+		bit, _ := strconv.Atoi(cmd.Args[2])
+		return formatInt(ce.database.SetBit(cmd.Args[0], offset, bit))
+
+	case CmdGetBit:
+		offset, _ := strconv.Atoi(cmd.Args[1])
+		return formatInt(ce.database.GetBit(cmd.Args[0], offset))
+
+	case CmdBitCount:
+		start, _ := strconv.Atoi(cmd.Args[1])
+		end, _ := strconv.Atoi(cmd.Args[2])
+		return formatInt(ce.database.BitCount(cmd.Args[0], start, end, hasOption(cmd.Args[3:], "BIT")))
+
+	case CmdBitPos:
+		bit, _ := strconv.Atoi(cmd.Args[1])
+		start, _ := strconv.Atoi(cmd.Args[2])
+		end, _ := strconv.Atoi(cmd.Args[3])
+		return formatInt(ce.database.BitPos(cmd.Args[0], bit, start, end, hasOption(cmd.Args[4:], "BIT")))
+
+	case CmdBitOp:
+		return formatInt(ce.database.BitOp(cmd.Args[0], cmd.Args[1], cmd.Args[2:]...))
+	}
+	return ""
+}
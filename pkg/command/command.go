@@ -2,8 +2,15 @@ package command
 
 import (
 	"fmt"
+	"simple-database/pkg/pubsub"
+	"simple-database/pkg/schema"
+	"simple-database/pkg/storage"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // CommandType represents different database commands
@@ -14,6 +21,103 @@ const (
 	CmdGet
 	CmdUnset
 	CmdNumEqualTo
+	CmdSetNX
+	CmdGetSet
+	CmdGetDel
+	CmdCAS
+	CmdCAD
+	CmdMGet
+	CmdMSet
+	CmdMSetNX
+	CmdDel
+	CmdExists
+	CmdType
+	CmdRename
+	CmdRenameNX
+	CmdCopy
+	CmdSelect
+	CmdMove
+	CmdSwapDB
+	CmdDBSize
+	CmdFlushDB
+	CmdFlushAll
+	CmdRange
+	CmdPrefix
+	CmdKeys
+	CmdScan
+	CmdKeysEqualTo
+	CmdCountRange
+	CmdKeysInRange
+	CmdTopValues
+	CmdHistogram
+	CmdDistinctValues
+	CmdHSet
+	CmdHGet
+	CmdHDel
+	CmdHGetAll
+	CmdHIncrBy
+	CmdLPush
+	CmdRPush
+	CmdLPop
+	CmdRPop
+	CmdLRange
+	CmdLLen
+	CmdLTrim
+	CmdBLPop
+	CmdBRPop
+	CmdSAdd
+	CmdSRem
+	CmdSMembers
+	CmdSIsMember
+	CmdSCard
+	CmdSInter
+	CmdSUnion
+	CmdSDiff
+	CmdSInterStore
+	CmdSUnionStore
+	CmdSDiffStore
+	CmdZAdd
+	CmdZRem
+	CmdZScore
+	CmdZIncrBy
+	CmdZRank
+	CmdZCard
+	CmdZRange
+	CmdZRevRange
+	CmdZRangeByScore
+	CmdSetBit
+	CmdGetBit
+	CmdBitCount
+	CmdBitPos
+	CmdBitOp
+	CmdPFAdd
+	CmdPFCount
+	CmdPFMerge
+	CmdGeoAdd
+	CmdGeoPos
+	CmdGeoDist
+	CmdGeoSearch
+	CmdXAdd
+	CmdXLen
+	CmdXRange
+	CmdXTrim
+	CmdXRead
+	CmdXGroupCreate
+	CmdXReadGroup
+	CmdXAck
+	CmdXPending
+	CmdJSONSet
+	CmdJSONGet
+	CmdJSONDel
+	CmdJSONNumIncrBy
+	CmdSubscribe
+	CmdPSubscribe
+	CmdUnsubscribe
+	CmdPUnsubscribe
+	CmdPublish
+	CmdSchemaAdd
+	CmdSchemaList
+	CmdSchemaDel
 	CmdBegin
 	CmdRollback
 	CmdCommit
@@ -47,18 +151,169 @@ func parseCommand(input string) Command {
 		if len(args) == 2 {
 			return Command{Type: CmdSet, Args: args}
 		}
+		if len(args) > 2 {
+			return parseSetOptions(args)
+		}
 	case "GET":
 		if len(args) == 1 {
 			return Command{Type: CmdGet, Args: args}
 		}
 	case "UNSET":
-		if len(args) == 1 {
+		if len(args) >= 1 {
 			return Command{Type: CmdUnset, Args: args}
 		}
 	case "NUMEQUALTO":
 		if len(args) == 1 {
 			return Command{Type: CmdNumEqualTo, Args: args}
 		}
+	case "KEYSEQUALTO":
+		if len(args) == 1 {
+			return Command{Type: CmdKeysEqualTo, Args: []string{args[0], "0", "0"}}
+		}
+		if len(args) == 4 && strings.ToUpper(args[1]) == "LIMIT" && isCount(args[2]) && isCount(args[3]) {
+			return Command{Type: CmdKeysEqualTo, Args: []string{args[0], args[2], args[3]}}
+		}
+	case "COUNTRANGE":
+		return parseValueRange(CmdCountRange, args)
+	case "KEYSINRANGE":
+		return parseValueRange(CmdKeysInRange, args)
+	case "TOPVALUES":
+		if len(args) == 1 && isCount(args[0]) {
+			return Command{Type: CmdTopValues, Args: args}
+		}
+	case "HISTOGRAM":
+		if len(args) == 0 {
+			return Command{Type: CmdHistogram}
+		}
+	case "DISTINCTVALUES":
+		if len(args) == 0 {
+			return Command{Type: CmdDistinctValues}
+		}
+	case "SETNX":
+		if len(args) == 2 {
+			return Command{Type: CmdSetNX, Args: args}
+		}
+	case "GETSET":
+		if len(args) == 2 {
+			return Command{Type: CmdGetSet, Args: args}
+		}
+	case "GETDEL":
+		if len(args) == 1 {
+			return Command{Type: CmdGetDel, Args: args}
+		}
+	case "CAS":
+		if len(args) == 3 {
+			return Command{Type: CmdCAS, Args: args}
+		}
+	case "CAD":
+		if len(args) == 2 {
+			return Command{Type: CmdCAD, Args: args}
+		}
+	case "MGET":
+		if len(args) >= 1 {
+			return Command{Type: CmdMGet, Args: args}
+		}
+	case "MSET":
+		if len(args) >= 2 && len(args)%2 == 0 {
+			return Command{Type: CmdMSet, Args: args}
+		}
+	case "MSETNX":
+		if len(args) >= 2 && len(args)%2 == 0 {
+			return Command{Type: CmdMSetNX, Args: args}
+		}
+	case "DEL":
+		if len(args) >= 1 {
+			return Command{Type: CmdDel, Args: args}
+		}
+	case "EXISTS":
+		if len(args) >= 1 {
+			return Command{Type: CmdExists, Args: args}
+		}
+	case "TYPE":
+		if len(args) == 1 {
+			return Command{Type: CmdType, Args: args}
+		}
+	case "RENAME":
+		if len(args) == 2 {
+			return Command{Type: CmdRename, Args: args}
+		}
+	case "RENAMENX":
+		if len(args) == 2 {
+			return Command{Type: CmdRenameNX, Args: args}
+		}
+	case "COPY":
+		if len(args) == 2 {
+			return Command{Type: CmdCopy, Args: args}
+		}
+		if len(args) == 3 && strings.ToUpper(args[2]) == "REPLACE" {
+			return Command{Type: CmdCopy, Args: []string{args[0], args[1], "REPLACE"}}
+		}
+	case "SELECT":
+		if len(args) == 1 && isCount(args[0]) {
+			return Command{Type: CmdSelect, Args: args}
+		}
+	case "MOVE":
+		if len(args) == 2 && isCount(args[1]) {
+			return Command{Type: CmdMove, Args: args}
+		}
+	case "SWAPDB":
+		if len(args) == 2 && isCount(args[0]) && isCount(args[1]) {
+			return Command{Type: CmdSwapDB, Args: args}
+		}
+	case "DBSIZE":
+		if len(args) == 0 {
+			return Command{Type: CmdDBSize}
+		}
+	case "FLUSHDB":
+		if len(args) == 0 {
+			return Command{Type: CmdFlushDB}
+		}
+	case "FLUSHALL":
+		if len(args) == 0 {
+			return Command{Type: CmdFlushAll}
+		}
+	case "RANGE":
+		if len(args) == 2 {
+			return Command{Type: CmdRange, Args: args}
+		}
+		if len(args) == 4 && strings.ToUpper(args[2]) == "LIMIT" && isCount(args[3]) {
+			return Command{Type: CmdRange, Args: []string{args[0], args[1], "LIMIT", args[3]}}
+		}
+	case "PREFIX":
+		if len(args) == 1 {
+			return Command{Type: CmdPrefix, Args: args}
+		}
+	case "KEYS":
+		if len(args) == 1 {
+			return Command{Type: CmdKeys, Args: args}
+		}
+	case "SCAN":
+		if len(args) >= 1 {
+			return parseScanOptions(args)
+		}
+	case "HSET", "HGET", "HDEL", "HGETALL", "HINCRBY":
+		return parseHashCommand(cmdName, args)
+	case "LPUSH", "RPUSH", "LPOP", "RPOP", "LRANGE", "LLEN", "LTRIM", "BLPOP", "BRPOP":
+		return parseListCommand(cmdName, args)
+	case "SADD", "SREM", "SMEMBERS", "SISMEMBER", "SCARD", "SINTER", "SUNION", "SDIFF",
+		"SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE":
+		return parseSetCommand(cmdName, args)
+	case "ZADD", "ZREM", "ZSCORE", "ZINCRBY", "ZRANK", "ZCARD", "ZRANGE", "ZREVRANGE", "ZRANGEBYSCORE":
+		return parseSortedSetCommand(cmdName, args)
+	case "SETBIT", "GETBIT", "BITCOUNT", "BITPOS", "BITOP":
+		return parseBitCommand(cmdName, args)
+	case "PFADD", "PFCOUNT", "PFMERGE":
+		return parseHyperLogLogCommand(cmdName, args)
+	case "GEOADD", "GEOPOS", "GEODIST", "GEOSEARCH":
+		return parseGeoCommand(cmdName, args)
+	case "XADD", "XLEN", "XRANGE", "XTRIM", "XREAD", "XGROUP", "XREADGROUP", "XACK", "XPENDING":
+		return parseStreamCommand(cmdName, args)
+	case "JSON.SET", "JSON.GET", "JSON.DEL", "JSON.NUMINCRBY":
+		return parseJSONCommand(cmdName, args, input)
+	case "SUBSCRIBE", "PSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "PUBLISH":
+		return parsePubSubCommand(cmdName, args, input)
+	case "SCHEMA":
+		return parseSchemaCommand(args, input)
 	case "BEGIN":
 		if len(args) == 0 {
 			return Command{Type: CmdBegin}
@@ -79,12 +334,267 @@ func parseCommand(input string) Command {
 	return Command{Type: CmdInvalid}
 }
 
+// parseSetOptions parses SET key value followed by NX, XX and/or GET.
+// Options are normalized to upper case and appended after the key and value.
+func parseSetOptions(args []string) Command {
+	parsed := []string{args[0], args[1]}
+	seen := make(map[string]bool)
+
+	for _, arg := range args[2:] {
+		option := strings.ToUpper(arg)
+		switch option {
+		case "NX", "XX", "GET":
+		default:
+			return Command{Type: CmdInvalid}
+		}
+		if seen[option] {
+			return Command{Type: CmdInvalid}
+		}
+		seen[option] = true
+		parsed = append(parsed, option)
+	}
+
+	if seen["NX"] && seen["XX"] {
+		return Command{Type: CmdInvalid}
+	}
+	return Command{Type: CmdSet, Args: parsed}
+}
+
+// parseScanOptions parses SCAN cursor followed by optional MATCH pattern
+// and COUNT n. The result always has the form [cursor, pattern, count],
+// with an empty pattern and count "0" when they are not given.
+func parseScanOptions(args []string) Command {
+	pattern, count := "", "0"
+	options := args[1:]
+	seen := make(map[string]bool)
+
+	for len(options) > 0 {
+		if len(options) < 2 {
+			return Command{Type: CmdInvalid}
+		}
+		option := strings.ToUpper(options[0])
+		if seen[option] {
+			return Command{Type: CmdInvalid}
+		}
+		seen[option] = true
+
+		switch option {
+		case "MATCH":
+			pattern = options[1]
+		case "COUNT":
+			if !isCount(options[1]) || options[1] == "0" {
+				return Command{Type: CmdInvalid}
+			}
+			count = options[1]
+		default:
+			return Command{Type: CmdInvalid}
+		}
+		options = options[2:]
+	}
+	return Command{Type: CmdScan, Args: []string{args[0], pattern, count}}
+}
+
+// parseValueRange parses min max [LEX]. Without LEX both bounds must be
+// numbers; with it they are compared as strings and LEX is kept as a third
+// argument.
+func parseValueRange(cmdType CommandType, args []string) Command {
+	if len(args) == 3 && strings.ToUpper(args[2]) == "LEX" {
+		return Command{Type: cmdType, Args: []string{args[0], args[1], "LEX"}}
+	}
+	if len(args) != 2 {
+		return Command{Type: CmdInvalid}
+	}
+	for _, bound := range args {
+		if _, err := strconv.ParseFloat(bound, 64); err != nil {
+			return Command{Type: CmdInvalid}
+		}
+	}
+	return Command{Type: cmdType, Args: args}
+}
+
+// hasOption reports whether a normalized option appears in args
+func hasOption(args []string, option string) bool {
+	for _, arg := range args {
+		if arg == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isCount reports whether arg is a non-negative integer
+func isCount(arg string) bool {
+	n, err := strconv.Atoi(arg)
+	return err == nil && n >= 0
+}
+
+// restOfLine returns input after its first n fields, for a final argument
+// that may contain spaces, which splitting into fields would lose
+func restOfLine(input string, n int) string {
+	for i := 0; i < n; i++ {
+		input = strings.TrimLeftFunc(input, unicode.IsSpace)
+		end := strings.IndexFunc(input, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		input = input[end:]
+	}
+	return strings.TrimSpace(input)
+}
+
+// isInteger reports whether arg is a 64-bit signed integer
+func isInteger(arg string) bool {
+	_, err := strconv.ParseInt(arg, 10, 64)
+	return err == nil
+}
+
+// formatInt renders an integer command result, or the error if there is one
+func formatInt(n int, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return strconv.Itoa(n)
+}
+
+// formatBool renders a boolean command result as "1" or "0"
+func formatBool(ok bool) string {
+	if ok {
+		return "1"
+	}
+	return "0"
+}
+
+// formatApplied renders whether a conditional write happened, or the error
+// if there is one
+func formatApplied(ok bool, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return formatBool(ok)
+}
+
+// formatString renders a string command result, or the error if there is one
+func formatString(value string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return formatValue(value)
+}
+
+// formatValue renders a stored string on a single line. SETBIT and BITOP
+// can put any byte in a value, including a newline that would split the
+// reply, so a value holding control characters or invalid UTF-8 is printed
+// double-quoted with Go escapes. So is a value starting with a double
+// quote, so that it can't be mistaken for one of those.
+func formatValue(value string) string {
+	if strings.HasPrefix(value, `"`) || !utf8.ValidString(value) || strings.ContainsFunc(value, unicode.IsControl) {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
 // Database defines the interface that the command executor expects
 type Database interface {
-	Set(key, value string)
-	Get(key string) string
-	Unset(key string)
+	Set(key, value string) error
+	Get(key string) (string, error)
+	Unset(key string) error
+	MGet(keys ...string) []string
+	MSet(pairs ...string) error
+	MSetNX(pairs ...string) (bool, error)
+	Del(keys ...string) (int, error)
+	Exists(keys ...string) int
+	Type(key string) string
+	Rename(key, newKey string) error
+	RenameNX(key, newKey string) (bool, error)
+	Copy(source, destination string, replace bool) (bool, error)
+	Select(index int) error
+	Move(key string, index int) (bool, error)
+	SwapDB(a, b int) error
+	DBSize() int
+	FlushDB()
+	FlushAll()
+	Range(start, end string, limit int) []string
+	Prefix(prefix string) []string
+	Keys(pattern string) []string
+	Scan(cursor, pattern string, count int) (string, []string, error)
+	SetNX(key, value string) (string, bool, error)
+	SetXX(key, value string) (string, bool, error)
+	GetSet(key, value string) (string, error)
+	GetDel(key string) (string, error)
+	CompareAndSwap(key, expected, newValue string) (bool, error)
+	CompareAndDelete(key, expected string) (bool, error)
 	NumEqualTo(value string) int
+	KeysEqualTo(value string, offset, count int) []string
+	CountRange(min, max float64) int
+	CountLexRange(min, max string) int
+	KeysInRange(min, max float64) []string
+	KeysInLexRange(min, max string) []string
+	TopValues(n int) ([]string, []int)
+	ValueHistogram() map[int]int
+	DistinctValues() int
+	HSet(key string, pairs ...string) (int, error)
+	HGet(key, field string) (string, error)
+	HDel(key string, fields ...string) (int, error)
+	HGetAll(key string) ([]string, error)
+	HIncrBy(key, field string, increment int64) (int64, error)
+	LPush(key string, values ...string) (int, error)
+	RPush(key string, values ...string) (int, error)
+	LPop(key string, count int) ([]string, error)
+	RPop(key string, count int) ([]string, error)
+	LRange(key string, start, stop int) ([]string, error)
+	LLen(key string) (int, error)
+	LTrim(key string, start, stop int) error
+	BLPop(keys []string, timeout time.Duration) (string, string, bool, error)
+	BRPop(keys []string, timeout time.Duration) (string, string, bool, error)
+	SAdd(key string, members ...string) (int, error)
+	SRem(key string, members ...string) (int, error)
+	SMembers(key string) ([]string, error)
+	SIsMember(key, member string) (bool, error)
+	SCard(key string) (int, error)
+	SInter(keys ...string) ([]string, error)
+	SUnion(keys ...string) ([]string, error)
+	SDiff(keys ...string) ([]string, error)
+	SInterStore(destination string, keys ...string) (int, error)
+	SUnionStore(destination string, keys ...string) (int, error)
+	SDiffStore(destination string, keys ...string) (int, error)
+	ZAdd(key string, pairs ...string) (int, error)
+	ZRem(key string, members ...string) (int, error)
+	ZScore(key, member string) (float64, bool, error)
+	ZIncrBy(key, member string, increment float64) (float64, error)
+	ZRank(key, member string) (int, bool, error)
+	ZCard(key string) (int, error)
+	ZRange(key string, start, stop int) ([]string, []float64, error)
+	ZRevRange(key string, start, stop int) ([]string, []float64, error)
+	ZRangeByScore(key string, min, max float64, offset, count int) ([]string, []float64, error)
+	SetBit(key string, offset, bit int) (int, error)
+	GetBit(key string, offset int) (int, error)
+	BitCount(key string, start, end int, bitUnit bool) (int, error)
+	BitPos(key string, bit, start, end int, bitUnit bool) (int, error)
+	BitOp(op, destination string, keys ...string) (int, error)
+	PFAdd(key string, elements ...string) (bool, error)
+	PFCount(keys ...string) (int, error)
+	PFMerge(destination string, keys ...string) error
+	GeoAdd(key string, triples ...string) (int, error)
+	GeoPos(key string, members ...string) ([][2]float64, []bool, error)
+	GeoDist(key, member1, member2 string) (float64, bool, error)
+	GeoSearch(key string, longitude, latitude, radius, width, height float64, count int, descending bool) ([]string, []float64, [][2]float64, error)
+	GeoSearchFromMember(key, member string, radius, width, height float64, count int, descending bool) ([]string, []float64, [][2]float64, error)
+	XAdd(key, id string, fields ...string) (string, error)
+	XLen(key string) (int, error)
+	XRange(key, start, end string, count int) ([]storage.StreamEntry, error)
+	XTrim(key, strategy, threshold string) (int, error)
+	XRead(keys, ids []string, count int, block time.Duration) ([]string, [][]storage.StreamEntry, error)
+	XGroupCreate(key, group, id string, mkStream bool) error
+	XReadGroup(group, consumer string, keys, ids []string, count int, block time.Duration) ([]string, [][]storage.StreamEntry, error)
+	XAck(key, group string, ids ...string) (int, error)
+	XPending(key, group, start, end string, count int, consumer string) ([]storage.PendingEntry, error)
+	JSONSet(key, path, value string, nx, xx bool) (bool, error)
+	JSONGet(key, path string) (string, error)
+	JSONDel(key, path string) (int, error)
+	JSONNumIncrBy(key, path, increment string) (string, error)
+	AddRule(rule schema.Rule) (int, error)
+	Rules() []schema.Rule
+	RemoveRule(id int) bool
 	Begin()
 	Rollback() error
 	Commit() error
@@ -92,7 +602,9 @@ type Database interface {
 
 // Executor handles the execution of database commands
 type Executor struct {
-	database Database
+	database   Database
+	broker     *pubsub.Broker
+	subscriber *pubsub.Subscriber
 }
 
 // NewExecutor creates a new command executor
@@ -105,24 +617,208 @@ func NewExecutor(db Database) *Executor {
 // Execute processes a command string and returns output if any
 func (ce *Executor) Execute(input string) (output string, shouldExit bool) {
 	cmd := parseCommand(input)
+	if ce.subscribed() && !allowedWhileSubscribed(cmd.Type) {
+		return subscribedMessage, false
+	}
 
 	switch cmd.Type {
 	case CmdSet:
-		ce.database.Set(cmd.Args[0], cmd.Args[1])
-		return "", false
+		return ce.executeSet(cmd.Args), false
 
 	case CmdGet:
-		result := ce.database.Get(cmd.Args[0])
-		return result, false
+		return formatString(ce.database.Get(cmd.Args[0])), false
 
 	case CmdUnset:
-		ce.database.Unset(cmd.Args[0])
-		return "", false
+		_, err := ce.database.Del(cmd.Args...)
+		return formatString("", err), false
 
 	case CmdNumEqualTo:
 		count := ce.database.NumEqualTo(cmd.Args[0])
 		return strconv.Itoa(count), false
 
+	case CmdKeysEqualTo:
+		offset, _ := strconv.Atoi(cmd.Args[1])
+		count, _ := strconv.Atoi(cmd.Args[2])
+		return strings.Join(ce.database.KeysEqualTo(cmd.Args[0], offset, count), "\n"), false
+
+	case CmdCountRange:
+		if hasOption(cmd.Args[2:], "LEX") {
+			return strconv.Itoa(ce.database.CountLexRange(cmd.Args[0], cmd.Args[1])), false
+		}
+		min, _ := strconv.ParseFloat(cmd.Args[0], 64)
+		max, _ := strconv.ParseFloat(cmd.Args[1], 64)
+		return strconv.Itoa(ce.database.CountRange(min, max)), false
+
+	case CmdKeysInRange:
+		if hasOption(cmd.Args[2:], "LEX") {
+			return strings.Join(ce.database.KeysInLexRange(cmd.Args[0], cmd.Args[1]), "\n"), false
+		}
+		min, _ := strconv.ParseFloat(cmd.Args[0], 64)
+		max, _ := strconv.ParseFloat(cmd.Args[1], 64)
+		return strings.Join(ce.database.KeysInRange(min, max), "\n"), false
+
+	case CmdTopValues:
+		n, _ := strconv.Atoi(cmd.Args[0])
+		values, counts := ce.database.TopValues(n)
+		lines := make([]string, len(values))
+		for i, value := range values {
+			lines[i] = formatValue(value) + " " + strconv.Itoa(counts[i])
+		}
+		return strings.Join(lines, "\n"), false
+
+	case CmdHistogram:
+		return formatHistogram(ce.database.ValueHistogram()), false
+
+	case CmdDistinctValues:
+		return strconv.Itoa(ce.database.DistinctValues()), false
+
+	case CmdSetNX:
+		_, applied, err := ce.database.SetNX(cmd.Args[0], cmd.Args[1])
+		if err != nil {
+			return err.Error(), false
+		}
+		return formatBool(applied), false
+
+	case CmdGetSet:
+		return formatString(ce.database.GetSet(cmd.Args[0], cmd.Args[1])), false
+
+	case CmdGetDel:
+		return formatString(ce.database.GetDel(cmd.Args[0])), false
+
+	case CmdCAS:
+		return formatApplied(ce.database.CompareAndSwap(cmd.Args[0], cmd.Args[1], cmd.Args[2])), false
+
+	case CmdCAD:
+		return formatApplied(ce.database.CompareAndDelete(cmd.Args[0], cmd.Args[1])), false
+
+	case CmdMGet:
+		values := ce.database.MGet(cmd.Args...)
+		for i, value := range values {
+			values[i] = formatValue(value)
+		}
+		return strings.Join(values, "\n"), false
+
+	case CmdMSet:
+		return formatString("", ce.database.MSet(cmd.Args...)), false
+
+	case CmdMSetNX:
+		return formatApplied(ce.database.MSetNX(cmd.Args...)), false
+
+	case CmdDel:
+		return formatInt(ce.database.Del(cmd.Args...)), false
+
+	case CmdExists:
+		return strconv.Itoa(ce.database.Exists(cmd.Args...)), false
+
+	case CmdType:
+		return ce.database.Type(cmd.Args[0]), false
+
+	case CmdRename:
+		if err := ce.database.Rename(cmd.Args[0], cmd.Args[1]); err != nil {
+			return err.Error(), false
+		}
+		return "", false
+
+	case CmdRenameNX:
+		renamed, err := ce.database.RenameNX(cmd.Args[0], cmd.Args[1])
+		if err != nil {
+			return err.Error(), false
+		}
+		return formatBool(renamed), false
+
+	case CmdCopy:
+		replace := hasOption(cmd.Args[2:], "REPLACE")
+		return formatApplied(ce.database.Copy(cmd.Args[0], cmd.Args[1], replace)), false
+
+	case CmdSelect:
+		index, _ := strconv.Atoi(cmd.Args[0])
+		if err := ce.database.Select(index); err != nil {
+			return err.Error(), false
+		}
+		return "", false
+
+	case CmdMove:
+		index, _ := strconv.Atoi(cmd.Args[1])
+		moved, err := ce.database.Move(cmd.Args[0], index)
+		if err != nil {
+			return err.Error(), false
+		}
+		return formatBool(moved), false
+
+	case CmdSwapDB:
+		a, _ := strconv.Atoi(cmd.Args[0])
+		b, _ := strconv.Atoi(cmd.Args[1])
+		if err := ce.database.SwapDB(a, b); err != nil {
+			return err.Error(), false
+		}
+		return "", false
+
+	case CmdDBSize:
+		return strconv.Itoa(ce.database.DBSize()), false
+
+	case CmdFlushDB:
+		ce.database.FlushDB()
+		return "", false
+
+	case CmdFlushAll:
+		ce.database.FlushAll()
+		return "", false
+
+	case CmdRange:
+		limit := 0
+		if len(cmd.Args) == 4 {
+			limit, _ = strconv.Atoi(cmd.Args[3])
+		}
+		return strings.Join(ce.database.Range(cmd.Args[0], cmd.Args[1], limit), "\n"), false
+
+	case CmdPrefix:
+		return strings.Join(ce.database.Prefix(cmd.Args[0]), "\n"), false
+
+	case CmdKeys:
+		return strings.Join(ce.database.Keys(cmd.Args[0]), "\n"), false
+
+	case CmdScan:
+		count, _ := strconv.Atoi(cmd.Args[2])
+		next, keys, err := ce.database.Scan(cmd.Args[0], cmd.Args[1], count)
+		if err != nil {
+			return err.Error(), false
+		}
+		return strings.Join(append([]string{next}, keys...), "\n"), false
+
+	case CmdHSet, CmdHGet, CmdHDel, CmdHGetAll, CmdHIncrBy:
+		return ce.executeHash(cmd), false
+
+	case CmdLPush, CmdRPush, CmdLPop, CmdRPop, CmdLRange, CmdLLen, CmdLTrim, CmdBLPop, CmdBRPop:
+		return ce.executeList(cmd), false
+
+	case CmdSAdd, CmdSRem, CmdSMembers, CmdSIsMember, CmdSCard, CmdSInter, CmdSUnion, CmdSDiff,
+		CmdSInterStore, CmdSUnionStore, CmdSDiffStore:
+		return ce.executeSetFamily(cmd), false
+
+	case CmdZAdd, CmdZRem, CmdZScore, CmdZIncrBy, CmdZRank, CmdZCard, CmdZRange, CmdZRevRange, CmdZRangeByScore:
+		return ce.executeSortedSet(cmd), false
+
+	case CmdSetBit, CmdGetBit, CmdBitCount, CmdBitPos, CmdBitOp:
+		return ce.executeBits(cmd), false
+
+	case CmdPFAdd, CmdPFCount, CmdPFMerge:
+		return ce.executeHyperLogLog(cmd), false
+
+	case CmdGeoAdd, CmdGeoPos, CmdGeoDist, CmdGeoSearch:
+		return ce.executeGeo(cmd), false
+
+	case CmdXAdd, CmdXLen, CmdXRange, CmdXTrim, CmdXRead, CmdXGroupCreate, CmdXReadGroup, CmdXAck, CmdXPending:
+		return ce.executeStream(cmd), false
+
+	case CmdJSONSet, CmdJSONGet, CmdJSONDel, CmdJSONNumIncrBy:
+		return ce.executeJSON(cmd), false
+
+	case CmdSubscribe, CmdPSubscribe, CmdUnsubscribe, CmdPUnsubscribe, CmdPublish:
+		return ce.executePubSub(cmd), false
+
+	case CmdSchemaAdd, CmdSchemaList, CmdSchemaDel:
+		return ce.executeSchema(cmd), false
+
 	case CmdBegin:
 		ce.database.Begin()
 		return "", false
@@ -149,6 +845,51 @@ func (ce *Executor) Execute(input string) (output string, shouldExit bool) {
 	return "", false
 }
 
+// executeSet handles SET with its optional NX, XX and GET modifiers.
+// A plain SET produces no output; NX/XX report whether the write happened,
+// and GET returns the previous value instead.
+func (ce *Executor) executeSet(args []string) string {
+	key, value, options := args[0], args[1], args[2:]
+
+	var oldValue string
+	var err error
+	applied := true
+	switch {
+	case hasOption(options, "NX"):
+		oldValue, applied, err = ce.database.SetNX(key, value)
+	case hasOption(options, "XX"):
+		oldValue, applied, err = ce.database.SetXX(key, value)
+	case hasOption(options, "GET"):
+		oldValue, err = ce.database.GetSet(key, value)
+	default:
+		return formatString("", ce.database.Set(key, value))
+	}
+
+	if err != nil {
+		return err.Error()
+	}
+	if hasOption(options, "GET") {
+		return formatValue(oldValue)
+	}
+	return formatBool(applied)
+}
+
+// formatHistogram renders one "frequency values" line per frequency,
+// in increasing order of frequency
+func formatHistogram(histogram map[int]int) string {
+	frequencies := make([]int, 0, len(histogram))
+	for frequency := range histogram {
+		frequencies = append(frequencies, frequency)
+	}
+	sort.Ints(frequencies)
+
+	lines := make([]string, len(frequencies))
+	for i, frequency := range frequencies {
+		lines[i] = strconv.Itoa(frequency) + " " + strconv.Itoa(histogram[frequency])
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ExecuteAndPrint processes a command and prints output if needed
 func (ce *Executor) ExecuteAndPrint(input string) bool {
 	output, shouldExit := ce.Execute(input)
@@ -1,7 +1,10 @@
 package command
 
 import (
+	"errors"
 	"simple-database/pkg/database"
+	"simple-database/pkg/pubsub"
+	"strings"
 	"testing"
 )
 
@@ -25,6 +28,163 @@ func TestCommandParser(t *testing.T) {
 		{"SET key", CmdInvalid, nil},     // Missing argument
 		{"GET", CmdInvalid, nil},         // Missing argument
 		{"BEGIN extra", CmdInvalid, nil}, // Extra argument
+		{"SET key value nx get", CmdSet, []string{"key", "value", "NX", "GET"}},
+		{"SET key value XX", CmdSet, []string{"key", "value", "XX"}},
+		{"SET key value NX XX", CmdInvalid, nil}, // Conflicting options
+		{"SET key value GET GET", CmdInvalid, nil},
+		{"SET key value EX", CmdInvalid, nil}, // Unknown option
+		{"SETNX key value", CmdSetNX, []string{"key", "value"}},
+		{"GETSET key value", CmdGetSet, []string{"key", "value"}},
+		{"GETDEL key", CmdGetDel, []string{"key"}},
+		{"CAS key old new", CmdCAS, []string{"key", "old", "new"}},
+		{"CAS key old", CmdInvalid, nil},
+		{"CAD key old", CmdCAD, []string{"key", "old"}},
+		{"UNSET a b", CmdUnset, []string{"a", "b"}},
+		{"DEL a b c", CmdDel, []string{"a", "b", "c"}},
+		{"MGET a b", CmdMGet, []string{"a", "b"}},
+		{"MSET a 1 b 2", CmdMSet, []string{"a", "1", "b", "2"}},
+		{"MSET a 1 b", CmdInvalid, nil}, // Odd number of arguments
+		{"MSETNX a 1", CmdMSetNX, []string{"a", "1"}},
+		{"MGET", CmdInvalid, nil},
+		{"EXISTS a b", CmdExists, []string{"a", "b"}},
+		{"TYPE a", CmdType, []string{"a"}},
+		{"RENAME a b", CmdRename, []string{"a", "b"}},
+		{"RENAMENX a b", CmdRenameNX, []string{"a", "b"}},
+		{"COPY a b", CmdCopy, []string{"a", "b"}},
+		{"COPY a b replace", CmdCopy, []string{"a", "b", "REPLACE"}},
+		{"COPY a b c", CmdInvalid, nil},
+		{"SELECT 3", CmdSelect, []string{"3"}},
+		{"SELECT -1", CmdInvalid, nil},
+		{"MOVE k 1", CmdMove, []string{"k", "1"}},
+		{"MOVE k x", CmdInvalid, nil},
+		{"SWAPDB 0 1", CmdSwapDB, []string{"0", "1"}},
+		{"SWAPDB 0", CmdInvalid, nil},
+		{"DBSIZE", CmdDBSize, nil},
+		{"flushdb", CmdFlushDB, nil},
+		{"FLUSHALL ASYNC", CmdInvalid, nil},
+		{"RANGE a z", CmdRange, []string{"a", "z"}},
+		{"RANGE a z limit 5", CmdRange, []string{"a", "z", "LIMIT", "5"}},
+		{"RANGE a z LIMIT x", CmdInvalid, nil},
+		{"RANGE a z LIMIT -1", CmdInvalid, nil},
+		{"PREFIX user:", CmdPrefix, []string{"user:"}},
+		{"KEYS user:*", CmdKeys, []string{"user:*"}},
+		{"SCAN 0", CmdScan, []string{"0", "", "0"}},
+		{"SCAN 0 count 5 match a*", CmdScan, []string{"0", "a*", "5"}},
+		{"SCAN 0 MATCH", CmdInvalid, nil},
+		{"SCAN 0 COUNT 0", CmdInvalid, nil},
+		{"SCAN 0 COUNT 1 COUNT 2", CmdInvalid, nil},
+		{"KEYSEQUALTO 10", CmdKeysEqualTo, []string{"10", "0", "0"}},
+		{"KEYSEQUALTO 10 LIMIT 5 2", CmdKeysEqualTo, []string{"10", "5", "2"}},
+		{"KEYSEQUALTO 10 LIMIT 5", CmdInvalid, nil},
+		{"COUNTRANGE 100 200", CmdCountRange, []string{"100", "200"}},
+		{"COUNTRANGE -inf +inf", CmdCountRange, []string{"-inf", "+inf"}},
+		{"COUNTRANGE a b", CmdInvalid, nil}, // Numeric mode needs numbers
+		{"COUNTRANGE a b lex", CmdCountRange, []string{"a", "b", "LEX"}},
+		{"KEYSINRANGE 1 2", CmdKeysInRange, []string{"1", "2"}},
+		{"TOPVALUES 3", CmdTopValues, []string{"3"}},
+		{"TOPVALUES x", CmdInvalid, nil},
+		{"HISTOGRAM", CmdHistogram, []string{}},
+		{"DISTINCTVALUES", CmdDistinctValues, []string{}},
+		{"HSET h f v", CmdHSet, []string{"h", "f", "v"}},
+		{"HSET h f v g", CmdInvalid, nil}, // Field without a value
+		{"HGET h f", CmdHGet, []string{"h", "f"}},
+		{"HDEL h f g", CmdHDel, []string{"h", "f", "g"}},
+		{"HGETALL h", CmdHGetAll, []string{"h"}},
+		{"HINCRBY h f -3", CmdHIncrBy, []string{"h", "f", "-3"}},
+		{"HINCRBY h f 1.5", CmdInvalid, nil},
+		{"LPUSH l a b", CmdLPush, []string{"l", "a", "b"}},
+		{"RPUSH l", CmdInvalid, nil},
+		{"LPOP l", CmdLPop, []string{"l"}},
+		{"RPOP l 3", CmdRPop, []string{"l", "3"}},
+		{"RPOP l x", CmdInvalid, nil},
+		{"LRANGE l 0 -1", CmdLRange, []string{"l", "0", "-1"}},
+		{"LTRIM l 0 x", CmdInvalid, nil},
+		{"LLEN l", CmdLLen, []string{"l"}},
+		{"BLPOP a b 0", CmdBLPop, []string{"a", "b", "0"}},
+		{"BRPOP a 0.5", CmdBRPop, []string{"a", "0.5"}},
+		{"BLPOP a", CmdInvalid, nil},
+		{"BLPOP a -1", CmdInvalid, nil},
+		{"SADD s a b", CmdSAdd, []string{"s", "a", "b"}},
+		{"SADD s", CmdInvalid, nil},
+		{"SISMEMBER s a", CmdSIsMember, []string{"s", "a"}},
+		{"SISMEMBER s a b", CmdInvalid, nil},
+		{"SMEMBERS s", CmdSMembers, []string{"s"}},
+		{"SINTER a b", CmdSInter, []string{"a", "b"}},
+		{"SDIFFSTORE dst", CmdInvalid, nil},
+		{"SUNIONSTORE dst a b", CmdSUnionStore, []string{"dst", "a", "b"}},
+		{"ZADD z 1 a 2.5 b", CmdZAdd, []string{"z", "1", "a", "2.5", "b"}},
+		{"ZADD z x a", CmdInvalid, nil},
+		{"ZADD z 1", CmdInvalid, nil},
+		{"ZINCRBY z -2 a", CmdZIncrBy, []string{"z", "-2", "a"}},
+		{"ZRANGE z 0 -1 withscores", CmdZRange, []string{"z", "0", "-1", "WITHSCORES"}},
+		{"ZREVRANGE z 0 x", CmdInvalid, nil},
+		{"ZRANGEBYSCORE z -inf (5", CmdZRangeByScore, []string{"z", "-inf", "(5", "0", "-1"}},
+		{"ZRANGEBYSCORE z 1 2 limit 1 3 withscores", CmdZRangeByScore, []string{"z", "1", "2", "1", "3", "WITHSCORES"}},
+		{"ZRANGEBYSCORE z 1 2 LIMIT 1", CmdInvalid, nil},
+		{"ZRANGEBYSCORE z a 2", CmdInvalid, nil},
+		{"SETBIT b 7 1", CmdSetBit, []string{"b", "7", "1"}},
+		{"SETBIT b 7 2", CmdInvalid, nil},
+		{"GETBIT b -1", CmdInvalid, nil},
+		{"BITCOUNT b", CmdBitCount, []string{"b", "0", "-1"}},
+		{"BITCOUNT b 1", CmdInvalid, nil},
+		{"BITCOUNT b 1 5 bit", CmdBitCount, []string{"b", "1", "5", "BIT"}},
+		{"BITCOUNT b 1 5 byte", CmdBitCount, []string{"b", "1", "5"}},
+		{"BITPOS b 0 2", CmdBitPos, []string{"b", "0", "2", "-1"}},
+		{"BITOP and d a b", CmdBitOp, []string{"AND", "d", "a", "b"}},
+		{"BITOP NOT d a b", CmdInvalid, nil},
+		{"BITOP NAND d a", CmdInvalid, nil},
+		{"PFADD h a b", CmdPFAdd, []string{"h", "a", "b"}},
+		{"PFADD", CmdInvalid, nil},
+		{"PFCOUNT a b", CmdPFCount, []string{"a", "b"}},
+		{"PFMERGE d a", CmdPFMerge, []string{"d", "a"}},
+		{"GEOADD g 13.4 52.5 berlin", CmdGeoAdd, []string{"g", "13.4", "52.5", "berlin"}},
+		{"GEOADD g 13.4 north berlin", CmdInvalid, nil},
+		{"GEOADD g 13.4 52.5", CmdInvalid, nil},
+		{"GEODIST g a b", CmdGeoDist, []string{"g", "a", "b", "m"}},
+		{"GEODIST g a b KM", CmdGeoDist, []string{"g", "a", "b", "km"}},
+		{"GEODIST g a b miles", CmdInvalid, nil},
+		{"GEOSEARCH g fromlonlat 15 37 byradius 200 km desc count 2 withdist",
+			CmdGeoSearch, []string{"g", "FROMLONLAT", "", "15", "37", "200", "0", "0", "km", "2", "DESC", "WITHDIST"}},
+		{"GEOSEARCH g FROMMEMBER a BYBOX 2 3 m",
+			CmdGeoSearch, []string{"g", "FROMMEMBER", "a", "0", "0", "0", "2", "3", "m", "0", "ASC"}},
+		{"GEOSEARCH g FROMMEMBER a", CmdInvalid, nil},
+		{"GEOSEARCH g BYRADIUS 1 m", CmdInvalid, nil},
+		{"GEOSEARCH g FROMMEMBER a BYRADIUS 0 m", CmdInvalid, nil},
+		{"GEOSEARCH g FROMMEMBER a BYRADIUS 1 m ASC DESC", CmdInvalid, nil},
+		{"XADD s * f v", CmdXAdd, []string{"s", "*", "f", "v"}},
+		{"XADD s 5-* f v", CmdXAdd, []string{"s", "5-*", "f", "v"}},
+		{"XADD s x f v", CmdInvalid, nil},
+		{"XADD s * f", CmdInvalid, nil},
+		{"XRANGE s - +", CmdXRange, []string{"s", "-", "+", "-1"}},
+		{"XRANGE s 1 2 count 5", CmdXRange, []string{"s", "1", "2", "5"}},
+		{"XRANGE s + -", CmdInvalid, nil},
+		{"XTRIM s maxlen 10", CmdXTrim, []string{"s", "MAXLEN", "10"}},
+		{"XTRIM s MINID x", CmdInvalid, nil},
+		{"XREAD streams a b 0 $", CmdXRead, []string{"-1", "-1", "a", "b", "0", "$"}},
+		{"XREAD COUNT 2 BLOCK 100 STREAMS a 0", CmdXRead, []string{"2", "100", "a", "0"}},
+		{"XREAD STREAMS a b 0", CmdInvalid, nil},
+		{"XREAD STREAMS a >", CmdInvalid, nil},
+		{"XREADGROUP GROUP g c STREAMS a >", CmdXReadGroup, []string{"g", "c", "-1", "-1", "a", ">"}},
+		{"XREADGROUP GROUP g c STREAMS a $", CmdInvalid, nil},
+		{"XGROUP CREATE s g $ mkstream", CmdXGroupCreate, []string{"s", "g", "$", "MKSTREAM"}},
+		{"XGROUP DESTROY s g", CmdInvalid, nil},
+		{"XACK s g 1-0 2-0", CmdXAck, []string{"s", "g", "1-0", "2-0"}},
+		{`JSON.SET d $ {"a": "x  y"}`, CmdJSONSet, []string{"d", "$", `{"a": "x  y"}`, ""}},
+		{"JSON.SET d $.a [1, 2] nx", CmdJSONSet, []string{"d", "$.a", "[1, 2]", "NX"}},
+		{"JSON.SET d $ XX", CmdJSONSet, []string{"d", "$", "XX", ""}},
+		{"JSON.SET d $", CmdInvalid, nil},
+		{"JSON.GET d", CmdJSONGet, []string{"d", "$"}},
+		{"JSON.DEL d $.a", CmdJSONDel, []string{"d", "$.a"}},
+		{"JSON.NUMINCRBY d $.n 2", CmdJSONNumIncrBy, []string{"d", "$.n", "2"}},
+		{"SUBSCRIBE a b", CmdSubscribe, []string{"a", "b"}},
+		{"SUBSCRIBE", CmdInvalid, nil},
+		{"UNSUBSCRIBE", CmdUnsubscribe, nil},
+		{"psubscribe n*", CmdPSubscribe, []string{"n*"}},
+		{"PUBLISH news  hello   there ", CmdPublish, []string{"news", "hello   there"}},
+		{"PUBLISH news", CmdInvalid, nil},
+		{"XPENDING s g", CmdXPending, []string{"s", "g"}},
+		{"XPENDING s g - + 10 alice", CmdXPending, []string{"s", "g", "-", "+", "10", "alice"}},
+		{"XPENDING s g - +", CmdInvalid, nil},
 	}
 
 	for _, test := range tests {
@@ -163,3 +323,872 @@ func TestCaseSensitivity(t *testing.T) {
 		t.Errorf("Expected value 'MyValue', got '%s'", cmd.Args[1])
 	}
 }
+
+func TestConditionalWriteCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SET a 10 XX", "0"},
+		{"GET a", "NULL"},
+		{"SET a 10 NX", "1"},
+		{"SET a 20 NX GET", "10"},
+		{"GET a", "10"},
+		{"SET a 20 XX GET", "10"},
+		{"SETNX a 30", "0"},
+		{"SETNX b 30", "1"},
+		{"GETSET b 40", "30"},
+		{"GETDEL b", "40"},
+		{"GET b", "NULL"},
+		{"SET c 50 GET", "NULL"},
+		{"GET c", "50"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestCompareAndSwapCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"CAS lock NULL owner1", "1"},
+		{"CAS lock NULL owner2", "0"},
+		{"GET lock", "owner1"},
+		{"CAS lock owner2 owner3", "0"},
+		{"CAS lock owner1 owner2", "1"},
+		{"CAD lock owner1", "0"},
+		{"CAD lock owner2", "1"},
+		{"GET lock", "NULL"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestMultiKeyCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MSET a 1 b 2 c 3", ""},
+		{"MGET a b missing c", "1\n2\nNULL\n3"},
+		{"MSETNX c 4 d 5", "0"},
+		{"GET d", "NULL"},
+		{"MSETNX d 5 e 6", "1"},
+		{"DEL a b missing", "2"},
+		{"UNSET c d", ""},
+		{"MGET a b c d e", "NULL\nNULL\nNULL\nNULL\n6"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestKeyManagementCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MSET a 1 b 2", ""},
+		{"EXISTS a b a missing", "3"},
+		{"TYPE a", "string"},
+		{"TYPE missing", "none"},
+		{"RENAME missing c", "NO SUCH KEY"},
+		{"RENAME a c", ""},
+		{"MGET a c", "NULL\n1"},
+		{"RENAMENX c b", "0"},
+		{"RENAMENX c d", "1"},
+		{"RENAMENX missing e", "NO SUCH KEY"},
+		{"COPY d b", "0"},
+		{"COPY d b REPLACE", "1"},
+		{"COPY d e", "1"},
+		{"MGET b d e", "1\n1\n1"},
+		{"NUMEQUALTO 1", "3"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestDatabaseSelectionCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SET a 1", ""},
+		{"SELECT 1", ""},
+		{"GET a", "NULL"},
+		{"SET a 2", ""},
+		{"SELECT 0", ""},
+		{"GET a", "1"},
+		{"SELECT 16", "DB INDEX IS OUT OF RANGE"},
+		{"MOVE a 0", "SOURCE AND DESTINATION DB ARE THE SAME"},
+		{"MOVE a 1", "0"},
+		{"HSET h f v", "1"},
+		{"MOVE h 1", "1"},
+		{"EXISTS h", "0"},
+		{"SWAPDB 0 1", ""},
+		{"MGET a", "2"},
+		{"HGET h f", "v"},
+		{"BEGIN", ""},
+		{"SELECT 1", "NOT ALLOWED IN A TRANSACTION"},
+		{"ROLLBACK", ""},
+		{"DBSIZE", "2"},
+		{"BEGIN", ""},
+		{"FLUSHDB", ""},
+		{"DBSIZE", "0"},
+		{"SET c 3", ""},
+		{"DBSIZE", "1"},
+		{"FLUSHALL", ""},
+		{"DBSIZE", "0"},
+		{"ROLLBACK", ""},
+		{"DBSIZE", "2"},
+		{"FLUSHALL", ""},
+		{"DBSIZE", "0"},
+		{"SELECT 1", ""},
+		{"DBSIZE", "0"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestRangeCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MSET user:2 b user:1 a order:1 c user:3 d", ""},
+		{"RANGE user:1 user:9", "user:1\nuser:2\nuser:3"},
+		{"RANGE a z LIMIT 2", "order:1\nuser:1"},
+		{"PREFIX user:", "user:1\nuser:2\nuser:3"},
+		{"PREFIX missing", ""},
+		{"BEGIN", ""},
+		{"UNSET user:2", ""},
+		{"SET user:0 e", ""},
+		{"PREFIX user:", "user:0\nuser:1\nuser:3"},
+		{"ROLLBACK", ""},
+		{"PREFIX user:", "user:1\nuser:2\nuser:3"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestKeysAndScanCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MSET user:1 a user:2 b order:1 c", ""},
+		{"KEYS user:*", "user:1\nuser:2"},
+		{"KEYS *", "order:1\nuser:1\nuser:2"},
+		{"SCAN 0 COUNT 2", "757365723a32\norder:1\nuser:1"},
+		{"SCAN 757365723a32 COUNT 2", "0\nuser:2"},
+		{"SCAN 0 MATCH user:*", "0\nuser:1\nuser:2"},
+		{"SCAN zz", "INVALID CURSOR"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestKeysEqualToCommand(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MSET c 10 a 10 b 20 d 10", ""},
+		{"KEYSEQUALTO 10", "a\nc\nd"},
+		{"KEYSEQUALTO 10 LIMIT 1 1", "c"},
+		{"KEYSEQUALTO 10 LIMIT 5 1", ""},
+		{"BEGIN", ""},
+		{"SET b 10", ""},
+		{"UNSET a", ""},
+		{"KEYSEQUALTO 10", "b\nc\nd"},
+		{"NUMEQUALTO 10", "3"},
+		{"ROLLBACK", ""},
+		{"KEYSEQUALTO 10", "a\nc\nd"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestValueRangeCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MSET a 150 b 99 c 200 d 1e2 e apple f banana", ""},
+		{"COUNTRANGE 100 200", "3"},
+		{"KEYSINRANGE 100 200", "a\nc\nd"},
+		{"COUNTRANGE -inf +inf", "4"},
+		{"COUNTRANGE a c LEX", "2"},
+		{"KEYSINRANGE a c LEX", "e\nf"},
+		{"KEYSINRANGE 1 2 LEX", "a\nd"},
+		{"BEGIN", ""},
+		{"SET b 120", ""},
+		{"UNSET c", ""},
+		{"COUNTRANGE 100 200", "3"},
+		{"KEYSINRANGE 100 200", "a\nb\nd"},
+		{"ROLLBACK", ""},
+		{"KEYSINRANGE 100 200", "a\nc\nd"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestValueStatisticsCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MSET a x b x c y d x e y f z", ""},
+		{"TOPVALUES 2", "x 3\ny 2"},
+		{"HISTOGRAM", "1 1\n2 1\n3 1"},
+		{"DISTINCTVALUES", "3"},
+		{"BEGIN", ""},
+		{"MSET f x e x", ""},
+		{"TOPVALUES 0", "x 5\ny 1"},
+		{"HISTOGRAM", "1 1\n5 1"},
+		{"DISTINCTVALUES", "2"},
+		{"ROLLBACK", ""},
+		{"DISTINCTVALUES", "3"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestHashCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	wrongType := "WRONGTYPE Operation against a key holding the wrong kind of value"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"HSET user name alice age 30", "2"},
+		{"HGET user name", "alice"},
+		{"HGET user missing", "NULL"},
+		{"HINCRBY user age 2", "32"},
+		{"HINCRBY user name 1", "NOT AN INTEGER"},
+		{"HGETALL user", "age\n32\nname\nalice"},
+		{"TYPE user", "hash"},
+		{"GET user", wrongType},
+		{"GETSET user x", wrongType},
+		{"SET user x GET", wrongType},
+		{"CAS user NULL x", wrongType},
+		{"SETNX user x", wrongType},
+		{"SET user x XX", wrongType},
+		{"GETDEL user", wrongType},
+		{"CAD user x", wrongType},
+		{"TYPE user", "hash"},
+		{"SET name bob", ""},
+		{"HSET name f v", wrongType},
+		{"BEGIN", ""},
+		{"HSET user name carol", "0"},
+		{"HDEL user age", "1"},
+		{"ROLLBACK", ""},
+		{"HGETALL user", "age\n32\nname\nalice"},
+		{"HDEL user age name", "2"},
+		{"EXISTS user", "0"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestListCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"RPUSH jobs a b c", "3"},
+		{"LPUSH jobs z", "4"},
+		{"LRANGE jobs 0 -1", "z\na\nb\nc"},
+		{"LLEN jobs", "4"},
+		{"TYPE jobs", "list"},
+		{"GET jobs", "WRONGTYPE Operation against a key holding the wrong kind of value"},
+		{"BEGIN", ""},
+		{"LPOP jobs", "z"},
+		{"RPOP jobs 2", "c\nb"},
+		{"ROLLBACK", ""},
+		{"LRANGE jobs 0 -1", "z\na\nb\nc"},
+		{"LTRIM jobs 1 2", ""},
+		{"LRANGE jobs 0 -1", "a\nb"},
+		{"RPOP jobs 5", "b\na"},
+		{"LPOP jobs", "NULL"},
+		{"LLEN jobs", "0"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestBlockingPopCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"RPUSH jobs a b", "2"},
+		{"BLPOP empty jobs 1", "jobs\na"},
+		{"BRPOP jobs 1", "jobs\nb"},
+		{"BLPOP jobs 0.01", "NULL"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestSetCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	wrongType := "WRONGTYPE Operation against a key holding the wrong kind of value"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SADD a x y z", "3"},
+		{"SADD a x w", "1"},
+		{"SADD b y z q", "3"},
+		{"SMEMBERS a", "w\nx\ny\nz"},
+		{"SISMEMBER a w", "1"},
+		{"SISMEMBER a q", "0"},
+		{"SCARD a", "4"},
+		{"TYPE a", "set"},
+		{"SINTER a b", "y\nz"},
+		{"SUNION a b", "q\nw\nx\ny\nz"},
+		{"SDIFF a b", "w\nx"},
+		{"SINTER a missing", ""},
+		{"SDIFFSTORE c a b", "2"},
+		{"SMEMBERS c", "w\nx"},
+		{"SET s v", ""},
+		{"SADD s x", wrongType},
+		{"SUNION a s", wrongType},
+		{"GET a", wrongType},
+		{"SINTERSTORE s a b", "2"},
+		{"TYPE s", "set"},
+		{"BEGIN", ""},
+		{"SREM a x y", "2"},
+		{"SADD a n", "1"},
+		{"SMEMBERS a", "n\nw\nz"},
+		{"ROLLBACK", ""},
+		{"SMEMBERS a", "w\nx\ny\nz"},
+		{"SREM c w x", "2"},
+		{"EXISTS c", "0"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestSortedSetCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ZADD board 10 alice 20 bob 30 carol", "3"},
+		{"ZADD board 15 alice", "0"},
+		{"ZSCORE board alice", "15"},
+		{"ZSCORE board nobody", "NULL"},
+		{"ZINCRBY board 0.5 bob", "20.5"},
+		{"ZRANK board carol", "2"},
+		{"ZRANK board nobody", "NULL"},
+		{"ZCARD board", "3"},
+		{"TYPE board", "zset"},
+		{"ZRANGE board 0 -1", "alice\nbob\ncarol"},
+		{"ZREVRANGE board 0 0 WITHSCORES", "carol\n30"},
+		{"ZRANGEBYSCORE board (15 +inf", "bob\ncarol"},
+		{"ZRANGEBYSCORE board -inf inf WITHSCORES LIMIT 1 1", "bob\n20.5"},
+		{"GET board", "WRONGTYPE Operation against a key holding the wrong kind of value"},
+		{"BEGIN", ""},
+		{"ZADD board 100 alice", "0"},
+		{"ZREM board carol", "1"},
+		{"ZREVRANGE board 0 -1", "alice\nbob"},
+		{"ROLLBACK", ""},
+		{"ZRANGE board 0 -1 WITHSCORES", "alice\n15\nbob\n20.5\ncarol\n30"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestBitCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SETBIT active 3 1", "0"},
+		{"SETBIT active 3 1", "1"},
+		{"SETBIT active 10 1", "0"},
+		{"GETBIT active 10", "1"},
+		{"GETBIT active 99", "0"},
+		{"BITCOUNT active", "2"},
+		{"BITCOUNT active 1 -1", "1"},
+		{"BITCOUNT active 0 3 BIT", "1"},
+		{"BITPOS active 1", "3"},
+		{"BITPOS active 1 8 15 BIT", "10"},
+		{"BITPOS missing 1", "-1"},
+		{"SETBIT other 0 1", "0"},
+		{"BITOP OR both active other", "2"},
+		{"BITCOUNT both", "3"},
+		{"BITOP AND both active other", "2"},
+		{"BITCOUNT both", "0"},
+		{"BEGIN", ""},
+		{"SETBIT active 3 0", "1"},
+		{"ROLLBACK", ""},
+		{"GETBIT active 3", "1"},
+		{"HSET h f v", "1"},
+		{"GETBIT h 0", "WRONGTYPE Operation against a key holding the wrong kind of value"},
+		{"SETBIT nl 4 1", "0"},
+		{"SETBIT nl 6 1", "0"},
+		{"GET nl", `"\n"`},
+		{"MGET nl missing", "\"\\n\"\nNULL"},
+		{"SET nl2 x GET", "NULL"},
+		{"BITOP OR nl2 nl", "1"},
+		{"TOPVALUES 1", `"\n" 2`},
+		{"GETDEL nl2", `"\n"`},
+		{`SET quoted "x"`, ""},
+		{"GET quoted", `"\"x\""`},
+		{"SET plain héllo", ""},
+		{"GET plain", "héllo"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestHyperLogLogCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"PFADD mon alice bob carol", "1"},
+		{"PFADD mon alice", "0"},
+		{"PFADD tue carol dave", "1"},
+		{"PFCOUNT mon", "3"},
+		{"PFCOUNT mon tue missing", "4"},
+		{"PFMERGE week mon tue", ""},
+		{"PFCOUNT week", "4"},
+		{"TYPE week", "string"},
+		{"SET plain value", ""},
+		{"PFCOUNT plain", "WRONGTYPE Key is not a valid HyperLogLog string value"},
+		{"BEGIN", ""},
+		{"PFADD mon erin", "1"},
+		{"ROLLBACK", ""},
+		{"PFCOUNT mon", "3"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestGeoCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"GEOADD sicily 13.361389 38.115556 Palermo 15.087269 37.502669 Catania", "2"},
+		{"GEODIST sicily Palermo Catania", "166274.1516"},
+		{"GEODIST sicily Palermo Catania km", "166.2742"},
+		{"GEODIST sicily Palermo Rome", "NULL"},
+		{"GEOPOS sicily Rome", "NULL"},
+		{"GEOSEARCH sicily FROMLONLAT 15 37 BYRADIUS 200 km WITHDIST", "Catania 56.4413\nPalermo 190.4424"},
+		{"GEOSEARCH sicily FROMLONLAT 15 37 BYRADIUS 100 km", "Catania"},
+		{"GEOSEARCH sicily FROMLONLAT 15 37 BYBOX 400 400 km DESC COUNT 1", "Palermo"},
+		{"GEOSEARCH sicily FROMMEMBER Palermo BYRADIUS 10 km", "Palermo"},
+		{"GEOSEARCH sicily FROMMEMBER Rome BYRADIUS 10 km", "NO SUCH KEY"},
+		{"GEOADD sicily 0 89 Pole", "INVALID LONGITUDE,LATITUDE PAIR"},
+		{"TYPE sicily", "zset"},
+		{"BEGIN", ""},
+		{"GEOADD sicily 15.55 38.19 Messina", "1"},
+		{"GEOSEARCH sicily FROMLONLAT 15 37 BYRADIUS 200 km", "Catania\nMessina\nPalermo"},
+		{"ROLLBACK", ""},
+		{"GEOSEARCH sicily FROMLONLAT 15 37 BYRADIUS 200 km", "Catania\nPalermo"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestStreamCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"XADD temps 1-0 room kitchen c 21", "1-0"},
+		{"XADD temps 1-* room hall c 19", "1-1"},
+		{"XADD temps 1-0 room attic c 30", "STREAM ID MUST BE GREATER THAN THE LAST ONE"},
+		{"XADD temps 2 room kitchen c 22", "2-0"},
+		{"XLEN temps", "3"},
+		{"TYPE temps", "stream"},
+		{"XRANGE temps - +", "1-0 room kitchen c 21\n1-1 room hall c 19\n2-0 room kitchen c 22"},
+		{"XRANGE temps 1 1 COUNT 1", "1-0 room kitchen c 21"},
+		{"XREAD STREAMS temps 1-1", "temps 2-0 room kitchen c 22"},
+		{"XREAD STREAMS temps $", "NULL"},
+		{"XREAD BLOCK 10 STREAMS temps $", "NULL"},
+		{"XTRIM temps MAXLEN 2", "1"},
+		{"XRANGE temps - +", "1-1 room hall c 19\n2-0 room kitchen c 22"},
+		{"XGROUP CREATE temps alerts 0", ""},
+		{"XGROUP CREATE temps alerts 0", "BUSYGROUP CONSUMER GROUP ALREADY EXISTS"},
+		{"XREADGROUP GROUP alerts w1 COUNT 1 STREAMS temps >", "temps 1-1 room hall c 19"},
+		{"XREADGROUP GROUP alerts w2 STREAMS temps >", "temps 2-0 room kitchen c 22"},
+		{"XREADGROUP GROUP alerts w2 STREAMS temps >", "NULL"},
+		{"XPENDING temps alerts", "2\n1-1\n2-0\nw1 1\nw2 1"},
+		{"XACK temps alerts 1-1", "1"},
+		{"XREADGROUP GROUP alerts w2 STREAMS temps 0", "temps 2-0 room kitchen c 22"},
+		{"XPENDING temps alerts", "1\n2-0\n2-0\nw2 1"},
+		{"XREADGROUP GROUP missing w1 STREAMS temps >", "NOGROUP NO SUCH KEY OR CONSUMER GROUP"},
+		{"BEGIN", ""},
+		{"XADD temps 3-0 room hall c 18", "3-0"},
+		{"XACK temps alerts 2-0", "1"},
+		{"ROLLBACK", ""},
+		{"XLEN temps", "2"},
+		{"XPENDING temps alerts", "1\n2-0\n2-0\nw2 1"},
+		{"XACK temps alerts 2-0", "1"},
+		{"XPENDING temps alerts", "0"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+
+	// The idle time varies, so only the other columns are checked
+	executor.Execute("XADD temps 4-0 room hall c 17")
+	executor.Execute("XREADGROUP GROUP alerts w3 STREAMS temps >")
+	output, _ := executor.Execute("XPENDING temps alerts - + 10 w3")
+	if fields := strings.Fields(output); len(fields) != 4 || fields[0] != "4-0" || fields[1] != "w3" || fields[3] != "1" {
+		t.Errorf("Expected '4-0 w3 <idle> 1', got '%s'", output)
+	}
+}
+
+func TestJSONCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`JSON.SET doc $ {"name": "kv  db", "n": 1, "tags": []}`, "1"},
+		{"JSON.GET doc", `{"n":1,"name":"kv  db","tags":[]}`},
+		{"JSON.GET doc $.name", `"kv  db"`},
+		{"JSON.GET doc $.missing", "NO SUCH PATH"},
+		{"JSON.GET missing", "NULL"},
+		{`JSON.SET doc $.tags[0] "x"`, "0"},
+		{`JSON.SET doc $.tags {"a": 1} XX`, "1"},
+		{"JSON.SET doc $.extra 1 XX", "0"},
+		{"JSON.SET doc $.n 5 NX", "0"},
+		{"JSON.SET doc $ {bad", "INVALID JSON"},
+		{"JSON.SET other $.a 1", "NEW DOCUMENTS MUST BE CREATED AT THE ROOT"},
+		{"JSON.NUMINCRBY doc $.n 2.5", "3.5"},
+		{"JSON.NUMINCRBY doc $.name 1", "VALUE AT PATH IS NOT A NUMBER"},
+		{"TYPE doc", "json"},
+		{"BEGIN", ""},
+		{"JSON.DEL doc $.tags", "1"},
+		{"JSON.NUMINCRBY doc $.n 1", "4.5"},
+		{"JSON.GET doc", `{"n":4.5,"name":"kv  db"}`},
+		{"ROLLBACK", ""},
+		{"JSON.GET doc", `{"n":3.5,"name":"kv  db","tags":{"a":1}}`},
+		{"JSON.DEL doc", "1"},
+		{"TYPE doc", "none"},
+		{"JSON.SET n $ [1]", "1"},
+		{"JSON.DEL n $[0]", "1"},
+		{"JSON.GET n $", "[]"},
+		{"SET plain value", ""},
+		{"JSON.GET plain", "WRONGTYPE Operation against a key holding the wrong kind of value"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestPubSubCommands(t *testing.T) {
+	broker := pubsub.NewBroker(pubsub.DefaultBufferSize, pubsub.Disconnect)
+	subscriber := NewExecutor(database.New())
+	subscriber.UseBroker(broker)
+	publisher := NewExecutor(database.New())
+	publisher.UseBroker(broker)
+
+	steps := []struct {
+		executor *Executor
+		input    string
+		expected string
+	}{
+		{subscriber, "UNSUBSCRIBE", "unsubscribe NULL 0"},
+		{subscriber, "SUBSCRIBE a b", "subscribe a 1\nsubscribe b 2"},
+		{subscriber, "PSUBSCRIBE a*", "psubscribe a* 3"},
+		{subscriber, "SET k v", "ONLY SUBSCRIBE, UNSUBSCRIBE, PSUBSCRIBE AND PUNSUBSCRIBE ARE ALLOWED WHILE SUBSCRIBED"},
+		{publisher, "PUBLISH a hi there", "2"},
+		{publisher, "PUBLISH c hi", "0"},
+		{subscriber, "UNSUBSCRIBE b", "unsubscribe b 2"},
+		{subscriber, "UNSUBSCRIBE", "unsubscribe a 1"},
+		{subscriber, "PUNSUBSCRIBE", "punsubscribe a* 0"},
+		{subscriber, "SET k v", ""},
+	}
+
+	for _, step := range steps {
+		output, _ := step.executor.Execute(step.input)
+		if output != step.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", step.input, step.expected, output)
+		}
+	}
+
+	var messages []string
+	for len(subscriber.Messages()) > 0 {
+		messages = append(messages, FormatMessage(<-subscriber.Messages()))
+	}
+	if got := strings.Join(messages, "\n"); got != "message a hi there\npmessage a* a hi there" {
+		t.Errorf("Expected both messages, got '%s'", got)
+	}
+
+	if output, _ := NewExecutor(database.New()).Execute("PUBLISH a hi"); output != "PUBSUB REQUIRES A NETWORK CONNECTION" {
+		t.Errorf("Expected 'PUBSUB REQUIRES A NETWORK CONNECTION', got '%s'", output)
+	}
+}
+
+func TestHookErrorsAreReported(t *testing.T) {
+	db := database.New()
+	db.AddInterceptor(func(write database.Write, next func(database.Write) error) error {
+		if strings.HasPrefix(write.Key, "ro:") {
+			return errors.New("READ ONLY KEY")
+		}
+		return next(write)
+	})
+	db.AddPreCommitHook(func(changes []database.TransactionChange) error {
+		if len(changes) > 2 {
+			return errors.New("TOO MANY CHANGES")
+		}
+		return nil
+	})
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SET ro:a 1", "READ ONLY KEY"},
+		{"SET a 1", ""},
+		{"UNSET a ro:a", "READ ONLY KEY"},
+		{"GET a", "1"},
+		{"DEL ro:a", "READ ONLY KEY"},
+		{"MSET b 1 ro:b 1", "READ ONLY KEY"},
+		{"SETNX ro:a 1", "READ ONLY KEY"},
+		{"SET ro:a 1 GET", "READ ONLY KEY"},
+		{"RENAME a ro:a", "READ ONLY KEY"},
+		{"COPY a ro:a", "READ ONLY KEY"},
+		{"SETBIT ro:a 0 1", "READ ONLY KEY"},
+		{"EXISTS a b ro:a ro:b", "1"},
+		{"UNSET a", ""},
+		{"BEGIN", ""},
+		{"SET a 1", ""},
+		{"SET b 1", ""},
+		{"SET c 1", ""},
+		{"COMMIT", "TOO MANY CHANGES"},
+		{"GET c", "1"},
+		{"ROLLBACK", ""},
+		{"BEGIN", ""},
+		{"SET a 1", ""},
+		{"SET b 1", ""},
+		{"COMMIT", ""},
+		{"MGET a b c", "1\n1\nNULL"},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
+
+func TestSchemaCommands(t *testing.T) {
+	db := database.New()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SCHEMA ADD user:* REGEX ^[a-z]+( [a-z]+)?$", "1"},
+		{"SCHEMA ADD age:* RANGE 0 150", "2"},
+		{"SCHEMA ADD bio:* MAXLEN 5", "3"},
+		{"SCHEMA ADD flag:* TYPE BOOLEAN", "4"},
+		{"SCHEMA ADD x RANGE 5 1", "INVALID SCHEMA RULE"},
+		{"SCHEMA ADD x REGEX (", "INVALID SCHEMA RULE"},
+		{"SCHEMA ADD x TYPE date", "INVALID SCHEMA RULE"},
+		{"SCHEMA ADD x MAXLEN -1", ""},
+		{"SCHEMA LIST", "1 user:* REGEX ^[a-z]+( [a-z]+)?$\n2 age:* RANGE 0 150\n3 bio:* MAXLEN 5\n4 flag:* TYPE boolean"},
+		{"SET user:1 alice", ""},
+		{"SET user:2 Bob", "SCHEMA VIOLATION: RULE 1 REGEX ^[a-z]+( [a-z]+)?$ FOR user:2"},
+		{"SET age:1 151", "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:1"},
+		{"SETNX age:1 -1", "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:1"},
+		{"SET age:1 30 NX", "1"},
+		{"SET age:1 x XX", "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:1"},
+		{"GETSET age:1 x", "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:1"},
+		{"CAS age:1 30 300", "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:1"},
+		{"MSET bio:1 short bio:2 too-long", "SCHEMA VIOLATION: RULE 3 MAXLEN 5 FOR bio:2"},
+		{"MSETNX flag:1 true flag:2 yes", "SCHEMA VIOLATION: RULE 4 TYPE boolean FOR flag:2"},
+		{"MGET age:1 bio:1 flag:1", "30\nNULL\nNULL"},
+		{"SET tmp 999", ""},
+		{"RENAME tmp age:2", "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:2"},
+		{"COPY tmp age:2", "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:2"},
+		{"MOVE tmp 1", "1"},
+		{"GET age:2", "NULL"},
+		{"HSET age:3 f v", "1"},
+		{"BEGIN", ""},
+		{"SET bio:1 toolong", "SCHEMA VIOLATION: RULE 3 MAXLEN 5 FOR bio:1"},
+		{"SET bio:1 ok", ""},
+		{"COMMIT", ""},
+		{"GET bio:1", "ok"},
+		{"SCHEMA DEL 3", "1"},
+		{"SCHEMA DEL 3", "0"},
+		{"SET bio:1 no longer limited", ""},
+		{"SCHEMA LIST", "1 user:* REGEX ^[a-z]+( [a-z]+)?$\n2 age:* RANGE 0 150\n4 flag:* TYPE boolean"},
+		{"SCHEMA", ""},
+	}
+
+	for _, test := range tests {
+		output, _ := executor.Execute(test.input)
+		if output != test.expected {
+			t.Errorf("Input '%s': expected '%s', got '%s'", test.input, test.expected, output)
+		}
+	}
+}
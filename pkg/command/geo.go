@@ -0,0 +1,205 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// geoUnits maps distance units to their length in meters
+var geoUnits = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"mi": 1609.34,
+	"ft": 0.3048,
+}
+
+// parseGeoCommand parses the GEO* command family
+func parseGeoCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "GEOADD":
+		if len(args) >= 4 && (len(args)-1)%3 == 0 {
+			for i := 1; i < len(args); i += 3 {
+				if !isScore(args[i]) || !isScore(args[i+1]) {
+					return Command{Type: CmdInvalid}
+				}
+			}
+			return Command{Type: CmdGeoAdd, Args: args}
+		}
+	case "GEOPOS":
+		if len(args) >= 2 {
+			return Command{Type: CmdGeoPos, Args: args}
+		}
+	case "GEODIST":
+		if len(args) == 3 {
+			return Command{Type: CmdGeoDist, Args: []string{args[0], args[1], args[2], "m"}}
+		}
+		if len(args) == 4 && isGeoUnit(args[3]) {
+			return Command{Type: CmdGeoDist, Args: []string{args[0], args[1], args[2], strings.ToLower(args[3])}}
+		}
+	case "GEOSEARCH":
+		if len(args) >= 1 {
+			return parseGeoSearch(args)
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// parseGeoSearch parses GEOSEARCH key FROMMEMBER member|FROMLONLAT lon lat
+// BYRADIUS radius unit|BYBOX width height unit [ASC|DESC] [COUNT n]
+// [WITHDIST] [WITHCOORD] into fixed positions: key, FROMMEMBER or
+// FROMLONLAT, member, lon, lat, radius, width, height, unit, count and
+// ASC or DESC, followed by any WITHDIST and WITHCOORD flags. Unused
+// positions hold "" or "0".
+func parseGeoSearch(args []string) Command {
+	normalized := []string{args[0], "", "", "0", "0", "0", "0", "0", "", "0", "ASC"}
+	var flags []string
+	seen := make(map[string]bool)
+
+	options := args[1:]
+	for len(options) > 0 {
+		option := strings.ToUpper(options[0])
+		if seen[option] {
+			return Command{Type: CmdInvalid}
+		}
+		seen[option] = true
+
+		switch {
+		case option == "FROMMEMBER" && len(options) >= 2:
+			normalized[1], normalized[2] = option, options[1]
+			options = options[2:]
+		case option == "FROMLONLAT" && len(options) >= 3 && isScore(options[1]) && isScore(options[2]):
+			normalized[1], normalized[3], normalized[4] = option, options[1], options[2]
+			options = options[3:]
+		case option == "BYRADIUS" && len(options) >= 3 && isPositive(options[1]) && isGeoUnit(options[2]):
+			normalized[5], normalized[8] = options[1], strings.ToLower(options[2])
+			options = options[3:]
+		case option == "BYBOX" && len(options) >= 4 && isPositive(options[1]) && isPositive(options[2]) && isGeoUnit(options[3]):
+			normalized[6], normalized[7], normalized[8] = options[1], options[2], strings.ToLower(options[3])
+			options = options[4:]
+		case option == "COUNT" && len(options) >= 2 && isCount(options[1]) && options[1] != "0":
+			normalized[9] = options[1]
+			options = options[2:]
+		case option == "ASC" || option == "DESC":
+			if seen["ASC"] && seen["DESC"] {
+				return Command{Type: CmdInvalid}
+			}
+			normalized[10] = option
+			options = options[1:]
+		case option == "WITHDIST" || option == "WITHCOORD":
+			flags = append(flags, option)
+			options = options[1:]
+		default:
+			return Command{Type: CmdInvalid}
+		}
+	}
+
+	// Exactly one center and one shape are required
+	if seen["FROMMEMBER"] == seen["FROMLONLAT"] || seen["BYRADIUS"] == seen["BYBOX"] {
+		return Command{Type: CmdInvalid}
+	}
+	return Command{Type: CmdGeoSearch, Args: append(normalized, flags...)}
+}
+
+// isGeoUnit reports whether arg is a distance unit
+func isGeoUnit(arg string) bool {
+	_, ok := geoUnits[strings.ToLower(arg)]
+	return ok
+}
+
+// isPositive reports whether arg is a number greater than zero
+func isPositive(arg string) bool {
+	n, err := strconv.ParseFloat(arg, 64)
+	return err == nil && n > 0
+}
+
+// formatDistance renders a distance in meters in unit, to four decimals
+func formatDistance(meters float64, unit string) string {
+	return strconv.FormatFloat(meters/geoUnits[unit], 'f', 4, 64)
+}
+
+// formatPosition renders a longitude and latitude on one line
+func formatPosition(position [2]float64) string {
+	return formatScore(position[0]) + " " + formatScore(position[1])
+}
+
+// executeGeo runs a command from the GEO* family
+func (ce *Executor) executeGeo(cmd Command) string {
+	key := cmd.Args[0]
+
+	switch cmd.Type {
+	case CmdGeoAdd:
+		added, err := ce.database.GeoAdd(key, cmd.Args[1:]...)
+		return formatInt(added, err)
+
+	case CmdGeoPos:
+		positions, found, err := ce.database.GeoPos(key, cmd.Args[1:]...)
+		if err != nil {
+			return err.Error()
+		}
+		lines := make([]string, len(positions))
+		for i, position := range positions {
+			lines[i] = "NULL"
+			if found[i] {
+				lines[i] = formatPosition(position)
+			}
+		}
+		return strings.Join(lines, "\n")
+
+	case CmdGeoDist:
+		distance, ok, err := ce.database.GeoDist(key, cmd.Args[1], cmd.Args[2])
+		if err != nil {
+			return err.Error()
+		}
+		if !ok {
+			return "NULL"
+		}
+		return formatDistance(distance, cmd.Args[3])
+
+	case CmdGeoSearch:
+		return ce.executeGeoSearch(cmd)
+	}
+	return ""
+}
+
+// executeGeoSearch runs GEOSEARCH from its normalized arguments, printing
+// one match per line followed by its distance and position when asked
+func (ce *Executor) executeGeoSearch(cmd Command) string {
+	unit := cmd.Args[8]
+	scale := geoUnits[unit]
+	radius, _ := strconv.ParseFloat(cmd.Args[5], 64)
+	width, _ := strconv.ParseFloat(cmd.Args[6], 64)
+	height, _ := strconv.ParseFloat(cmd.Args[7], 64)
+	count, _ := strconv.Atoi(cmd.Args[9])
+	descending := cmd.Args[10] == "DESC"
+
+	var members []string
+	var distances []float64
+	var positions [][2]float64
+	var err error
+	if cmd.Args[1] == "FROMMEMBER" {
+		members, distances, positions, err = ce.database.GeoSearchFromMember(cmd.Args[0], cmd.Args[2],
+			radius*scale, width*scale, height*scale, count, descending)
+	} else {
+		longitude, _ := strconv.ParseFloat(cmd.Args[3], 64)
+		latitude, _ := strconv.ParseFloat(cmd.Args[4], 64)
+		members, distances, positions, err = ce.database.GeoSearch(cmd.Args[0], longitude, latitude,
+			radius*scale, width*scale, height*scale, count, descending)
+	}
+	if err != nil {
+		return err.Error()
+	}
+
+	withDist := hasOption(cmd.Args[11:], "WITHDIST")
+	withCoord := hasOption(cmd.Args[11:], "WITHCOORD")
+	lines := make([]string, len(members))
+	for i, member := range members {
+		lines[i] = member
+		if withDist {
+			lines[i] += " " + formatDistance(distances[i], unit)
+		}
+		if withCoord {
+			lines[i] += " " + formatPosition(positions[i])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
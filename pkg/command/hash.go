@@ -0,0 +1,71 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseHashCommand parses the H* command family
+func parseHashCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "HSET":
+		if len(args) >= 3 && len(args)%2 == 1 {
+			return Command{Type: CmdHSet, Args: args}
+		}
+	case "HGET":
+		if len(args) == 2 {
+			return Command{Type: CmdHGet, Args: args}
+		}
+	case "HDEL":
+		if len(args) >= 2 {
+			return Command{Type: CmdHDel, Args: args}
+		}
+	case "HGETALL":
+		if len(args) == 1 {
+			return Command{Type: CmdHGetAll, Args: args}
+		}
+	case "HINCRBY":
+		if len(args) == 3 && isInteger(args[2]) {
+			return Command{Type: CmdHIncrBy, Args: args}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// executeHash runs a command from the H* family
+func (ce *Executor) executeHash(cmd Command) string {
+	key := cmd.Args[0]
+
+	switch cmd.Type {
+	case CmdHSet:
+		created, err := ce.database.HSet(key, cmd.Args[1:]...)
+		return formatInt(created, err)
+
+	case CmdHGet:
+		value, err := ce.database.HGet(key, cmd.Args[1])
+		if err != nil {
+			return err.Error()
+		}
+		return value
+
+	case CmdHDel:
+		removed, err := ce.database.HDel(key, cmd.Args[1:]...)
+		return formatInt(removed, err)
+
+	case CmdHGetAll:
+		pairs, err := ce.database.HGetAll(key)
+		if err != nil {
+			return err.Error()
+		}
+		return strings.Join(pairs, "\n")
+
+	case CmdHIncrBy:
+		increment, _ := strconv.ParseInt(cmd.Args[2], 10, 64)
+		result, err := ce.database.HIncrBy(key, cmd.Args[1], increment)
+		if err != nil {
+			return err.Error()
+		}
+		return strconv.FormatInt(result, 10)
+	}
+	return ""
+}
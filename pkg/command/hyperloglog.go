@@ -0,0 +1,47 @@
+package command
+
+import "strconv"
+
+// parseHyperLogLogCommand parses the PF* HyperLogLog command family
+func parseHyperLogLogCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "PFADD":
+		if len(args) >= 1 {
+			return Command{Type: CmdPFAdd, Args: args}
+		}
+	case "PFCOUNT":
+		if len(args) >= 1 {
+			return Command{Type: CmdPFCount, Args: args}
+		}
+	case "PFMERGE":
+		if len(args) >= 1 {
+			return Command{Type: CmdPFMerge, Args: args}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// executeHyperLogLog runs a command from the PF* HyperLogLog family
+func (ce *Executor) executeHyperLogLog(cmd Command) string {
+	switch cmd.Type {
+	case CmdPFAdd:
+		changed, err := ce.database.PFAdd(cmd.Args[0], cmd.Args[1:]...)
+		if err != nil {
+			return err.Error()
+		}
+		return formatBool(changed)
+
+	case CmdPFCount:
+		count, err := ce.database.PFCount(cmd.Args...)
+		if err != nil {
+			return err.Error()
+		}
+		return strconv.Itoa(count)
+
+	case CmdPFMerge:
+		if err := ce.database.PFMerge(cmd.Args[0], cmd.Args[1:]...); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
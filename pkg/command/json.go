@@ -0,0 +1,76 @@
+package command
+
+import "strings"
+
+// parseJSONCommand parses the JSON.* document command family. JSON.SET
+// takes its value from the raw input, since JSON may contain spaces.
+func parseJSONCommand(cmdName string, args []string, input string) Command {
+	switch cmdName {
+	case "JSON.SET":
+		if len(args) >= 3 {
+			return parseJSONSet(args, input)
+		}
+	case "JSON.GET", "JSON.DEL":
+		cmdType := CmdJSONGet
+		if cmdName == "JSON.DEL" {
+			cmdType = CmdJSONDel
+		}
+		if len(args) == 1 {
+			return Command{Type: cmdType, Args: []string{args[0], "$"}}
+		}
+		if len(args) == 2 {
+			return Command{Type: cmdType, Args: args}
+		}
+	case "JSON.NUMINCRBY":
+		if len(args) == 3 {
+			return Command{Type: CmdJSONNumIncrBy, Args: args}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// parseJSONSet normalizes JSON.SET key path value [NX|XX] to Args of
+// key, path, value and option, where option is "" if none was given
+func parseJSONSet(args []string, input string) Command {
+	value := restOfLine(input, 3)
+
+	option := strings.ToUpper(args[len(args)-1])
+	if len(args) > 3 && (option == "NX" || option == "XX") {
+		value = strings.TrimSpace(value[:len(value)-len(option)])
+	} else {
+		option = ""
+	}
+
+	return Command{Type: CmdJSONSet, Args: []string{args[0], args[1], value, option}}
+}
+
+// executeJSON runs a command from the JSON.* document family
+func (ce *Executor) executeJSON(cmd Command) string {
+	switch cmd.Type {
+	case CmdJSONSet:
+		option := cmd.Args[3]
+		ok, err := ce.database.JSONSet(cmd.Args[0], cmd.Args[1], cmd.Args[2], option == "NX", option == "XX")
+		if err != nil {
+			return err.Error()
+		}
+		return formatBool(ok)
+
+	case CmdJSONGet:
+		result, err := ce.database.JSONGet(cmd.Args[0], cmd.Args[1])
+		if err != nil {
+			return err.Error()
+		}
+		return result
+
+	case CmdJSONDel:
+		return formatInt(ce.database.JSONDel(cmd.Args[0], cmd.Args[1]))
+
+	case CmdJSONNumIncrBy:
+		result, err := ce.database.JSONNumIncrBy(cmd.Args[0], cmd.Args[1], cmd.Args[2])
+		if err != nil {
+			return err.Error()
+		}
+		return result
+	}
+	return ""
+}
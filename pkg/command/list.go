@@ -0,0 +1,136 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseListCommand parses the list command family
+func parseListCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "LPUSH", "RPUSH":
+		if len(args) >= 2 {
+			return Command{Type: listCommandTypes[cmdName], Args: args}
+		}
+	case "LPOP", "RPOP":
+		if len(args) == 1 || (len(args) == 2 && isCount(args[1])) {
+			return Command{Type: listCommandTypes[cmdName], Args: args}
+		}
+	case "LRANGE", "LTRIM":
+		if len(args) == 3 && isInteger(args[1]) && isInteger(args[2]) {
+			return Command{Type: listCommandTypes[cmdName], Args: args}
+		}
+	case "BLPOP", "BRPOP":
+		if len(args) >= 2 && isTimeout(args[len(args)-1]) {
+			return Command{Type: listCommandTypes[cmdName], Args: args}
+		}
+	case "LLEN":
+		if len(args) == 1 {
+			return Command{Type: CmdLLen, Args: args}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// listCommandTypes maps list command names to their types
+var listCommandTypes = map[string]CommandType{
+	"LPUSH":  CmdLPush,
+	"RPUSH":  CmdRPush,
+	"LPOP":   CmdLPop,
+	"RPOP":   CmdRPop,
+	"LRANGE": CmdLRange,
+	"LTRIM":  CmdLTrim,
+	"BLPOP":  CmdBLPop,
+	"BRPOP":  CmdBRPop,
+}
+
+// isTimeout reports whether arg is a non-negative number of seconds
+func isTimeout(arg string) bool {
+	seconds, err := strconv.ParseFloat(arg, 64)
+	return err == nil && seconds >= 0 && seconds <= maxTimeoutSeconds
+}
+
+// maxTimeoutSeconds caps blocking timeouts so they fit in a time.Duration
+const maxTimeoutSeconds = 1e9
+
+// executeList runs a command from the list family
+func (ce *Executor) executeList(cmd Command) string {
+	key := cmd.Args[0]
+
+	if cmd.Type == CmdBLPop || cmd.Type == CmdBRPop {
+		return ce.executeBlockingPop(cmd)
+	}
+
+	switch cmd.Type {
+	case CmdLPush:
+		length, err := ce.database.LPush(key, cmd.Args[1:]...)
+		return formatInt(length, err)
+
+	case CmdRPush:
+		length, err := ce.database.RPush(key, cmd.Args[1:]...)
+		return formatInt(length, err)
+
+	case CmdLPop, CmdRPop:
+		count := 1
+		if len(cmd.Args) == 2 {
+			count, _ = strconv.Atoi(cmd.Args[1])
+		}
+
+		pop := ce.database.LPop
+		if cmd.Type == CmdRPop {
+			pop = ce.database.RPop
+		}
+		popped, err := pop(key, count)
+		if err != nil {
+			return err.Error()
+		}
+		if popped == nil {
+			return "NULL"
+		}
+		return strings.Join(popped, "\n")
+
+	case CmdLRange:
+		start, _ := strconv.Atoi(cmd.Args[1])
+		stop, _ := strconv.Atoi(cmd.Args[2])
+		elements, err := ce.database.LRange(key, start, stop)
+		if err != nil {
+			return err.Error()
+		}
+		return strings.Join(elements, "\n")
+
+	case CmdLLen:
+		length, err := ce.database.LLen(key)
+		return formatInt(length, err)
+
+	case CmdLTrim:
+		start, _ := strconv.Atoi(cmd.Args[1])
+		stop, _ := strconv.Atoi(cmd.Args[2])
+		if err := ce.database.LTrim(key, start, stop); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+	return ""
+}
+
+// executeBlockingPop runs BLPOP or BRPOP, printing the key and the element,
+// or "NULL" if the timeout expired first
+func (ce *Executor) executeBlockingPop(cmd Command) string {
+	keys := cmd.Args[:len(cmd.Args)-1]
+	seconds, _ := strconv.ParseFloat(cmd.Args[len(cmd.Args)-1], 64)
+	timeout := time.Duration(seconds * float64(time.Second))
+
+	pop := ce.database.BLPop
+	if cmd.Type == CmdBRPop {
+		pop = ce.database.BRPop
+	}
+	key, value, ok, err := pop(keys, timeout)
+	if err != nil {
+		return err.Error()
+	}
+	if !ok {
+		return "NULL"
+	}
+	return key + "\n" + value
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"simple-database/pkg/pubsub"
+	"strconv"
+	"strings"
+)
+
+// noBrokerMessage is printed for the pub/sub commands when the executor
+// has no broker, as when reading standard input
+const noBrokerMessage = "PUBSUB REQUIRES A NETWORK CONNECTION"
+
+// subscribedMessage is printed for other commands while the client has
+// subscriptions, since its connection is then in push mode
+const subscribedMessage = "ONLY SUBSCRIBE, UNSUBSCRIBE, PSUBSCRIBE AND PUNSUBSCRIBE ARE ALLOWED WHILE SUBSCRIBED"
+
+// parsePubSubCommand parses the publish/subscribe command family. PUBLISH
+// takes its message from the raw input, so it may contain spaces.
+func parsePubSubCommand(cmdName string, args []string, input string) Command {
+	switch cmdName {
+	case "SUBSCRIBE":
+		if len(args) >= 1 {
+			return Command{Type: CmdSubscribe, Args: args}
+		}
+	case "PSUBSCRIBE":
+		if len(args) >= 1 {
+			return Command{Type: CmdPSubscribe, Args: args}
+		}
+	case "UNSUBSCRIBE":
+		return Command{Type: CmdUnsubscribe, Args: args}
+	case "PUNSUBSCRIBE":
+		return Command{Type: CmdPUnsubscribe, Args: args}
+	case "PUBLISH":
+		if len(args) >= 2 {
+			return Command{Type: CmdPublish, Args: []string{args[0], restOfLine(input, 2)}}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// UseBroker enables the pub/sub commands, which publish to and subscribe
+// on broker
+func (ce *Executor) UseBroker(broker *pubsub.Broker) {
+	ce.broker = broker
+}
+
+// Messages returns the channel the messages for this client's
+// subscriptions arrive on, or nil before its first subscription. The
+// channel is closed if the client is disconnected for falling behind.
+func (ce *Executor) Messages() <-chan pubsub.Message {
+	if ce.subscriber == nil {
+		return nil
+	}
+	return ce.subscriber.Messages()
+}
+
+// Close drops this client's subscriptions
+func (ce *Executor) Close() {
+	if ce.subscriber != nil {
+		ce.subscriber.Close()
+	}
+}
+
+// subscribed reports whether the client has subscriptions, which limits
+// it to the subscription commands
+func (ce *Executor) subscribed() bool {
+	return ce.subscriber != nil && ce.subscriber.Count() > 0
+}
+
+// allowedWhileSubscribed reports whether a command can run while the
+// client has subscriptions
+func allowedWhileSubscribed(cmdType CommandType) bool {
+	switch cmdType {
+	case CmdSubscribe, CmdPSubscribe, CmdUnsubscribe, CmdPUnsubscribe, CmdEnd, CmdInvalid:
+		return true
+	}
+	return false
+}
+
+// executePubSub runs a command from the publish/subscribe family
+func (ce *Executor) executePubSub(cmd Command) string {
+	if ce.broker == nil {
+		return noBrokerMessage
+	}
+	if cmd.Type == CmdPublish {
+		return strconv.Itoa(ce.broker.Publish(cmd.Args[0], cmd.Args[1]))
+	}
+
+	if ce.subscriber == nil {
+		ce.subscriber = ce.broker.NewSubscriber()
+	}
+
+	switch cmd.Type {
+	case CmdSubscribe:
+		return formatSubscriptions("subscribe", cmd.Args, ce.subscriber.Subscribe(cmd.Args...))
+	case CmdPSubscribe:
+		return formatSubscriptions("psubscribe", cmd.Args, ce.subscriber.PSubscribe(cmd.Args...))
+	case CmdUnsubscribe:
+		names, counts := ce.subscriber.Unsubscribe(cmd.Args...)
+		return formatSubscriptions("unsubscribe", names, counts)
+	case CmdPUnsubscribe:
+		names, counts := ce.subscriber.PUnsubscribe(cmd.Args...)
+		return formatSubscriptions("punsubscribe", names, counts)
+	}
+	return ""
+}
+
+// formatSubscriptions renders one "kind name count" line per channel or
+// pattern, where count is the number of subscriptions left after it.
+// Unsubscribing with no subscriptions prints a single line for "NULL".
+func formatSubscriptions(kind string, names []string, counts []int) string {
+	if len(names) == 0 {
+		return kind + " NULL 0"
+	}
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		count := 0
+		if i < len(counts) {
+			count = counts[i]
+		}
+		lines[i] = kind + " " + name + " " + strconv.Itoa(count)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatMessage renders a pushed message as "message channel payload", or
+// "pmessage pattern channel payload" for a pattern subscription
+func FormatMessage(message pubsub.Message) string {
+	if message.Pattern != "" {
+		return "pmessage " + message.Pattern + " " + message.Channel + " " + message.Payload
+	}
+	return "message " + message.Channel + " " + message.Payload
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"simple-database/pkg/schema"
+	"strconv"
+	"strings"
+)
+
+// parseSchemaCommand parses SCHEMA ADD, LIST and DEL. SCHEMA ADD is
+// normalized to Args of pattern, kind and the kind's arguments; a regular
+// expression is taken from the raw input, so it may contain spaces.
+func parseSchemaCommand(args []string, input string) Command {
+	if len(args) == 0 {
+		return Command{Type: CmdInvalid}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "ADD":
+		if len(args) >= 4 {
+			return parseSchemaAdd(args[1:], input)
+		}
+	case "LIST":
+		if len(args) == 1 {
+			return Command{Type: CmdSchemaList}
+		}
+	case "DEL":
+		if len(args) == 2 && isCount(args[1]) {
+			return Command{Type: CmdSchemaDel, Args: args[1:]}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// parseSchemaAdd parses the pattern, kind and arguments of SCHEMA ADD
+func parseSchemaAdd(args []string, input string) Command {
+	pattern, kind := args[0], strings.ToUpper(args[1])
+	switch kind {
+	case "REGEX":
+		return Command{Type: CmdSchemaAdd, Args: []string{pattern, kind, restOfLine(input, 4)}}
+	case "RANGE":
+		if len(args) == 4 && isInteger(args[2]) && isInteger(args[3]) {
+			return Command{Type: CmdSchemaAdd, Args: []string{pattern, kind, args[2], args[3]}}
+		}
+	case "MAXLEN":
+		if len(args) == 3 && isCount(args[2]) {
+			return Command{Type: CmdSchemaAdd, Args: []string{pattern, kind, args[2]}}
+		}
+	case "TYPE":
+		if len(args) == 3 {
+			return Command{Type: CmdSchemaAdd, Args: []string{pattern, kind, strings.ToLower(args[2])}}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// executeSchema runs a command from the SCHEMA family
+func (ce *Executor) executeSchema(cmd Command) string {
+	switch cmd.Type {
+	case CmdSchemaAdd:
+		kind, _ := schema.ParseKind(cmd.Args[1])
+		rule := schema.Rule{Pattern: cmd.Args[0], Kind: kind}
+		switch kind {
+		case schema.Regex:
+			rule.Regex = cmd.Args[2]
+		case schema.Range:
+			rule.Min, _ = strconv.ParseInt(cmd.Args[2], 10, 64)
+			rule.Max, _ = strconv.ParseInt(cmd.Args[3], 10, 64)
+		case schema.MaxLen:
+			rule.MaxLen, _ = strconv.Atoi(cmd.Args[2])
+		case schema.Type:
+			rule.Type = cmd.Args[2]
+		}
+		return formatInt(ce.database.AddRule(rule))
+
+	case CmdSchemaList:
+		rules := ce.database.Rules()
+		lines := make([]string, len(rules))
+		for i, rule := range rules {
+			lines[i] = strconv.Itoa(rule.ID) + " " + rule.Pattern + " " + rule.Spec()
+		}
+		return strings.Join(lines, "\n")
+
+	case CmdSchemaDel:
+		id, _ := strconv.Atoi(cmd.Args[0])
+		return formatBool(ce.database.RemoveRule(id))
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"strings"
+)
+
+// parseSetCommand parses the S* set command family
+func parseSetCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "SADD", "SREM":
+		if len(args) >= 2 {
+			return Command{Type: setCommandTypes[cmdName], Args: args}
+		}
+	case "SISMEMBER":
+		if len(args) == 2 {
+			return Command{Type: CmdSIsMember, Args: args}
+		}
+	case "SMEMBERS", "SCARD":
+		if len(args) == 1 {
+			return Command{Type: setCommandTypes[cmdName], Args: args}
+		}
+	case "SINTER", "SUNION", "SDIFF":
+		if len(args) >= 1 {
+			return Command{Type: setCommandTypes[cmdName], Args: args}
+		}
+	case "SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE":
+		if len(args) >= 2 {
+			return Command{Type: setCommandTypes[cmdName], Args: args}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// setCommandTypes maps set command names to their types
+var setCommandTypes = map[string]CommandType{
+	"SADD":        CmdSAdd,
+	"SREM":        CmdSRem,
+	"SMEMBERS":    CmdSMembers,
+	"SISMEMBER":   CmdSIsMember,
+	"SCARD":       CmdSCard,
+	"SINTER":      CmdSInter,
+	"SUNION":      CmdSUnion,
+	"SDIFF":       CmdSDiff,
+	"SINTERSTORE": CmdSInterStore,
+	"SUNIONSTORE": CmdSUnionStore,
+	"SDIFFSTORE":  CmdSDiffStore,
+}
+
+// executeSetFamily runs a command from the S* set family
+func (ce *Executor) executeSetFamily(cmd Command) string {
+	key := cmd.Args[0]
+
+	switch cmd.Type {
+	case CmdSAdd:
+		added, err := ce.database.SAdd(key, cmd.Args[1:]...)
+		return formatInt(added, err)
+
+	case CmdSRem:
+		removed, err := ce.database.SRem(key, cmd.Args[1:]...)
+		return formatInt(removed, err)
+
+	case CmdSIsMember:
+		isMember, err := ce.database.SIsMember(key, cmd.Args[1])
+		if err != nil {
+			return err.Error()
+		}
+		return formatBool(isMember)
+
+	case CmdSCard:
+		return formatInt(ce.database.SCard(key))
+
+	case CmdSMembers:
+		return formatMembers(ce.database.SMembers(key))
+
+	case CmdSInter:
+		return formatMembers(ce.database.SInter(cmd.Args...))
+
+	case CmdSUnion:
+		return formatMembers(ce.database.SUnion(cmd.Args...))
+
+	case CmdSDiff:
+		return formatMembers(ce.database.SDiff(cmd.Args...))
+
+	case CmdSInterStore:
+		return formatInt(ce.database.SInterStore(key, cmd.Args[1:]...))
+
+	case CmdSUnionStore:
+		return formatInt(ce.database.SUnionStore(key, cmd.Args[1:]...))
+
+	case CmdSDiffStore:
+		return formatInt(ce.database.SDiffStore(key, cmd.Args[1:]...))
+	}
+	return ""
+}
+
+// formatMembers prints one member per line, or the error if there is one
+func formatMembers(members []string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return strings.Join(members, "\n")
+}
@@ -0,0 +1,284 @@
+package command
+
+import (
+	"simple-database/pkg/storage"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseStreamCommand parses the X* stream command family
+func parseStreamCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "XADD":
+		if len(args) >= 4 && len(args)%2 == 0 && isNewStreamID(args[1]) {
+			return Command{Type: CmdXAdd, Args: args}
+		}
+	case "XLEN":
+		if len(args) == 1 {
+			return Command{Type: CmdXLen, Args: args}
+		}
+	case "XRANGE":
+		if (len(args) == 3 || len(args) == 5) && isRangeID(args[1], "-") && isRangeID(args[2], "+") {
+			count := "-1"
+			if len(args) == 5 {
+				if strings.ToUpper(args[3]) != "COUNT" || !isCount(args[4]) {
+					return Command{Type: CmdInvalid}
+				}
+				count = args[4]
+			}
+			return Command{Type: CmdXRange, Args: []string{args[0], args[1], args[2], count}}
+		}
+	case "XTRIM":
+		if len(args) == 3 {
+			strategy := strings.ToUpper(args[1])
+			if (strategy == "MAXLEN" && isCount(args[2])) || (strategy == "MINID" && isStreamID(args[2])) {
+				return Command{Type: CmdXTrim, Args: []string{args[0], strategy, args[2]}}
+			}
+		}
+	case "XREAD":
+		return parseStreamRead(CmdXRead, nil, args)
+	case "XREADGROUP":
+		if len(args) >= 3 && strings.ToUpper(args[0]) == "GROUP" {
+			return parseStreamRead(CmdXReadGroup, args[1:3], args[3:])
+		}
+	case "XGROUP":
+		if (len(args) == 4 || len(args) == 5) && strings.ToUpper(args[0]) == "CREATE" &&
+			(args[3] == "$" || isStreamID(args[3])) {
+			if len(args) == 4 {
+				return Command{Type: CmdXGroupCreate, Args: args[1:]}
+			}
+			if strings.ToUpper(args[4]) == "MKSTREAM" {
+				return Command{Type: CmdXGroupCreate, Args: []string{args[1], args[2], args[3], "MKSTREAM"}}
+			}
+		}
+	case "XACK":
+		if len(args) >= 3 {
+			for _, id := range args[2:] {
+				if !isStreamID(id) {
+					return Command{Type: CmdInvalid}
+				}
+			}
+			return Command{Type: CmdXAck, Args: args}
+		}
+	case "XPENDING":
+		if len(args) == 2 {
+			return Command{Type: CmdXPending, Args: args}
+		}
+		if (len(args) == 5 || len(args) == 6) && isRangeID(args[2], "-") && isRangeID(args[3], "+") && isCount(args[4]) {
+			return Command{Type: CmdXPending, Args: args}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// parseStreamRead parses [COUNT n] [BLOCK ms] STREAMS key [key ...] id
+// [id ...] into Args of the leading arguments (the group and consumer for
+// XREADGROUP), count, block, the keys and then the IDs. A missing COUNT or
+// BLOCK becomes -1.
+func parseStreamRead(cmdType CommandType, leading, args []string) Command {
+	count, block := "-1", "-1"
+	seen := make(map[string]bool)
+
+	for len(args) > 0 {
+		option := strings.ToUpper(args[0])
+		if seen[option] {
+			return Command{Type: CmdInvalid}
+		}
+		seen[option] = true
+
+		switch {
+		case option == "COUNT" && len(args) >= 2 && isCount(args[1]):
+			count = args[1]
+			args = args[2:]
+		case option == "BLOCK" && len(args) >= 2 && isBlockTimeout(args[1]):
+			block = args[1]
+			args = args[2:]
+		case option == "STREAMS" && len(args) >= 3 && len(args)%2 == 1:
+			streams := args[1:]
+			for _, id := range streams[len(streams)/2:] {
+				validID := isStreamID(id) || (id == "$" && cmdType == CmdXRead) || (id == ">" && cmdType == CmdXReadGroup)
+				if !validID {
+					return Command{Type: CmdInvalid}
+				}
+			}
+			normalized := append(append([]string{}, leading...), count, block)
+			return Command{Type: cmdType, Args: append(normalized, streams...)}
+		default:
+			return Command{Type: CmdInvalid}
+		}
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// isStreamID reports whether arg is "ms" or "ms-seq"
+func isStreamID(arg string) bool {
+	_, ok := storage.ParseStreamID(arg, 0)
+	return ok
+}
+
+// isNewStreamID reports whether arg is an ID XADD accepts: "*", "ms-*" or
+// an explicit ID
+func isNewStreamID(arg string) bool {
+	if arg == "*" {
+		return true
+	}
+	if ms, found := strings.CutSuffix(arg, "-*"); found {
+		_, err := strconv.ParseUint(ms, 10, 64)
+		return err == nil
+	}
+	return isStreamID(arg)
+}
+
+// isRangeID reports whether arg is a stream ID or the special bound
+func isRangeID(arg, special string) bool {
+	return arg == special || isStreamID(arg)
+}
+
+// isBlockTimeout reports whether arg is a non-negative number of
+// milliseconds that fits in a time.Duration
+func isBlockTimeout(arg string) bool {
+	ms, err := strconv.Atoi(arg)
+	return err == nil && ms >= 0 && ms <= maxTimeoutSeconds*1000
+}
+
+// formatEntry renders a stream entry as its ID followed by its fields and
+// values on one line
+func formatEntry(entry storage.StreamEntry) string {
+	return strings.Join(append([]string{entry.ID.String()}, entry.Fields...), " ")
+}
+
+// formatStreamRead renders the result of XREAD or XREADGROUP, one entry
+// per line prefixed with its stream's key, or "NULL" if there are none
+func formatStreamRead(keys []string, results [][]storage.StreamEntry, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if len(keys) == 0 {
+		return "NULL"
+	}
+
+	var lines []string
+	for i, key := range keys {
+		for _, entry := range results[i] {
+			lines = append(lines, key+" "+formatEntry(entry))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// executeStream runs a command from the X* stream family
+func (ce *Executor) executeStream(cmd Command) string {
+	switch cmd.Type {
+	case CmdXAdd:
+		id, err := ce.database.XAdd(cmd.Args[0], cmd.Args[1], cmd.Args[2:]...)
+		if err != nil {
+			return err.Error()
+		}
+		return id
+
+	case CmdXLen:
+		return formatInt(ce.database.XLen(cmd.Args[0]))
+
+	case CmdXRange:
+		count, _ := strconv.Atoi(cmd.Args[3])
+		entries, err := ce.database.XRange(cmd.Args[0], cmd.Args[1], cmd.Args[2], count)
+		if err != nil {
+			return err.Error()
+		}
+		lines := make([]string, len(entries))
+		for i, entry := range entries {
+			lines[i] = formatEntry(entry)
+		}
+		return strings.Join(lines, "\n")
+
+	case CmdXTrim:
+		return formatInt(ce.database.XTrim(cmd.Args[0], cmd.Args[1], cmd.Args[2]))
+
+	case CmdXRead:
+		count, block, streams := streamReadArgs(cmd.Args)
+		keys, ids := streams[:len(streams)/2], streams[len(streams)/2:]
+		return formatStreamRead(ce.database.XRead(keys, ids, count, block))
+
+	case CmdXReadGroup:
+		count, block, streams := streamReadArgs(cmd.Args[2:])
+		keys, ids := streams[:len(streams)/2], streams[len(streams)/2:]
+		return formatStreamRead(ce.database.XReadGroup(cmd.Args[0], cmd.Args[1], keys, ids, count, block))
+
+	case CmdXGroupCreate:
+		mkStream := hasOption(cmd.Args[3:], "MKSTREAM")
+		if err := ce.database.XGroupCreate(cmd.Args[0], cmd.Args[1], cmd.Args[2], mkStream); err != nil {
+			return err.Error()
+		}
+		return ""
+
+	case CmdXAck:
+		return formatInt(ce.database.XAck(cmd.Args[0], cmd.Args[1], cmd.Args[2:]...))
+
+	case CmdXPending:
+		return ce.executeXPending(cmd)
+	}
+	return ""
+}
+
+// streamReadArgs splits the normalized count and block from the keys and
+// IDs of XREAD and XREADGROUP. A negative block means no blocking.
+func streamReadArgs(args []string) (int, time.Duration, []string) {
+	count, _ := strconv.Atoi(args[0])
+	ms, _ := strconv.Atoi(args[1])
+	block := time.Duration(-1)
+	if ms >= 0 {
+		block = time.Duration(ms) * time.Millisecond
+	}
+	return count, block, args[2:]
+}
+
+// executeXPending prints a summary of a group's pending entries (their
+// number, the smallest and largest ID, then each consumer with its number
+// of entries), or with a range, one line per entry of its ID, consumer,
+// milliseconds since delivery and delivery count
+func (ce *Executor) executeXPending(cmd Command) string {
+	start, end, count, consumer := "-", "+", -1, ""
+	if len(cmd.Args) > 2 {
+		start, end = cmd.Args[2], cmd.Args[3]
+		count, _ = strconv.Atoi(cmd.Args[4])
+	}
+	if len(cmd.Args) > 5 {
+		consumer = cmd.Args[5]
+	}
+
+	pending, err := ce.database.XPending(cmd.Args[0], cmd.Args[1], start, end, count, consumer)
+	if err != nil {
+		return err.Error()
+	}
+
+	if len(cmd.Args) > 2 {
+		lines := make([]string, len(pending))
+		for i, entry := range pending {
+			idle := time.Since(entry.DeliveredAt).Milliseconds()
+			lines[i] = strings.Join([]string{entry.ID.String(), entry.Consumer,
+				strconv.FormatInt(idle, 10), strconv.Itoa(entry.DeliveryCount)}, " ")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if len(pending) == 0 {
+		return "0"
+	}
+	perConsumer := make(map[string]int)
+	for _, entry := range pending {
+		perConsumer[entry.Consumer]++
+	}
+	consumers := make([]string, 0, len(perConsumer))
+	for name := range perConsumer {
+		consumers = append(consumers, name)
+	}
+	sort.Strings(consumers)
+
+	lines := []string{strconv.Itoa(len(pending)), pending[0].ID.String(), pending[len(pending)-1].ID.String()}
+	for _, name := range consumers {
+		lines = append(lines, name+" "+strconv.Itoa(perConsumer[name]))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,237 @@
+package command
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseSortedSetCommand parses the Z* sorted set command family
+func parseSortedSetCommand(cmdName string, args []string) Command {
+	switch cmdName {
+	case "ZADD":
+		if len(args) >= 3 && len(args)%2 == 1 {
+			for i := 1; i < len(args); i += 2 {
+				if !isScore(args[i]) {
+					return Command{Type: CmdInvalid}
+				}
+			}
+			return Command{Type: CmdZAdd, Args: args}
+		}
+	case "ZREM":
+		if len(args) >= 2 {
+			return Command{Type: CmdZRem, Args: args}
+		}
+	case "ZSCORE", "ZRANK":
+		if len(args) == 2 {
+			return Command{Type: sortedSetCommandTypes[cmdName], Args: args}
+		}
+	case "ZINCRBY":
+		if len(args) == 3 && isScore(args[1]) {
+			return Command{Type: CmdZIncrBy, Args: args}
+		}
+	case "ZCARD":
+		if len(args) == 1 {
+			return Command{Type: CmdZCard, Args: args}
+		}
+	case "ZRANGE", "ZREVRANGE":
+		return parseRankRange(sortedSetCommandTypes[cmdName], args)
+	case "ZRANGEBYSCORE":
+		return parseScoreRange(args)
+	}
+	return Command{Type: CmdInvalid}
+}
+
+// sortedSetCommandTypes maps sorted set command names to their types
+var sortedSetCommandTypes = map[string]CommandType{
+	"ZSCORE":    CmdZScore,
+	"ZRANK":     CmdZRank,
+	"ZRANGE":    CmdZRange,
+	"ZREVRANGE": CmdZRevRange,
+}
+
+// parseRankRange parses key start stop [WITHSCORES], normalizing the
+// option to uppercase
+func parseRankRange(cmdType CommandType, args []string) Command {
+	if len(args) < 3 || len(args) > 4 || !isInteger(args[1]) || !isInteger(args[2]) {
+		return Command{Type: CmdInvalid}
+	}
+	if len(args) == 4 {
+		if strings.ToUpper(args[3]) != "WITHSCORES" {
+			return Command{Type: CmdInvalid}
+		}
+		return Command{Type: cmdType, Args: []string{args[0], args[1], args[2], "WITHSCORES"}}
+	}
+	return Command{Type: cmdType, Args: args}
+}
+
+// parseScoreRange parses key min max [WITHSCORES] [LIMIT offset count]
+// into Args of key, min, max, offset, count and an optional WITHSCORES.
+// A missing LIMIT becomes offset 0 and count -1 (no limit).
+func parseScoreRange(args []string) Command {
+	if len(args) < 3 || !isScoreBound(args[1]) || !isScoreBound(args[2]) {
+		return Command{Type: CmdInvalid}
+	}
+
+	offset, count, withScores := "0", "-1", false
+	options := args[3:]
+	seen := make(map[string]bool)
+
+	for len(options) > 0 {
+		option := strings.ToUpper(options[0])
+		if seen[option] {
+			return Command{Type: CmdInvalid}
+		}
+		seen[option] = true
+
+		switch option {
+		case "WITHSCORES":
+			withScores = true
+			options = options[1:]
+		case "LIMIT":
+			if len(options) < 3 || !isCount(options[1]) || !isInteger(options[2]) {
+				return Command{Type: CmdInvalid}
+			}
+			offset, count = options[1], options[2]
+			options = options[3:]
+		default:
+			return Command{Type: CmdInvalid}
+		}
+	}
+
+	normalized := []string{args[0], args[1], args[2], offset, count}
+	if withScores {
+		normalized = append(normalized, "WITHSCORES")
+	}
+	return Command{Type: CmdZRangeByScore, Args: normalized}
+}
+
+// isScore reports whether arg is a valid score
+func isScore(arg string) bool {
+	score, err := strconv.ParseFloat(arg, 64)
+	return err == nil && !math.IsNaN(score)
+}
+
+// isScoreBound reports whether arg is a valid ZRANGEBYSCORE bound
+func isScoreBound(arg string) bool {
+	_, ok := parseScoreBound(arg, true)
+	return ok
+}
+
+// parseScoreBound parses a score range bound. A leading "(" makes the
+// bound exclusive, which is turned into the nearest inclusive float in the
+// direction of the range: upward for a lower bound, downward for an upper
+// one.
+func parseScoreBound(bound string, lower bool) (float64, bool) {
+	exclusive := strings.HasPrefix(bound, "(")
+	if exclusive {
+		bound = bound[1:]
+	}
+
+	score, err := strconv.ParseFloat(bound, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, false
+	}
+
+	if exclusive {
+		if lower {
+			score = math.Nextafter(score, math.Inf(1))
+		} else {
+			score = math.Nextafter(score, math.Inf(-1))
+		}
+	}
+	return score, true
+}
+
+// formatScore renders a score the way it was most likely written
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// formatScoredMembers prints one member per line, each followed by its
+// score when withScores is set, or the error if there is one
+func formatScoredMembers(members []string, scores []float64, err error, withScores bool) string {
+	if err != nil {
+		return err.Error()
+	}
+	if !withScores {
+		return strings.Join(members, "\n")
+	}
+
+	lines := make([]string, 0, 2*len(members))
+	for i, member := range members {
+		lines = append(lines, member, formatScore(scores[i]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// executeSortedSet runs a command from the Z* sorted set family
+func (ce *Executor) executeSortedSet(cmd Command) string {
+	key := cmd.Args[0]
+
+	switch cmd.Type {
+	case CmdZAdd:
+		added, err := ce.database.ZAdd(key, cmd.Args[1:]...)
+		return formatInt(added, err)
+
+	case CmdZRem:
+		removed, err := ce.database.ZRem(key, cmd.Args[1:]...)
+		return formatInt(removed, err)
+
+	case CmdZScore:
+		score, exists, err := ce.database.ZScore(key, cmd.Args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if !exists {
+			return "NULL"
+		}
+		return formatScore(score)
+
+	case CmdZIncrBy:
+		increment, _ := strconv.ParseFloat(cmd.Args[1], 64)
+		score, err := ce.database.ZIncrBy(key, cmd.Args[2], increment)
+		if err != nil {
+			return err.Error()
+		}
+		return formatScore(score)
+
+	case CmdZRank:
+		rank, exists, err := ce.database.ZRank(key, cmd.Args[1])
+		if err != nil {
+			return err.Error()
+		}
+		if !exists {
+			return "NULL"
+		}
+		return strconv.Itoa(rank)
+
+	case CmdZCard:
+		return formatInt(ce.database.ZCard(key))
+
+	case CmdZRange, CmdZRevRange:
+		start, _ := strconv.Atoi(cmd.Args[1])
+		stop, _ := strconv.Atoi(cmd.Args[2])
+		rangeFunc := ce.database.ZRange
+		if cmd.Type == CmdZRevRange {
+			rangeFunc = ce.database.ZRevRange
+		}
+		members, scores, err := rangeFunc(key, start, stop)
+		return formatScoredMembers(members, scores, err, hasOption(cmd.Args[3:], "WITHSCORES"))
+
+	case CmdZRangeByScore:
+		min, _ := parseScoreBound(cmd.Args[1], true)
+		max, _ := parseScoreBound(cmd.Args[2], false)
+		offset, _ := strconv.Atoi(cmd.Args[3])
+		count, _ := strconv.Atoi(cmd.Args[4])
+		members, scores, err := ce.database.ZRangeByScore(key, min, max, offset, count)
+		return formatScoredMembers(members, scores, err, hasOption(cmd.Args[5:], "WITHSCORES"))
+	}
+	return ""
+}
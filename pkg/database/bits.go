@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"simple-database/pkg/storage"
+)
+
+// MaxBitOffset is the largest bit offset SETBIT accepts, which caps a
+// bitmap at 512MB
+const MaxBitOffset = 1<<32 - 1
+
+var (
+	ErrBitOffset = errors.New("BIT OFFSET IS NOT AN INTEGER OR OUT OF RANGE")
+	ErrNotBit    = errors.New("BIT IS NOT 0 OR 1")
+)
+
+// SetBit sets the bit at offset in the string at key to bit and returns
+// the previous bit. A missing key is treated as an empty string, and the
+// value grows with zero bytes to reach offset. The bitmap is an ordinary
+// string, so every call copies the whole value and stores it again like
+// Set, moving it in the value index: the cost grows with the size of the
+// bitmap, not with the one bit that changed.
+func (db *Database) SetBit(key string, offset, bit int) (int, error) {
+	if offset < 0 || offset > MaxBitOffset {
+		return 0, ErrBitOffset
+	}
+	if bit != 0 && bit != 1 {
+		return 0, ErrNotBit
+	}
+
+	old := 0
+	err := db.intercept([]Write{{Key: key, Implied: true}}, func([]Write) error {
+		value, err := db.getBits(key)
+		if err != nil {
+			return err
+		}
+
+		value, old = storage.SetBit(value, offset, bit)
+		return db.set(key, value)
+	})
+	return old, err
+}
+
+// GetBit returns the bit at offset in the string at key
+func (db *Database) GetBit(key string, offset int) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	if offset < 0 {
+		return 0, ErrBitOffset
+	}
+
+	value, err := db.getBits(key)
+	return storage.GetBit(value, offset), err
+}
+
+// BitCount counts the set bits of the string at key between start and end
+// (inclusive). The positions are bytes, or bits when bitUnit is set, and
+// negative positions count from the end.
+func (db *Database) BitCount(key string, start, end int, bitUnit bool) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	value, err := db.getBits(key)
+	return storage.BitCount(value, start, end, bitUnit), err
+}
+
+// BitPos returns the offset of the first bit equal to bit in the string at
+// key between start and end (inclusive), or -1 if there is none. Positions
+// are as in BitCount. When end is -1, a search for 0 in a value of all
+// ones returns the first offset past the value.
+func (db *Database) BitPos(key string, bit, start, end int, bitUnit bool) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	if bit != 0 && bit != 1 {
+		return 0, ErrNotBit
+	}
+
+	value, err := db.getBits(key)
+	if err != nil {
+		return 0, err
+	}
+	return storage.BitPos(value, bit, start, end, bitUnit), nil
+}
+
+// BitOp stores the bitwise AND, OR, XOR or NOT of the strings at keys in
+// destination and returns its length in bytes. Missing keys count as
+// empty strings, shorter values are padded with zero bytes, and an empty
+// result removes destination.
+func (db *Database) BitOp(op, destination string, keys ...string) (int, error) {
+	length := 0
+	err := db.intercept([]Write{{Key: destination, Implied: true}}, func([]Write) error {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			value, err := db.getBits(key)
+			if err != nil {
+				return err
+			}
+			values[i] = value
+		}
+
+		result := storage.BitOp(op, values)
+		if result == "" {
+			db.unset(destination)
+			return nil
+		}
+		if err := db.set(destination, result); err != nil {
+			return err
+		}
+		length = len(result)
+		return nil
+	})
+	return length, err
+}
+
+// getBits returns the visible string at key, or "" if the key is missing
+func (db *Database) getBits(key string) (string, error) {
+	switch db.typeOf(key) {
+	case storage.TypeNone:
+		return "", nil
+	case storage.TypeString:
+		return db.get(key), nil
+	}
+	return "", ErrWrongType
+}
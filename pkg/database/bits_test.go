@@ -0,0 +1,154 @@
+package database
+
+import "testing"
+
+func TestSetBitAndGetBit(t *testing.T) {
+	db := New()
+
+	if old, err := db.SetBit("flags", 7, 1); old != 0 || err != nil {
+		t.Errorf("Expected (0, nil), got (%d, %v)", old, err)
+	}
+	if got, _ := db.Get("flags"); got != "\x01" {
+		t.Errorf("Expected '\\x01', got %q", got)
+	}
+
+	// Setting a bit past the end pads the value with zero bytes
+	db.SetBit("flags", 17, 1)
+	if got, _ := db.Get("flags"); got != "\x01\x00\x40" {
+		t.Errorf("Expected '\\x01\\x00\\x40', got %q", got)
+	}
+
+	if old, _ := db.SetBit("flags", 7, 0); old != 1 {
+		t.Errorf("Expected 1, got %d", old)
+	}
+
+	tests := []struct {
+		offset   int
+		expected int
+	}{
+		{7, 0},
+		{17, 1},
+		{100, 0},
+	}
+	for _, test := range tests {
+		if got, _ := db.GetBit("flags", test.offset); got != test.expected {
+			t.Errorf("Offset %d: expected %d, got %d", test.offset, test.expected, got)
+		}
+	}
+
+	if got, _ := db.GetBit("missing", 3); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if _, err := db.SetBit("flags", MaxBitOffset+1, 1); err != ErrBitOffset {
+		t.Errorf("Expected ErrBitOffset, got %v", err)
+	}
+}
+
+func TestBitCountAndBitPos(t *testing.T) {
+	db := New()
+	db.Set("s", "\xff\xf0\x00")
+	db.Set("ones", "\xff\xff")
+
+	counts := []struct {
+		start, end int
+		bitUnit    bool
+		expected   int
+	}{
+		{0, -1, false, 12},
+		{1, 1, false, 4},
+		{-1, -1, false, 0},
+		{5, 10, true, 6},
+		{5, 2, false, 0},
+	}
+	for _, test := range counts {
+		if got, _ := db.BitCount("s", test.start, test.end, test.bitUnit); got != test.expected {
+			t.Errorf("BitCount %d %d: expected %d, got %d", test.start, test.end, test.expected, got)
+		}
+	}
+
+	positions := []struct {
+		key        string
+		bit        int
+		start, end int
+		bitUnit    bool
+		expected   int
+	}{
+		{"s", 0, 0, -1, false, 12},
+		{"s", 1, 1, -1, false, 8},
+		{"s", 1, 2, -1, false, -1},
+		{"s", 1, 10, -1, true, 10},
+		{"ones", 0, 0, -1, false, 16},
+		{"ones", 0, 0, 1, false, -1},
+		{"missing", 0, 0, -1, false, 0},
+		{"missing", 1, 0, -1, false, -1},
+	}
+	for _, test := range positions {
+		if got, _ := db.BitPos(test.key, test.bit, test.start, test.end, test.bitUnit); got != test.expected {
+			t.Errorf("BitPos %s %d %d %d: expected %d, got %d", test.key, test.bit, test.start, test.end, test.expected, got)
+		}
+	}
+}
+
+func TestBitOp(t *testing.T) {
+	db := New()
+	db.Set("a", "\xf0\x0f")
+	db.Set("b", "\xff")
+
+	tests := []struct {
+		op       string
+		keys     []string
+		expected string
+	}{
+		{"AND", []string{"a", "b"}, "\xf0\x00"},
+		{"OR", []string{"a", "b"}, "\xff\x0f"},
+		{"XOR", []string{"a", "b", "missing"}, "\x0f\x0f"},
+		{"NOT", []string{"b"}, "\x00"},
+	}
+	for _, test := range tests {
+		length, err := db.BitOp(test.op, "dest", test.keys...)
+		if got, _ := db.Get("dest"); got != test.expected || length != len(test.expected) || err != nil {
+			t.Errorf("%s: expected (%q, %d), got (%q, %d, %v)", test.op, test.expected, len(test.expected), got, length, err)
+		}
+	}
+
+	// An empty result removes the destination
+	db.BitOp("OR", "dest", "missing")
+	if got := db.Exists("dest"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	db.HSet("hash", "f", "v")
+	if _, err := db.BitOp("AND", "dest", "a", "hash"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+	if _, err := db.SetBit("hash", 0, 1); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+}
+
+func TestBitsKeepValueIndexConsistent(t *testing.T) {
+	db := New()
+	db.Set("x", "@")
+	db.Set("y", "A")
+
+	// '@' is 0x40; setting its lowest bit turns it into 'A' (0x41)
+	db.Begin()
+	db.SetBit("x", 7, 1)
+	if got := db.NumEqualTo("A"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+	db.Rollback()
+
+	if got := db.NumEqualTo("@"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	db.SetBit("x", 7, 1)
+	if got := db.NumEqualTo("A"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+	if got := db.NumEqualTo("@"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
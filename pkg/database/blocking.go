@@ -0,0 +1,233 @@
+package database
+
+import (
+	"simple-database/pkg/storage"
+	"time"
+)
+
+// waiter is a client blocked in BLPOP or BRPOP
+type waiter struct {
+	keys   []string
+	left   bool
+	served bool
+	result chan [2]string
+}
+
+// BLPop pops the head of the first non-empty list among keys. If every list
+// is empty it waits up to timeout (forever if timeout is zero) for another
+// session to push and commit, and returns the key and the element, or ok
+// false on timeout. Clients waiting on the same key are served in the order
+// they started waiting. Inside a transaction it never blocks.
+func (db *Database) BLPop(keys []string, timeout time.Duration) (key, value string, ok bool, err error) {
+	return db.blockingPop(keys, true, timeout)
+}
+
+// BRPop is like BLPop but pops from the tail of the list
+func (db *Database) BRPop(keys []string, timeout time.Duration) (key, value string, ok bool, err error) {
+	return db.blockingPop(keys, false, timeout)
+}
+
+// Blocked returns the number of clients blocked in BLPOP, BRPOP or a
+// stream read waiting on key in the selected database
+func (db *Database) Blocked(key string) int {
+	db.lock()
+	defer db.unlock()
+
+	return len(db.keyspace.waiters[key]) + len(db.keyspace.streamWaiters[key])
+}
+
+// blockingPop implements BLPop and BRPop
+func (db *Database) blockingPop(keys []string, left bool, timeout time.Duration) (string, string, bool, error) {
+	db.lock()
+	for _, key := range keys {
+		popped, err := db.pop(key, left, 1)
+		if err != nil || len(popped) > 0 {
+			db.unlock()
+			if err != nil {
+				return "", "", false, err
+			}
+			return key, popped[0], true, nil
+		}
+	}
+
+	if db.transactions.InTransaction() {
+		db.unlock()
+		return "", "", false, nil
+	}
+
+	w := &waiter{keys: keys, left: left, result: make(chan [2]string, 1)}
+	for _, key := range keys {
+		db.keyspace.waiters[key] = append(db.keyspace.waiters[key], w)
+	}
+	db.unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case result := <-w.result:
+		return result[0], result[1], true, nil
+	case <-expired:
+	case <-db.closed:
+	}
+
+	db.lock()
+	defer db.unlock()
+
+	// An element may have been handed over while the lock was released
+	if w.served {
+		result := <-w.result
+		return result[0], result[1], true, nil
+	}
+	db.keyspace.removeWaiter(w)
+	return "", "", false, nil
+}
+
+// streamWaiter is a client blocked reading streams
+type streamWaiter struct {
+	keys []string
+	wake chan struct{}
+}
+
+// blockingRead calls read, which must be safe to repeat, until it finds
+// entries. While it finds none and block is not negative, it waits up to
+// block (forever if zero) for entries to be committed to one of the streams
+// at keys, then reads again. Inside a transaction it never waits. It must
+// be called with the lock held and releases it.
+func (db *Database) blockingRead(keys []string, block time.Duration, read func() ([]string, [][]storage.StreamEntry, error)) ([]string, [][]storage.StreamEntry, error) {
+	var expired <-chan time.Time
+	if block > 0 {
+		timer := time.NewTimer(block)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		names, entries, err := read()
+		if err != nil || len(names) > 0 || block < 0 || db.transactions.InTransaction() {
+			db.unlock()
+			return names, entries, err
+		}
+
+		w := &streamWaiter{keys: keys, wake: make(chan struct{}, 1)}
+		for _, key := range keys {
+			if db.keyspace.streamWaiters[key] == nil {
+				db.keyspace.streamWaiters[key] = make(map[*streamWaiter]bool)
+			}
+			db.keyspace.streamWaiters[key][w] = true
+		}
+		db.unlock()
+
+		timedOut := false
+		select {
+		case <-w.wake:
+		case <-expired:
+			timedOut = true
+		case <-db.closed:
+			timedOut = true
+		}
+
+		db.lock()
+		for _, key := range keys {
+			delete(db.keyspace.streamWaiters[key], w)
+			if len(db.keyspace.streamWaiters[key]) == 0 {
+				delete(db.keyspace.streamWaiters, key)
+			}
+		}
+		if timedOut {
+			db.unlock()
+			return nil, nil, nil
+		}
+	}
+}
+
+// wakeStreamReaders wakes the clients blocked reading the stream at key so
+// they read it again. It must be called with the lock held.
+func (ks *keyspace) wakeStreamReaders(key string) {
+	for w := range ks.streamWaiters[key] {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// markReady notes that key may now hold committed list elements for
+// blocked clients
+func (ks *keyspace) markReady(key string) {
+	if len(ks.waiters[key]) > 0 {
+		ks.ready[key] = true
+	}
+}
+
+// wakeAll rechecks the keys of every client blocked on the keyspace, after
+// its data was replaced
+func (ks *keyspace) wakeAll() {
+	for key := range ks.waiters {
+		ks.ready[key] = true
+	}
+	for key := range ks.streamWaiters {
+		ks.wakeStreamReaders(key)
+	}
+}
+
+// serveBlocked hands committed elements of ready lists to blocked clients,
+// oldest waiter first, in every database. It must be called with the lock
+// held.
+func (db *Database) serveBlocked() {
+	for _, ks := range db.shared.keyspaces {
+		for len(ks.ready) > 0 {
+			for key := range ks.ready {
+				delete(ks.ready, key)
+				ks.serveKey(key)
+			}
+		}
+	}
+}
+
+// serveKey pops elements of the committed list at key for as long as both
+// elements and waiters remain
+func (ks *keyspace) serveKey(key string) {
+	for len(ks.waiters[key]) > 0 {
+		list, _ := ks.storage.GetObject(key).(storage.List)
+		if len(list) == 0 {
+			return
+		}
+
+		w := ks.waiters[key][0]
+		value := list[0]
+		if !w.left {
+			value = list[len(list)-1]
+		}
+
+		patch := &storage.ListPatch{}
+		patch.Pop(w.left, value)
+		ks.applyPatch(key, patch)
+
+		w.served = true
+		w.result <- [2]string{key, value}
+		ks.removeWaiter(w)
+	}
+}
+
+// removeWaiter drops a waiter from the queue of every key it waits on
+func (ks *keyspace) removeWaiter(w *waiter) {
+	for _, key := range w.keys {
+		queue := ks.waiters[key]
+		for i, queued := range queue {
+			if queued == w {
+				queue = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+		if len(queue) == 0 {
+			delete(ks.waiters, key)
+		} else {
+			ks.waiters[key] = queue
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until n clients are waiting on key
+func waitForWaiters(t *testing.T, db *Database, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if db.Blocked(key) == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d waiters on '%s'", n, key)
+}
+
+type popResult struct {
+	key   string
+	value string
+	ok    bool
+}
+
+// blockingPopAsync runs BLPop in the background and returns its result channel
+func blockingPopAsync(db *Database, keys []string, timeout time.Duration) chan popResult {
+	results := make(chan popResult, 1)
+	go func() {
+		key, value, ok, _ := db.BLPop(keys, timeout)
+		results <- popResult{key, value, ok}
+	}()
+	return results
+}
+
+func TestBlockingPopReturnsImmediately(t *testing.T) {
+	db := New()
+	db.RPush("b", "1", "2")
+
+	key, value, ok, err := db.BRPop([]string{"a", "b"}, time.Second)
+	if !ok || err != nil || key != "b" || value != "2" {
+		t.Errorf("Expected (b, 2, true, nil), got (%s, %s, %v, %v)", key, value, ok, err)
+	}
+
+	db.Set("s", "x")
+	if _, _, _, err := db.BLPop([]string{"s"}, time.Second); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+}
+
+func TestBlockingPopTimesOut(t *testing.T) {
+	db := New()
+
+	start := time.Now()
+	if _, _, ok, _ := db.BLPop([]string{"empty"}, 20*time.Millisecond); ok {
+		t.Error("Expected timeout")
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("Expected BLPOP to wait for the timeout")
+	}
+
+	db.lock()
+	defer db.unlock()
+	if len(db.keyspace.waiters) != 0 {
+		t.Errorf("Expected no waiters left, got %d", len(db.keyspace.waiters))
+	}
+}
+
+func TestBlockingPopWakesOnPush(t *testing.T) {
+	db := New()
+	consumer := db.NewSession()
+
+	results := blockingPopAsync(consumer, []string{"jobs"}, 0)
+	waitForWaiters(t, db, "jobs", 1)
+
+	db.RPush("jobs", "job1", "job2")
+
+	result := <-results
+	if !result.ok || result.key != "jobs" || result.value != "job1" {
+		t.Errorf("Expected (jobs, job1), got %+v", result)
+	}
+
+	if got, _ := db.LLen("jobs"); got != 1 {
+		t.Errorf("Expected 1 element left, got %d", got)
+	}
+}
+
+func TestBlockingPopServesWaitersInOrder(t *testing.T) {
+	db := New()
+
+	first := blockingPopAsync(db.NewSession(), []string{"jobs"}, 0)
+	waitForWaiters(t, db, "jobs", 1)
+	second := blockingPopAsync(db.NewSession(), []string{"other", "jobs"}, 0)
+	waitForWaiters(t, db, "jobs", 2)
+
+	db.RPush("jobs", "job1")
+	if result := <-first; result.value != "job1" {
+		t.Errorf("Expected first waiter to get job1, got %+v", result)
+	}
+
+	db.RPush("jobs", "job2")
+	if result := <-second; result.value != "job2" {
+		t.Errorf("Expected second waiter to get job2, got %+v", result)
+	}
+
+	db.lock()
+	defer db.unlock()
+	if len(db.keyspace.waiters) != 0 {
+		t.Errorf("Expected no waiters left, got %d", len(db.keyspace.waiters))
+	}
+}
+
+func TestBlockingPopIgnoresUncommittedPushes(t *testing.T) {
+	db := New()
+	producer := db.NewSession()
+
+	results := blockingPopAsync(db.NewSession(), []string{"jobs"}, 0)
+	waitForWaiters(t, db, "jobs", 1)
+
+	producer.Begin()
+	producer.RPush("jobs", "job1")
+
+	select {
+	case result := <-results:
+		t.Fatalf("Expected no element before commit, got %+v", result)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := producer.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if result := <-results; result.value != "job1" {
+		t.Errorf("Expected job1, got %+v", result)
+	}
+}
+
+func TestBlockingPopConflictsWithTransactionalPop(t *testing.T) {
+	db := New()
+	consumer := db.NewSession()
+	producer := db.NewSession()
+	db.RPush("jobs", "job1")
+
+	// The transaction takes job1, but until it commits the consumer can too
+	db.Begin()
+	if popped, _ := db.LPop("jobs", 1); len(popped) != 1 || popped[0] != "job1" {
+		t.Errorf("Expected [job1], got %v", popped)
+	}
+	if result := <-blockingPopAsync(consumer, []string{"jobs"}, 0); result.value != "job1" {
+		t.Errorf("Expected job1, got '%s'", result.value)
+	}
+
+	// A blocked consumer is woken by the next push and served from it
+	results := blockingPopAsync(consumer, []string{"jobs"}, 0)
+	waitForWaiters(t, db, "jobs", 1)
+	producer.RPush("jobs", "job2", "job3")
+	if result := <-results; result.value != "job2" {
+		t.Errorf("Expected job2, got '%s'", result.value)
+	}
+
+	// job1 was handed out twice, so the transaction can't commit, and the
+	// element nobody received is still queued
+	if err := db.Commit(); err != ErrConflict {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	db.Rollback()
+	elements, _ := db.LRange("jobs", 0, -1)
+	if got := strings.Join(elements, ","); got != "job3" {
+		t.Errorf("Expected 'job3', got '%s'", got)
+	}
+}
+
+func TestBlockingPopInTransactionDoesNotBlock(t *testing.T) {
+	db := New()
+	db.Begin()
+
+	if _, _, ok, _ := db.BLPop([]string{"jobs"}, 0); ok {
+		t.Error("Expected BLPOP on an empty list inside a transaction to return at once")
+	}
+}
+
+func TestCloseWakesBlockedSession(t *testing.T) {
+	db := New()
+	session := db.NewSession()
+
+	results := blockingPopAsync(session, []string{"jobs"}, 0)
+	waitForWaiters(t, db, "jobs", 1)
+
+	session.Close()
+	if result := <-results; result.ok {
+		t.Errorf("Expected no element, got %+v", result)
+	}
+
+	// Elements pushed afterwards stay in the list
+	db.RPush("jobs", "job1")
+	if got, _ := db.LLen("jobs"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestSessionsHaveSeparateTransactions(t *testing.T) {
+	db := New()
+	other := db.NewSession()
+
+	db.Begin()
+	db.Set("a", "1")
+
+	if got, _ := other.Get("a"); got != "NULL" {
+		t.Errorf("Expected 'NULL' in the other session, got '%s'", got)
+	}
+
+	if err := other.Commit(); err != ErrNoTransaction {
+		t.Errorf("Expected ErrNoTransaction, got %v", err)
+	}
+
+	db.Commit()
+	if got, _ := other.Get("a"); got != "1" {
+		t.Errorf("Expected '1', got '%s'", got)
+	}
+}
@@ -1,98 +1,726 @@
 package database
 
-import "simple-database/pkg/storage"
+import (
+	"errors"
+	"simple-database/pkg/schema"
+	"simple-database/pkg/storage"
+	"sort"
+	"sync"
+)
 
-// Database represents an in-memory key-value store with transaction support
+var (
+	ErrNoSuchKey     = errors.New("NO SUCH KEY")
+	ErrInvalidCursor = errors.New("INVALID CURSOR")
+	ErrWrongType     = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+)
+
+// Database represents an in-memory key-value store with transaction support.
+// It hosts several independent numbered databases, each a keyspace with its
+// own storage and value counts. Sessions created with NewSession share the
+// stored data but each has its own selected database and stack of
+// transactions; every method is safe for concurrent use.
 type Database struct {
-	storage      *storage.Storage
+	shared       *shared
+	keyspace     *keyspace
+	index        int
 	transactions *TransactionManager
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+// shared holds the state common to every session of a database
+type shared struct {
+	mu        sync.Mutex
+	keyspaces []*keyspace
+	watchers  *watchers
+	hooks     hooks
+	schema    schema.Schema
 }
 
-// New creates a new database instance
+// DefaultDatabases is the number of databases New creates
+const DefaultDatabases = 16
+
+// New creates a new database instance with DefaultDatabases databases
 func New() *Database {
+	return NewWithDatabases(DefaultDatabases)
+}
+
+// NewWithDatabases creates a new database instance hosting count numbered
+// databases, at least one. Sessions start in database 0.
+func NewWithDatabases(count int) *Database {
+	state := &shared{
+		keyspaces: make([]*keyspace, max(count, 1)),
+		watchers:  newWatchers(),
+	}
+	for i := range state.keyspaces {
+		state.keyspaces[i] = newKeyspace(i, state.watchers)
+	}
+	return newSession(state)
+}
+
+// newSession creates a session on top of shared state, in database 0
+func newSession(state *shared) *Database {
 	return &Database{
-		storage:      storage.New(),
+		shared:       state,
+		keyspace:     state.keyspaces[0],
 		transactions: NewTransactionManager(),
+		closed:       make(chan struct{}),
 	}
 }
 
-// Set stores a key-value pair
-func (db *Database) Set(key, value string) {
-	if db.transactions.InTransaction() {
-		db.transactions.Set(key, value, db.storage.Get(key))
-	} else {
-		oldValue := db.storage.Get(key)
-		db.storage.Set(key, value)
-		db.storage.UpdateValueCount(oldValue, value)
+// NewSession creates another session on the same data, typically one per
+// client connection. Its transactions are independent of this session's,
+// and it starts in database 0.
+func (db *Database) NewSession() *Database {
+	return newSession(db.shared)
+}
+
+// Close ends the session: a blocking command it is waiting in returns right
+// away, and later blocking commands no longer wait. Uncommitted transactions
+// are simply dropped along with the session.
+func (db *Database) Close() {
+	db.closeOnce.Do(func() { close(db.closed) })
+}
+
+// lock acquires the lock shared by every session
+func (db *Database) lock() {
+	db.shared.mu.Lock()
+}
+
+// unlock hands any newly committed list elements to blocked clients and
+// releases the shared lock
+func (db *Database) unlock() {
+	db.serveBlocked()
+	db.shared.mu.Unlock()
+}
+
+// Set stores a key-value pair, passing the write through the interceptors.
+// It returns an error wrapping schema.ErrViolation if the value breaks a rule
+// for the key.
+func (db *Database) Set(key, value string) error {
+	return db.writeThrough(setWrites([]string{key, value}))
+}
+
+// Get retrieves a value by key, returns "NULL" if not found, or
+// ErrWrongType if the key holds another type
+func (db *Database) Get(key string) (string, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.getString(key)
+}
+
+// Unset removes a key-value pair, passing the removal through the
+// interceptors
+func (db *Database) Unset(key string) error {
+	return db.writeThrough(unsetWrites(key))
+}
+
+// MGet retrieves the values of several keys, using "NULL" for missing ones
+func (db *Database) MGet(keys ...string) []string {
+	db.lock()
+	defer db.unlock()
+
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = db.get(key)
 	}
+	return values
 }
 
-// Get retrieves a value by key, returns "NULL" if not found
-func (db *Database) Get(key string) string {
-	if db.transactions.InTransaction() {
-		if value, found := db.transactions.Get(key); found {
-			return value
+// MSet stores several key-value pairs given as alternating keys and values.
+// All pairs land in the same transaction layer when a transaction is active,
+// and if an interceptor rejects one, none is written.
+func (db *Database) MSet(pairs ...string) error {
+	return db.writeThrough(setWrites(pairs))
+}
+
+// MSetNX stores several key-value pairs only if none of the keys exist.
+// Either every pair is written or none is.
+func (db *Database) MSetNX(pairs ...string) (bool, error) {
+	applied := false
+	err := db.intercept(setWrites(pairs), func(writes []Write) error {
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if db.exists(pairs[i]) {
+				return nil
+			}
+		}
+		_, err := db.write(writes)
+		applied = err == nil
+		return err
+	})
+	return applied, err
+}
+
+// Del removes several keys and returns how many of them existed. If an
+// interceptor rejects one of the removals, no key is removed.
+func (db *Database) Del(keys ...string) (int, error) {
+	removed := 0
+	err := db.intercept(unsetWrites(keys...), func(writes []Write) error {
+		var err error
+		removed, err = db.write(writes)
+		return err
+	})
+	return removed, err
+}
+
+// Exists returns how many of the given keys exist. A key mentioned
+// several times is counted each time.
+func (db *Database) Exists(keys ...string) int {
+	db.lock()
+	defer db.unlock()
+
+	count := 0
+	for _, key := range keys {
+		if db.exists(key) {
+			count++
 		}
 	}
-	return db.storage.Get(key)
+	return count
 }
 
-// Unset removes a key-value pair
-func (db *Database) Unset(key string) {
-	currentValue := db.Get(key)
-	if currentValue == "NULL" {
-		return
+// Type returns the type of the value stored at key, or "none" if it is missing
+func (db *Database) Type(key string) string {
+	db.lock()
+	defer db.unlock()
+
+	return db.typeOf(key).String()
+}
+
+// Rename moves the value of key to newKey, overwriting newKey if it exists.
+// Both writes land in the same transaction layer when a transaction is active.
+func (db *Database) Rename(key, newKey string) error {
+	return db.intercept(moveWrites(key, newKey), func([]Write) error {
+		return db.rename(key, newKey)
+	})
+}
+
+// rename moves the value of key, of any type, to newKey
+func (db *Database) rename(key, newKey string) error {
+	if !db.exists(key) {
+		return ErrNoSuchKey
+	}
+	if key == newKey {
+		return nil
 	}
 
-	if db.transactions.InTransaction() {
-		db.transactions.Unset(key, currentValue)
-	} else {
-		db.storage.Unset(key)
-		db.storage.DecrementValueCount(currentValue)
+	value, object := db.get(key), db.getObject(key)
+	if object == nil {
+		if err := db.shared.schema.Check(newKey, value); err != nil {
+			return err
+		}
 	}
+	db.unset(key)
+	return db.store(newKey, value, object)
+}
+
+// RenameNX moves the value of key to newKey only if newKey does not exist.
+// It reports whether the rename happened.
+func (db *Database) RenameNX(key, newKey string) (bool, error) {
+	applied := false
+	err := db.intercept(moveWrites(key, newKey), func([]Write) error {
+		if !db.exists(key) {
+			return ErrNoSuchKey
+		}
+		if db.exists(newKey) {
+			return nil
+		}
+		applied = true
+		return db.rename(key, newKey)
+	})
+	return applied, err
+}
+
+// Copy stores the value of source under destination. Unless replace is set,
+// an existing destination is left alone. It reports whether the copy happened.
+func (db *Database) Copy(source, destination string, replace bool) (bool, error) {
+	applied := false
+	err := db.intercept([]Write{{Key: destination, Implied: true}}, func([]Write) error {
+		if !db.exists(source) || source == destination {
+			return nil
+		}
+		if !replace && db.exists(destination) {
+			return nil
+		}
+
+		if err := db.store(destination, db.get(source), db.getObject(source)); err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
+
+// SetNX stores a key-value pair only if the key does not exist yet.
+// It returns the previous value and whether the write happened, or
+// ErrWrongType if the key holds another type.
+func (db *Database) SetNX(key, value string) (string, bool, error) {
+	return db.setIf(key, value, func(oldValue string) bool {
+		return oldValue == "NULL"
+	})
+}
+
+// SetXX stores a key-value pair only if the key already exists.
+// It returns the previous value and whether the write happened, or
+// ErrWrongType if the key holds another type.
+func (db *Database) SetXX(key, value string) (string, bool, error) {
+	return db.setIf(key, value, func(oldValue string) bool {
+		return oldValue != "NULL"
+	})
+}
+
+// GetSet stores a key-value pair and returns the previous value, or
+// ErrWrongType without writing if the key holds another type
+func (db *Database) GetSet(key, value string) (string, error) {
+	oldValue, _, err := db.setIf(key, value, func(string) bool {
+		return true
+	})
+	return oldValue, err
+}
+
+// CompareAndSwap stores newValue only if the key currently holds expected.
+// An expected value of "NULL" matches a missing key. It returns
+// ErrWrongType if the key holds another type.
+func (db *Database) CompareAndSwap(key, expected, newValue string) (bool, error) {
+	_, applied, err := db.setIf(key, newValue, func(current string) bool {
+		return current == expected
+	})
+	return applied, err
+}
+
+// setIf passes the write of value to key through the interceptors and
+// applies it if the string at key satisfies condition. It returns the
+// previous value and whether the write happened, or ErrWrongType if the
+// key holds another type.
+func (db *Database) setIf(key, value string, condition func(oldValue string) bool) (string, bool, error) {
+	var oldValue string
+	applied := false
+	err := db.intercept(setWrites([]string{key, value}), func(writes []Write) error {
+		var err error
+		if oldValue, err = db.getString(key); err != nil || !condition(oldValue) {
+			return err
+		}
+		_, err = db.write(writes)
+		applied = err == nil
+		return err
+	})
+	return oldValue, applied, err
+}
+
+// GetDel removes a string key and returns the value it held, or
+// ErrWrongType if the key holds another type
+func (db *Database) GetDel(key string) (string, error) {
+	value, _, err := db.unsetIf(key, func(string) bool {
+		return true
+	})
+	return value, err
+}
+
+// CompareAndDelete removes the key only if it currently holds expected.
+// It returns ErrWrongType if the key holds another type.
+func (db *Database) CompareAndDelete(key, expected string) (bool, error) {
+	_, removed, err := db.unsetIf(key, func(current string) bool {
+		return current == expected
+	})
+	return removed, err
+}
+
+// unsetIf passes the removal of key through the interceptors and applies
+// it if the key holds a string satisfying condition. It returns the value
+// the key held and whether it was removed, or ErrWrongType if the key holds
+// another type.
+func (db *Database) unsetIf(key string, condition func(value string) bool) (string, bool, error) {
+	var value string
+	removed := false
+	err := db.intercept(unsetWrites(key), func(writes []Write) error {
+		var err error
+		if value, err = db.getString(key); err != nil || value == "NULL" || !condition(value) {
+			return err
+		}
+		_, err = db.write(writes)
+		removed = err == nil
+		return err
+	})
+	return value, removed, err
 }
 
 // NumEqualTo returns the count of keys with the given value
 func (db *Database) NumEqualTo(value string) int {
-	baseCount := db.storage.GetValueCount(value)
+	db.lock()
+	defer db.unlock()
+
+	baseCount := db.baseStorage().GetValueCount(value)
 	transactionCount := db.transactions.GetValueCount(value)
 	return baseCount + transactionCount
 }
 
+// KeysEqualTo returns the keys holding the given value in sorted order.
+// The first offset keys are skipped and at most count keys are returned;
+// a count of zero or less returns every remaining key.
+func (db *Database) KeysEqualTo(value string, offset, count int) []string {
+	db.lock()
+	defer db.unlock()
+
+	return paginate(db.keysEqualTo(value), offset, count)
+}
+
+// keysEqualTo returns every key holding the given value in sorted order
+func (db *Database) keysEqualTo(value string) []string {
+	keys := make(map[string]struct{})
+	for _, key := range db.baseStorage().GetValueKeys(value) {
+		keys[key] = struct{}{}
+	}
+	for key, holds := range db.transactions.GetValueKeyChanges(value) {
+		if holds {
+			keys[key] = struct{}{}
+		} else {
+			delete(keys, key)
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// paginate returns at most count items after skipping offset of them.
+// A count of zero or less means no upper bound.
+func paginate(items []string, offset, count int) []string {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if count > 0 && count < len(items) {
+		items = items[:count]
+	}
+	return items
+}
+
 // Begin starts a new transaction
 func (db *Database) Begin() {
+	db.lock()
+	defer db.unlock()
+
 	db.transactions.Begin()
 }
 
 // Rollback undoes the most recent transaction
 func (db *Database) Rollback() error {
+	db.lock()
+	defer db.unlock()
+
 	return db.transactions.Rollback()
 }
 
-// Commit applies all pending transactions to the main storage
+// Commit applies all pending transactions to the main storage. The
+// pre-commit hooks run first, and if one returns an error nothing is
+// applied and the transactions stay open. The same happens if a staged
+// change no longer applies because another session committed to the key
+// first; see checkChanges. The post-commit hooks run once the changes
+// are applied.
 func (db *Database) Commit() error {
+	changes, postCommit, err := db.commit()
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range postCommit {
+		hook(changes)
+	}
+	return nil
+}
+
+// commit applies the pending transactions unless a pre-commit hook vetoes
+// them, and returns the applied changes with the post-commit hooks to run
+func (db *Database) commit() ([]TransactionChange, []PostCommitHook, error) {
+	db.lock()
+	defer db.unlock()
+
 	if !db.transactions.InTransaction() {
-		return ErrNoTransaction
+		return nil, nil, ErrNoTransaction
 	}
 
 	changes := db.transactions.GetAllChanges()
+	if err := db.keyspace.checkChanges(changes); err != nil {
+		return nil, nil, err
+	}
+	for _, hook := range db.shared.hooks.preCommit {
+		if err := hook(changes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The storage takes over the staged values, so the post-commit hooks,
+	// which run unlocked, get their own copies
+	postCommit := db.shared.hooks.postCommit
+	committed := changes
+	if len(postCommit) > 0 {
+		committed = cloneChanges(changes)
+	}
+
 	for _, change := range changes {
-		db.applyChange(change)
+		if change.Operation == OpFlushAll {
+			db.flushAll()
+			continue
+		}
+		db.keyspace.applyChange(change)
 	}
 
 	db.transactions.Clear()
+	return committed, postCommit, nil
+}
+
+// get retrieves a value by key through the transaction layers
+func (db *Database) get(key string) string {
+	if db.transactions.InTransaction() {
+		if value, found := db.transactions.Get(key); found {
+			return value
+		}
+	}
+	return db.baseStorage().Get(key)
+}
+
+// getString retrieves the string at key through the transaction layers,
+// "NULL" if the key is missing, or ErrWrongType if it holds another type
+func (db *Database) getString(key string) (string, error) {
+	value := db.get(key)
+	if value == "NULL" && db.exists(key) {
+		return "", ErrWrongType
+	}
+	return value, nil
+}
+
+// set records a write in the current transaction, or applies it directly
+// to the main storage when no transaction is active. It returns an error
+// wrapping schema.ErrViolation, and writes nothing, if the value breaks a
+// rule for the key.
+func (db *Database) set(key, value string) error {
+	if err := db.shared.schema.Check(key, value); err != nil {
+		return err
+	}
+
+	if db.transactions.InTransaction() {
+		db.transactions.Set(key, value, db.get(key))
+		return nil
+	}
+	db.keyspace.applySet(key, value)
+	return nil
+}
+
+// unset records a removal in the current transaction, or applies it directly
+// to the main storage when no transaction is active. It reports whether the
+// key existed.
+func (db *Database) unset(key string) bool {
+	if !db.exists(key) {
+		return false
+	}
+
+	if db.transactions.InTransaction() {
+		db.transactions.Unset(key, db.get(key))
+	} else {
+		db.keyspace.applyUnset(key)
+	}
+	return true
+}
+
+// store writes either a string value, checked against the schema rules,
+// or, when object is not nil, a copy of a structured value to key
+func (db *Database) store(key, value string, object storage.Value) error {
+	if object == nil {
+		return db.set(key, value)
+	}
+	db.storeObject(key, object)
+	return nil
+}
+
+// storeObject writes a copy of a structured value to key
+func (db *Database) storeObject(key string, object storage.Value) {
+	object = object.Clone()
+	if db.transactions.InTransaction() {
+		db.transactions.SetObject(key, object, db.get(key))
+		return
+	}
+	db.keyspace.applySetObject(key, object)
+}
+
+// patch records a partial change to the structured value at key in the
+// current transaction, or applies it directly to the main storage when no
+// transaction is active. Callers must check the key's type first.
+func (db *Database) patch(key string, patch storage.Patch) {
+	if !db.transactions.InTransaction() {
+		db.keyspace.applyPatch(key, patch)
+		return
+	}
+
+	// A change that empties the value removes the key, which is staged as
+	// an unset so the key disappears from listings right away
+	current := db.getObject(key)
+	if current != nil {
+		current = current.Clone()
+	}
+	empty := patch.Clone().Apply(current) == nil
+	if empty && current == nil {
+		return
+	}
+	db.transactions.Patch(key, patch, empty)
+}
+
+// baseStorage returns the committed storage the transaction layers sit on,
+// or an empty one if a layer cleared the database. It is only for reading.
+func (db *Database) baseStorage() *storage.Storage {
+	if db.transactions.Cleared() {
+		return emptyStorage
+	}
+	return db.keyspace.storage
+}
+
+// getObject returns the visible structured value at key, or nil.
+// The result must not be modified.
+func (db *Database) getObject(key string) storage.Value {
+	base := db.baseStorage().GetObject(key)
+	if db.transactions.InTransaction() {
+		return db.transactions.GetObject(key, base)
+	}
+	return base
+}
+
+// getValue returns the visible structured value at key if it has the
+// expected type, nil if the key is missing, or ErrWrongType otherwise.
+// The result must not be modified.
+func (db *Database) getValue(key string, expected storage.ValueType) (storage.Value, error) {
+	switch db.typeOf(key) {
+	case storage.TypeNone:
+		return nil, nil
+	case expected:
+		return db.getObject(key), nil
+	}
+	return nil, ErrWrongType
+}
+
+// typeOf returns the type of the visible value at key
+func (db *Database) typeOf(key string) storage.ValueType {
+	if db.get(key) != "NULL" {
+		return storage.TypeString
+	}
+	if object := db.getObject(key); object != nil {
+		return object.Type()
+	}
+	return storage.TypeNone
+}
+
+// exists reports whether key holds a visible value of any type
+func (db *Database) exists(key string) bool {
+	return db.typeOf(key) != storage.TypeNone
+}
+
+// checkChanges returns an error if a staged patch no longer applies to the
+// main storage because another session committed to the key first:
+// ErrStreamIDTooSmall if stream entries would no longer have increasing
+// IDs, or ErrConflict if a hash increment hits a field that stopped holding
+// an integer or a list pop would take other elements than it returned. Changes following a flush start from an empty database, so
+// they always fit.
+func (ks *keyspace) checkChanges(changes []TransactionChange) error {
+	for _, change := range changes {
+		if change.Operation == OpFlush || change.Operation == OpFlushAll {
+			return nil
+		}
+		if change.Patch == nil {
+			continue
+		}
+
+		committed := ks.storage.GetObject(change.Key)
+		switch patch := change.Patch.(type) {
+		case *storage.StreamPatch:
+			if patch.Conflicts(committed) {
+				return ErrStreamIDTooSmall
+			}
+		case storage.HashPatch:
+			if patch.Conflicts(committed) {
+				return ErrConflict
+			}
+		case *storage.ListPatch:
+			if patch.Conflicts(committed) {
+				return ErrConflict
+			}
+		}
+	}
 	return nil
 }
 
 // applyChange applies a single transaction change to the main storage
-func (db *Database) applyChange(change TransactionChange) {
+func (ks *keyspace) applyChange(change TransactionChange) {
 	switch change.Operation {
 	case OpSet:
-		oldValue := db.storage.Get(change.Key)
-		db.storage.Set(change.Key, change.NewValue)
-		db.storage.UpdateValueCount(oldValue, change.NewValue)
+		ks.applySet(change.Key, change.NewValue)
 	case OpUnset:
-		db.storage.Unset(change.Key)
-		db.storage.DecrementValueCount(change.OldValue)
+		if change.Patch != nil {
+			ks.applyPatch(change.Key, change.Patch)
+			return
+		}
+		ks.applyUnset(change.Key)
+	case OpSetObject:
+		ks.applySetObject(change.Key, change.Object)
+	case OpPatch:
+		ks.applyPatch(change.Key, change.Patch)
+	case OpFlush:
+		ks.flush()
+	}
+}
+
+// applySet writes a key to the main storage and keeps the value index in sync
+func (ks *keyspace) applySet(key, value string) {
+	oldValue := ks.storage.Get(key)
+	ks.storage.Set(key, value)
+	ks.storage.UpdateValueIndex(key, oldValue, value)
+	ks.emit(key, EventSet)
+}
+
+// applyUnset removes a key from the main storage and keeps the value index in sync
+func (ks *keyspace) applyUnset(key string) {
+	if ks.storage.Type(key) == storage.TypeNone {
+		return
+	}
+
+	oldValue := ks.storage.Get(key)
+	ks.storage.Unset(key)
+	if oldValue != "NULL" {
+		ks.storage.RemoveValueKey(key, oldValue)
+	}
+	ks.emit(key, EventDel)
+}
+
+// applySetObject writes a structured value to the main storage, dropping any
+// string it replaces from the value index
+func (ks *keyspace) applySetObject(key string, object storage.Value) {
+	oldValue := ks.storage.Get(key)
+	ks.storage.SetObject(key, object)
+	if oldValue != "NULL" {
+		ks.storage.RemoveValueKey(key, oldValue)
+	}
+	ks.emit(key, EventSet)
+	switch object.Type() {
+	case storage.TypeList:
+		ks.markReady(key)
+	case storage.TypeStream:
+		ks.wakeStreamReaders(key)
+	}
+}
+
+// applyPatch applies a partial change to the structured value in the main
+// storage, removing the key if nothing is left
+func (ks *keyspace) applyPatch(key string, patch storage.Patch) {
+	object := ks.storage.GetObject(key)
+	if object != nil && object.Type() != patch.Type() {
+		object = nil
+	}
+
+	if object = patch.Apply(object); object == nil {
+		ks.applyUnset(key)
+		return
 	}
+	ks.applySetObject(key, object)
 }
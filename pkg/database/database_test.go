@@ -1,22 +1,23 @@
 package database
 
 import (
+	"strings"
 	"testing"
 )
 
 func TestBasicOperations(t *testing.T) {
 	db := New()
 	db.Set("key1", "value1")
-	if got := db.Get("key1"); got != "value1" {
+	if got, _ := db.Get("key1"); got != "value1" {
 		t.Errorf("Expected 'value1', got '%s'", got)
 	}
 
-	if got := db.Get("nonexistent"); got != "NULL" {
+	if got, _ := db.Get("nonexistent"); got != "NULL" {
 		t.Errorf("Expected 'NULL', got '%s'", got)
 	}
 
 	db.Unset("key1")
-	if got := db.Get("key1"); got != "NULL" {
+	if got, _ := db.Get("key1"); got != "NULL" {
 		t.Errorf("Expected 'NULL' after unset, got '%s'", got)
 	}
 }
@@ -25,13 +26,13 @@ func TestScenario1(t *testing.T) {
 	db := New()
 	db.Set("ex", "10")
 
-	if got := db.Get("ex"); got != "10" {
+	if got, _ := db.Get("ex"); got != "10" {
 		t.Errorf("Expected '10', got '%s'", got)
 	}
 
 	db.Unset("ex")
 
-	if got := db.Get("EX"); got != "NULL" {
+	if got, _ := db.Get("EX"); got != "NULL" {
 		t.Errorf("Expected 'NULL', got '%s'", got)
 	}
 }
@@ -59,14 +60,14 @@ func TestScenario2(t *testing.T) {
 func TestTransactionScenario1(t *testing.T) {
 	db := New()
 	db.Set("a", "10")
-	if got := db.Get("a"); got != "10" {
+	if got, _ := db.Get("a"); got != "10" {
 		t.Errorf("Expected '10', got '%s'", got)
 	}
 
 	db.Begin()
 	db.Set("a", "20")
 
-	if got := db.Get("a"); got != "20" {
+	if got, _ := db.Get("a"); got != "20" {
 		t.Errorf("Expected '20', got '%s'", got)
 	}
 
@@ -74,7 +75,7 @@ func TestTransactionScenario1(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	if got := db.Get("a"); got != "10" {
+	if got, _ := db.Get("a"); got != "10" {
 		t.Errorf("Expected '10', got '%s'", got)
 	}
 
@@ -82,7 +83,7 @@ func TestTransactionScenario1(t *testing.T) {
 		t.Error("Expected error for rollback with no transaction")
 	}
 
-	if got := db.Get("a"); got != "10" {
+	if got, _ := db.Get("a"); got != "10" {
 		t.Errorf("Expected '10', got '%s'", got)
 	}
 }
@@ -97,7 +98,7 @@ func TestTransactionScenario2(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	if got := db.Get("a"); got != "40" {
+	if got, _ := db.Get("a"); got != "40" {
 		t.Errorf("Expected '40', got '%s'", got)
 	}
 
@@ -113,7 +114,7 @@ func TestTransactionScenario3(t *testing.T) {
 
 	db.Begin()
 
-	if got := db.Get("a"); got != "50" {
+	if got, _ := db.Get("a"); got != "50" {
 		t.Errorf("Expected '50', got '%s'", got)
 	}
 
@@ -121,7 +122,7 @@ func TestTransactionScenario3(t *testing.T) {
 	db.Begin()
 	db.Unset("a")
 
-	if got := db.Get("a"); got != "NULL" {
+	if got, _ := db.Get("a"); got != "NULL" {
 		t.Errorf("Expected 'NULL', got '%s'", got)
 	}
 
@@ -129,7 +130,7 @@ func TestTransactionScenario3(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	if got := db.Get("a"); got != "60" {
+	if got, _ := db.Get("a"); got != "60" {
 		t.Errorf("Expected '60', got '%s'", got)
 	}
 
@@ -137,7 +138,7 @@ func TestTransactionScenario3(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	if got := db.Get("a"); got != "60" {
+	if got, _ := db.Get("a"); got != "60" {
 		t.Errorf("Expected '60', got '%s'", got)
 	}
 }
@@ -187,7 +188,7 @@ func TestNestedTransactions(t *testing.T) {
 	db.Set("key", "level3")
 
 	// Should see level3 value
-	if got := db.Get("key"); got != "level3" {
+	if got, _ := db.Get("key"); got != "level3" {
 		t.Errorf("Expected 'level3', got '%s'", got)
 	}
 
@@ -196,7 +197,7 @@ func TestNestedTransactions(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	if got := db.Get("key"); got != "level2" {
+	if got, _ := db.Get("key"); got != "level2" {
 		t.Errorf("Expected 'level2', got '%s'", got)
 	}
 
@@ -205,7 +206,7 @@ func TestNestedTransactions(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	if got := db.Get("key"); got != "level2" {
+	if got, _ := db.Get("key"); got != "level2" {
 		t.Errorf("Expected 'level2', got '%s'", got)
 	}
 }
@@ -296,3 +297,404 @@ func TestErrorHandling(t *testing.T) {
 		t.Errorf("Expected 'NO TRANSACTION', got '%s'", err.Error())
 	}
 }
+
+func TestSetNXAndSetXX(t *testing.T) {
+	db := New()
+
+	if old, applied, _ := db.SetXX("a", "10"); applied || old != "NULL" {
+		t.Errorf("Expected SETXX on missing key to be skipped, got (%s, %v)", old, applied)
+	}
+
+	if got, _ := db.Get("a"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+
+	if old, applied, _ := db.SetNX("a", "10"); !applied || old != "NULL" {
+		t.Errorf("Expected SETNX on missing key to apply, got (%s, %v)", old, applied)
+	}
+
+	if old, applied, _ := db.SetNX("a", "20"); applied || old != "10" {
+		t.Errorf("Expected SETNX on existing key to be skipped, got (%s, %v)", old, applied)
+	}
+
+	if old, applied, _ := db.SetXX("a", "30"); !applied || old != "10" {
+		t.Errorf("Expected SETXX on existing key to apply, got (%s, %v)", old, applied)
+	}
+
+	if got, _ := db.Get("a"); got != "30" {
+		t.Errorf("Expected '30', got '%s'", got)
+	}
+
+	if got := db.NumEqualTo("10"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestGetSetAndGetDel(t *testing.T) {
+	db := New()
+
+	if got, _ := db.GetSet("a", "10"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+
+	if got, _ := db.GetSet("a", "20"); got != "10" {
+		t.Errorf("Expected '10', got '%s'", got)
+	}
+
+	if got, _ := db.GetDel("a"); got != "20" {
+		t.Errorf("Expected '20', got '%s'", got)
+	}
+
+	if got, _ := db.GetDel("a"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+
+	if got := db.NumEqualTo("20"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestConditionalWritesInTransaction(t *testing.T) {
+	db := New()
+	db.Set("a", "10")
+
+	db.Begin()
+
+	// The key is visible through the committed storage
+	if _, applied, _ := db.SetNX("a", "20"); applied {
+		t.Error("Expected SETNX to be skipped for committed key")
+	}
+
+	if got, _ := db.GetDel("a"); got != "10" {
+		t.Errorf("Expected '10', got '%s'", got)
+	}
+
+	// The staged delete makes the key absent inside the transaction
+	if _, applied, _ := db.SetNX("a", "30"); !applied {
+		t.Error("Expected SETNX to apply after staged delete")
+	}
+
+	if got, _ := db.GetSet("a", "40"); got != "30" {
+		t.Errorf("Expected '30', got '%s'", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got, _ := db.Get("a"); got != "10" {
+		t.Errorf("Expected '10', got '%s'", got)
+	}
+
+	if got := db.NumEqualTo("10"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestTransactionRepeatedWritesValueCounting(t *testing.T) {
+	db := New()
+	db.Set("a", "10")
+
+	db.Begin()
+	db.Set("a", "20")
+	db.Set("a", "30")
+
+	if got := db.NumEqualTo("20"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if got := db.NumEqualTo("30"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	db.Unset("a")
+
+	if got := db.NumEqualTo("30"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := db.NumEqualTo("10"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if got := db.NumEqualTo("30"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	db := New()
+
+	if ok, _ := db.CompareAndSwap("lock", "NULL", "owner1"); !ok {
+		t.Error("Expected CAS on missing key with NULL to apply")
+	}
+
+	if ok, _ := db.CompareAndSwap("lock", "owner2", "owner3"); ok {
+		t.Error("Expected CAS with wrong expected value to fail")
+	}
+
+	if got, _ := db.Get("lock"); got != "owner1" {
+		t.Errorf("Expected 'owner1', got '%s'", got)
+	}
+
+	db.Begin()
+
+	// The staged value is what CAS compares against inside a transaction
+	db.Set("lock", "owner2")
+	if ok, _ := db.CompareAndSwap("lock", "owner1", "owner3"); ok {
+		t.Error("Expected CAS against committed value to fail inside transaction")
+	}
+
+	if ok, _ := db.CompareAndDelete("lock", "owner2"); !ok {
+		t.Error("Expected CAD against staged value to apply")
+	}
+
+	if got := db.NumEqualTo("owner1"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if ok, _ := db.CompareAndDelete("lock", "owner2"); ok {
+		t.Error("Expected CAD to fail after rollback")
+	}
+
+	if ok, _ := db.CompareAndDelete("lock", "owner1"); !ok {
+		t.Error("Expected CAD against committed value to apply")
+	}
+
+	if got, _ := db.Get("lock"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+}
+
+func TestMultiKeyOperations(t *testing.T) {
+	db := New()
+	db.MSet("a", "1", "b", "1", "c", "2")
+
+	got := db.MGet("a", "b", "c", "d")
+	expected := []string{"1", "1", "2", "NULL"}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected '%s' at %d, got '%s'", expected[i], i, got[i])
+		}
+	}
+
+	if got := db.NumEqualTo("1"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	if ok, _ := db.MSetNX("c", "3", "d", "3"); ok {
+		t.Error("Expected MSETNX to fail when one key exists")
+	}
+
+	if got, _ := db.Get("d"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+
+	if got, _ := db.Del("a", "b", "missing"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	if got := db.NumEqualTo("1"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestMultiKeyOperationsInTransaction(t *testing.T) {
+	db := New()
+	db.Set("a", "1")
+
+	db.Begin()
+	db.MSet("a", "2", "b", "2")
+
+	if ok, _ := db.MSetNX("c", "3", "d", "3"); !ok {
+		t.Error("Expected MSETNX to apply for missing keys")
+	}
+
+	if got := db.NumEqualTo("2"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	if got, _ := db.Del("a", "c"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	db.Begin()
+	db.MSet("b", "4", "e", "4")
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got, _ := db.Get("b"); got != "2" {
+		t.Errorf("Expected '2', got '%s'", got)
+	}
+
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	got := db.MGet("a", "b", "c", "d", "e")
+	expected := []string{"NULL", "2", "NULL", "3", "NULL"}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected '%s' at %d, got '%s'", expected[i], i, got[i])
+		}
+	}
+
+	if got := db.NumEqualTo("1"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestRenameAndCopy(t *testing.T) {
+	db := New()
+	db.Set("a", "1")
+	db.Set("b", "2")
+
+	if err := db.Rename("missing", "c"); err != ErrNoSuchKey {
+		t.Errorf("Expected ErrNoSuchKey, got %v", err)
+	}
+
+	if err := db.Rename("a", "b"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := db.Exists("a", "b"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	// Renaming moves the value, so its count is unchanged while the
+	// overwritten value disappears
+	if got := db.NumEqualTo("1"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	if got := db.NumEqualTo("2"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if ok, _ := db.Copy("b", "c", false); !ok {
+		t.Error("Expected copy to a missing key to apply")
+	}
+
+	if ok, _ := db.Copy("b", "c", false); ok {
+		t.Error("Expected copy onto an existing key to fail without replace")
+	}
+
+	if got := db.NumEqualTo("1"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	if got := db.Type("c"); got != "string" {
+		t.Errorf("Expected 'string', got '%s'", got)
+	}
+}
+
+func TestRenameInTransaction(t *testing.T) {
+	db := New()
+	db.Set("a", "1")
+
+	db.Begin()
+
+	if renamed, err := db.RenameNX("a", "b"); !renamed || err != nil {
+		t.Errorf("Expected rename to apply, got (%v, %v)", renamed, err)
+	}
+
+	if got, _ := db.Get("b"); got != "1" {
+		t.Errorf("Expected '1', got '%s'", got)
+	}
+
+	if got := db.NumEqualTo("1"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Both halves of the rename are undone together
+	if got, _ := db.Get("a"); got != "1" {
+		t.Errorf("Expected '1', got '%s'", got)
+	}
+
+	if got, _ := db.Get("b"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+
+	db.Begin()
+	if err := db.Rename("a", "b"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := db.Exists("a", "b"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	if got := db.NumEqualTo("1"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestKeysEqualTo(t *testing.T) {
+	db := New()
+	db.MSet("c", "10", "a", "10", "b", "20")
+
+	if got := strings.Join(db.KeysEqualTo("10", 0, 0), ","); got != "a,c" {
+		t.Errorf("Expected 'a,c', got '%s'", got)
+	}
+
+	db.Begin()
+	db.Set("b", "10")
+	db.Set("a", "30")
+
+	db.Begin()
+	db.Set("a", "10")
+	db.Unset("c")
+	db.Set("d", "10")
+
+	if got := strings.Join(db.KeysEqualTo("10", 0, 0), ","); got != "a,b,d" {
+		t.Errorf("Expected 'a,b,d', got '%s'", got)
+	}
+
+	if got := db.NumEqualTo("10"); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	if got := strings.Join(db.KeysEqualTo("10", 1, 1), ","); got != "b" {
+		t.Errorf("Expected 'b', got '%s'", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := strings.Join(db.KeysEqualTo("10", 0, 0), ","); got != "b,c" {
+		t.Errorf("Expected 'b,c', got '%s'", got)
+	}
+
+	if got := strings.Join(db.KeysEqualTo("30", 0, 0), ","); got != "a" {
+		t.Errorf("Expected 'a', got '%s'", got)
+	}
+
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := strings.Join(db.KeysEqualTo("10", 0, 0), ","); got != "b,c" {
+		t.Errorf("Expected 'b,c', got '%s'", got)
+	}
+
+	if got := db.KeysEqualTo("20", 0, 0); len(got) != 0 {
+		t.Errorf("Expected no keys, got %v", got)
+	}
+}
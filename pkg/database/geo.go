@@ -0,0 +1,198 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"simple-database/pkg/storage"
+	"sort"
+)
+
+var ErrInvalidPosition = errors.New("INVALID LONGITUDE,LATITUDE PAIR")
+
+// GeoAdd writes positions, given as longitude, latitude and member
+// triples, to the geo index at key and returns how many members were new.
+// A geo index is a sorted set scored by each member's geohash, so the
+// sorted set commands work on it too.
+func (db *Database) GeoAdd(key string, triples ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	patch := make(storage.SortedSetPatch)
+	for i := 0; i+2 < len(triples); i += 3 {
+		longitude, lonOK := storage.ParseNumber(triples[i])
+		latitude, latOK := storage.ParseNumber(triples[i+1])
+		if !lonOK || !latOK || !storage.ValidGeoPosition(longitude, latitude) {
+			return 0, ErrInvalidPosition
+		}
+
+		member := triples[i+2]
+		if _, exists := zset.Score(member); !exists {
+			if _, staged := patch[member]; !staged {
+				added++
+			}
+		}
+		patch.SetScore(member, float64(storage.GeohashEncode(longitude, latitude)))
+	}
+
+	db.patch(key, patch)
+	return added, nil
+}
+
+// GeoPos returns the longitude and latitude of each member, along with
+// whether it was found. Positions are the center of the member's geohash
+// cell, so they can differ from the added ones by a fraction of a meter.
+func (db *Database) GeoPos(key string, members ...string) ([][2]float64, []bool, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	positions := make([][2]float64, len(members))
+	found := make([]bool, len(members))
+	for i, member := range members {
+		positions[i], found[i] = geoPosition(zset, member)
+	}
+	return positions, found, nil
+}
+
+// GeoDist returns the distance in meters between two members. The boolean
+// is false if either member does not exist.
+func (db *Database) GeoDist(key, member1, member2 string) (float64, bool, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return 0, false, err
+	}
+
+	from, ok1 := geoPosition(zset, member1)
+	to, ok2 := geoPosition(zset, member2)
+	if !ok1 || !ok2 {
+		return 0, false, nil
+	}
+	return storage.GeoDistance(from[0], from[1], to[0], to[1]), true, nil
+}
+
+// GeoSearch returns the members within an area centered on a longitude
+// and latitude, with their distances from the center in meters and their
+// positions. The area is a circle when radius is positive, and otherwise a
+// width by height box, all in meters. Results are sorted by distance,
+// nearest first unless descending is set, and cut to count when count is
+// positive.
+func (db *Database) GeoSearch(key string, longitude, latitude, radius, width, height float64, count int, descending bool) ([]string, []float64, [][2]float64, error) {
+	db.lock()
+	defer db.unlock()
+
+	if !storage.ValidGeoPosition(longitude, latitude) {
+		return nil, nil, nil, ErrInvalidPosition
+	}
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return geoSearch(zset, [2]float64{longitude, latitude}, radius, width, height, count, descending)
+}
+
+// GeoSearchFromMember is GeoSearch centered on an existing member. It
+// returns ErrNoSuchKey if the member does not exist.
+func (db *Database) GeoSearchFromMember(key, member string, radius, width, height float64, count int, descending bool) ([]string, []float64, [][2]float64, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	center, ok := geoPosition(zset, member)
+	if !ok {
+		return nil, nil, nil, ErrNoSuchKey
+	}
+	return geoSearch(zset, center, radius, width, height, count, descending)
+}
+
+// geoMatch is a member found by a geo search
+type geoMatch struct {
+	member   string
+	distance float64
+	position [2]float64
+}
+
+// geoSearch looks up the geohash cells covering the area, keeps the
+// members that are really inside it and sorts them by distance
+func geoSearch(zset *storage.SortedSet, center [2]float64, radius, width, height float64, count int, descending bool) ([]string, []float64, [][2]float64, error) {
+	if radius > 0 {
+		width, height = 2*radius, 2*radius
+	}
+
+	var matches []geoMatch
+	for _, scores := range storage.GeoHashRanges(center[0], center[1], width, height) {
+		for _, item := range zset.RangeByScore(scores[0], scores[1], 0, -1) {
+			position := geoDecode(item.Score)
+			distance, inside := geoDistanceInArea(center, position, radius, width, height)
+			if inside {
+				matches = append(matches, geoMatch{member: item.Member, distance: distance, position: position})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return (matches[i].distance < matches[j].distance) != descending
+		}
+		return matches[i].member < matches[j].member
+	})
+	if count > 0 && len(matches) > count {
+		matches = matches[:count]
+	}
+
+	members := make([]string, len(matches))
+	distances := make([]float64, len(matches))
+	positions := make([][2]float64, len(matches))
+	for i, match := range matches {
+		members[i], distances[i], positions[i] = match.member, match.distance, match.position
+	}
+	return members, distances, positions, nil
+}
+
+// geoDistanceInArea returns the distance from center to position and
+// whether position lies within the circle of radius, or when radius is
+// not positive, within the width by height box around center
+func geoDistanceInArea(center, position [2]float64, radius, width, height float64) (float64, bool) {
+	distance := storage.GeoDistance(center[0], center[1], position[0], position[1])
+	if radius > 0 {
+		return distance, distance <= radius
+	}
+
+	// The north-south offset is measured along the member's meridian and
+	// the east-west offset along its parallel
+	latOffset := storage.GeoDistance(position[0], center[1], position[0], position[1])
+	lonOffset := storage.GeoDistance(center[0], position[1], position[0], position[1])
+	return distance, latOffset <= height/2 && lonOffset <= width/2
+}
+
+// geoPosition returns the position of a member of a geo index
+func geoPosition(zset *storage.SortedSet, member string) ([2]float64, bool) {
+	score, exists := zset.Score(member)
+	if !exists {
+		return [2]float64{}, false
+	}
+	return geoDecode(score), true
+}
+
+// geoDecode converts a geohash score back to a longitude and latitude
+func geoDecode(score float64) [2]float64 {
+	longitude, latitude := storage.GeohashDecode(uint64(math.Max(score, 0)))
+	return [2]float64{longitude, latitude}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"simple-database/pkg/storage"
+	"strings"
+	"testing"
+)
+
+func TestGeoAddAndDistance(t *testing.T) {
+	db := New()
+
+	if got, err := db.GeoAdd("sicily", "13.361389", "38.115556", "Palermo", "15.087269", "37.502669", "Catania"); got != 2 || err != nil {
+		t.Errorf("Expected (2, nil), got (%d, %v)", got, err)
+	}
+
+	distance, ok, _ := db.GeoDist("sicily", "Palermo", "Catania")
+	if !ok || math.Abs(distance-166274.1516) > 1 {
+		t.Errorf("Expected about 166274 meters, got (%.4f, %v)", distance, ok)
+	}
+
+	if _, ok, _ := db.GeoDist("sicily", "Palermo", "Rome"); ok {
+		t.Error("Expected no distance to a missing member")
+	}
+
+	positions, found, _ := db.GeoPos("sicily", "Palermo", "Rome")
+	if !found[0] || found[1] {
+		t.Errorf("Expected [true false], got %v", found)
+	}
+	if math.Abs(positions[0][0]-13.361389) > 1e-5 || math.Abs(positions[0][1]-38.115556) > 1e-5 {
+		t.Errorf("Expected Palermo near (13.361389, 38.115556), got %v", positions[0])
+	}
+
+	if got := db.Type("sicily"); got != "zset" {
+		t.Errorf("Expected 'zset', got '%s'", got)
+	}
+
+	if _, err := db.GeoAdd("sicily", "10", "86", "NorthPole"); err != ErrInvalidPosition {
+		t.Errorf("Expected ErrInvalidPosition, got %v", err)
+	}
+}
+
+func TestGeoSearch(t *testing.T) {
+	db := New()
+	db.GeoAdd("sicily", "13.361389", "38.115556", "Palermo", "15.087269", "37.502669", "Catania")
+
+	members, distances, _, _ := db.GeoSearch("sicily", 15, 37, 200000, 0, 0, 0, false)
+	if got := strings.Join(members, ","); got != "Catania,Palermo" {
+		t.Errorf("Expected 'Catania,Palermo', got '%s'", got)
+	}
+	if len(distances) == 2 && (math.Abs(distances[0]-56441.3) > 1 || math.Abs(distances[1]-190442.4) > 1) {
+		t.Errorf("Expected distances near [56441 190442], got %v", distances)
+	}
+
+	members, _, _, _ = db.GeoSearch("sicily", 15, 37, 100000, 0, 0, 0, false)
+	if got := strings.Join(members, ","); got != "Catania" {
+		t.Errorf("Expected 'Catania', got '%s'", got)
+	}
+
+	members, _, _, _ = db.GeoSearch("sicily", 15, 37, 0, 400000, 400000, 1, true)
+	if got := strings.Join(members, ","); got != "Palermo" {
+		t.Errorf("Expected 'Palermo', got '%s'", got)
+	}
+
+	members, _, _, _ = db.GeoSearchFromMember("sicily", "Palermo", 10000, 0, 0, 0, false)
+	if got := strings.Join(members, ","); got != "Palermo" {
+		t.Errorf("Expected 'Palermo', got '%s'", got)
+	}
+
+	if _, _, _, err := db.GeoSearchFromMember("sicily", "Rome", 10000, 0, 0, 0, false); err != ErrNoSuchKey {
+		t.Errorf("Expected ErrNoSuchKey, got %v", err)
+	}
+}
+
+func TestGeoSearchAcrossAntimeridian(t *testing.T) {
+	db := New()
+	db.GeoAdd("pacific", "179.9", "0", "east", "-179.9", "0", "west", "0", "0", "far")
+
+	members, _, _, _ := db.GeoSearch("pacific", 179.99, 0, 50000, 0, 0, 0, false)
+	if got := strings.Join(members, ","); got != "east,west" {
+		t.Errorf("Expected 'east,west', got '%s'", got)
+	}
+}
+
+func TestGeoSearchMatchesExhaustiveScan(t *testing.T) {
+	db := New()
+	random := rand.New(rand.NewPCG(1, 2))
+
+	type point struct{ lon, lat float64 }
+	points := make(map[string]point)
+	for i := 0; i < 2000; i++ {
+		name := fmt.Sprintf("device:%d", i)
+		p := point{lon: 10 + random.Float64()*10, lat: 40 + random.Float64()*10}
+		db.GeoAdd("devices", fmt.Sprint(p.lon), fmt.Sprint(p.lat), name)
+		points[name] = p
+	}
+
+	for _, radius := range []float64{1000, 25000, 150000, 600000} {
+		members, distances, _, _ := db.GeoSearch("devices", 15, 45, radius, 0, 0, 0, false)
+
+		expected := 0
+		for name := range points {
+			position, _, _ := db.GeoPos("devices", name)
+			if storage.GeoDistance(15, 45, position[0][0], position[0][1]) <= radius {
+				expected++
+			}
+		}
+		if len(members) != expected {
+			t.Errorf("Radius %.0f: expected %d members, got %d", radius, expected, len(members))
+		}
+		for i := 1; i < len(distances); i++ {
+			if distances[i] < distances[i-1] {
+				t.Errorf("Radius %.0f: results are not sorted by distance", radius)
+				break
+			}
+		}
+	}
+}
+
+func TestGeoAddRollback(t *testing.T) {
+	db := New()
+	db.GeoAdd("fleet", "13.4", "52.5", "truck")
+
+	db.Begin()
+	db.GeoAdd("fleet", "2.35", "48.85", "truck", "-0.12", "51.5", "van")
+	members, _, _, _ := db.GeoSearch("fleet", 2.35, 48.85, 1000, 0, 0, 0, false)
+	if got := strings.Join(members, ","); got != "truck" {
+		t.Errorf("Expected 'truck', got '%s'", got)
+	}
+	db.Rollback()
+
+	members, _, _, _ = db.GeoSearch("fleet", 13.4, 52.5, 1000, 0, 0, 0, false)
+	if got := strings.Join(members, ","); got != "truck" {
+		t.Errorf("Expected 'truck', got '%s'", got)
+	}
+	if _, found, _ := db.GeoPos("fleet", "van"); found[0] {
+		t.Error("Expected van to be rolled back")
+	}
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"simple-database/pkg/storage"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrNotInteger = errors.New("NOT AN INTEGER")
+	ErrOverflow   = errors.New("INCREMENT WOULD OVERFLOW")
+)
+
+// HSet writes field-value pairs, given as alternating fields and values,
+// to the hash at key. It returns how many fields were newly created.
+func (db *Database) HSet(key string, pairs ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	hash, err := db.getHash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	patch := make(storage.HashPatch)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		field := pairs[i]
+		if _, exists := hash[field]; !exists {
+			if _, staged := patch[field]; !staged {
+				created++
+			}
+		}
+		patch.SetField(field, pairs[i+1])
+	}
+
+	db.patch(key, patch)
+	return created, nil
+}
+
+// HGet returns the value of a hash field, or "NULL" if it does not exist
+func (db *Database) HGet(key, field string) (string, error) {
+	db.lock()
+	defer db.unlock()
+
+	hash, err := db.getHash(key)
+	if err != nil {
+		return "", err
+	}
+
+	if value, exists := hash[field]; exists {
+		return value, nil
+	}
+	return "NULL", nil
+}
+
+// HDel removes fields from the hash at key and returns how many existed.
+// Removing the last field removes the key.
+func (db *Database) HDel(key string, fields ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	hash, err := db.getHash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	patch := make(storage.HashPatch)
+	for _, field := range fields {
+		if _, exists := hash[field]; exists {
+			patch.DeleteField(field)
+		}
+	}
+
+	if len(patch) > 0 {
+		db.patch(key, patch)
+	}
+	return len(patch), nil
+}
+
+// HGetAll returns the fields and values of the hash at key as alternating
+// fields and values, sorted by field
+func (db *Database) HGetAll(key string) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	hash, err := db.getHash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(hash))
+	for field := range hash {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	pairs := make([]string, 0, 2*len(fields))
+	for _, field := range fields {
+		pairs = append(pairs, field, hash[field])
+	}
+	return pairs, nil
+}
+
+// HIncrBy adds increment to the integer stored in a hash field, treating a
+// missing field as 0, and returns the new value. Inside a transaction the
+// increment is applied again on commit to the committed field, so increments
+// committed by other sessions in between are kept.
+func (db *Database) HIncrBy(key, field string, increment int64) (int64, error) {
+	db.lock()
+	defer db.unlock()
+
+	hash, err := db.getHash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	current := int64(0)
+	if value, exists := hash[field]; exists {
+		current, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+
+	if (increment > 0 && current > math.MaxInt64-increment) ||
+		(increment < 0 && current < math.MinInt64-increment) {
+		return 0, ErrOverflow
+	}
+
+	result := current + increment
+	// The increment is staged rather than the result, so a commit adds it to
+	// whatever another session committed in the meantime
+	patch := make(storage.HashPatch)
+	patch.IncrField(field, increment)
+	db.patch(key, patch)
+	return result, nil
+}
+
+// getHash returns the visible hash at key, or nil if the key is missing.
+// The result must not be modified.
+func (db *Database) getHash(key string) (storage.Hash, error) {
+	value, err := db.getValue(key, storage.TypeHash)
+	if value == nil {
+		return nil, err
+	}
+	return value.(storage.Hash), nil
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashOperations(t *testing.T) {
+	db := New()
+
+	if created, err := db.HSet("user", "name", "alice", "age", "30"); created != 2 || err != nil {
+		t.Errorf("Expected (2, nil), got (%d, %v)", created, err)
+	}
+
+	if created, _ := db.HSet("user", "name", "bob", "city", "paris"); created != 1 {
+		t.Errorf("Expected 1 new field, got %d", created)
+	}
+
+	if got, _ := db.HGet("user", "name"); got != "bob" {
+		t.Errorf("Expected 'bob', got '%s'", got)
+	}
+
+	if got, _ := db.HGet("user", "missing"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+
+	pairs, _ := db.HGetAll("user")
+	if got := strings.Join(pairs, ","); got != "age,30,city,paris,name,bob" {
+		t.Errorf("Expected 'age,30,city,paris,name,bob', got '%s'", got)
+	}
+
+	if got, err := db.HIncrBy("user", "age", 5); got != 35 || err != nil {
+		t.Errorf("Expected (35, nil), got (%d, %v)", got, err)
+	}
+
+	if _, err := db.HIncrBy("user", "name", 1); err != ErrNotInteger {
+		t.Errorf("Expected ErrNotInteger, got %v", err)
+	}
+
+	if got, _ := db.HDel("user", "age", "city", "missing"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	if got := db.Type("user"); got != "hash" {
+		t.Errorf("Expected 'hash', got '%s'", got)
+	}
+
+	// Removing the last field removes the key
+	db.HDel("user", "name")
+	if got := db.Exists("user"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestHashWrongType(t *testing.T) {
+	db := New()
+	db.Set("name", "alice")
+	db.HSet("user", "name", "alice")
+
+	if _, err := db.HSet("name", "field", "value"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	if _, err := db.HGet("name", "field"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	// String commands other than SET refuse a hash key and leave it alone
+	if _, err := db.Get("user"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	if _, applied, err := db.SetNX("user", "x"); applied || err != ErrWrongType {
+		t.Errorf("Expected (false, ErrWrongType), got (%v, %v)", applied, err)
+	}
+
+	if _, applied, err := db.SetXX("user", "x"); applied || err != ErrWrongType {
+		t.Errorf("Expected (false, ErrWrongType), got (%v, %v)", applied, err)
+	}
+
+	if _, err := db.GetSet("user", "x"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	if _, err := db.GetDel("user"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	if _, err := db.CompareAndSwap("user", "NULL", "x"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	if _, err := db.CompareAndDelete("user", "x"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	if got := db.Type("user"); got != "hash" {
+		t.Errorf("Expected 'hash', got '%s'", got)
+	}
+
+	// SET replaces the hash entirely
+	db.Set("user", "plain")
+	if got := db.Type("user"); got != "string" {
+		t.Errorf("Expected 'string', got '%s'", got)
+	}
+
+	if got := db.NumEqualTo("alice"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestHashTransactionRollsBackFields(t *testing.T) {
+	db := New()
+	db.HSet("user", "name", "alice", "age", "30")
+
+	db.Begin()
+	db.HSet("user", "city", "paris")
+
+	db.Begin()
+	db.HSet("user", "name", "bob")
+	db.HDel("user", "age")
+
+	pairs, _ := db.HGetAll("user")
+	if got := strings.Join(pairs, ","); got != "city,paris,name,bob" {
+		t.Errorf("Expected 'city,paris,name,bob', got '%s'", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Only the inner layer's fields are undone
+	pairs, _ = db.HGetAll("user")
+	if got := strings.Join(pairs, ","); got != "age,30,city,paris,name,alice" {
+		t.Errorf("Expected 'age,30,city,paris,name,alice', got '%s'", got)
+	}
+
+	db.Begin()
+	db.HIncrBy("user", "age", 1)
+
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	pairs, _ = db.HGetAll("user")
+	if got := strings.Join(pairs, ","); got != "age,31,city,paris,name,alice" {
+		t.Errorf("Expected 'age,31,city,paris,name,alice', got '%s'", got)
+	}
+}
+
+func TestHashTransactionKeyLifecycle(t *testing.T) {
+	db := New()
+	db.HSet("a", "f", "1")
+
+	db.Begin()
+	db.HDel("a", "f")
+
+	if got := db.Exists("a"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if got := strings.Join(db.Keys("*"), ","); got != "" {
+		t.Errorf("Expected no keys, got '%s'", got)
+	}
+
+	db.Begin()
+	db.HSet("a", "g", "2")
+
+	if err := db.Rename("a", "b"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	db.HSet("b", "h", "3")
+
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := strings.Join(db.Keys("*"), ","); got != "b" {
+		t.Errorf("Expected 'b', got '%s'", got)
+	}
+
+	pairs, _ := db.HGetAll("b")
+	if got := strings.Join(pairs, ","); got != "g,2,h,3" {
+		t.Errorf("Expected 'g,2,h,3', got '%s'", got)
+	}
+
+	// Copies are independent of the source
+	db.Copy("b", "c", false)
+	db.HSet("c", "g", "changed")
+
+	if got, _ := db.HGet("b", "g"); got != "2" {
+		t.Errorf("Expected '2', got '%s'", got)
+	}
+
+	if got, _ := db.Del("b", "c"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+}
+
+func TestHIncrByCommitsOnTopOfOtherSessions(t *testing.T) {
+	db := New()
+	other := db.NewSession()
+	db.HSet("h", "n", "1")
+
+	db.Begin()
+	if got, _ := db.HIncrBy("h", "n", 1); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+	other.HIncrBy("h", "n", 10)
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if got, _ := db.HGet("h", "n"); got != "12" {
+		t.Errorf("Expected '12', got '%s'", got)
+	}
+
+	// Increments of the same transaction add up, and follow a staged HSET
+	db.Begin()
+	db.HIncrBy("h", "n", 1)
+	db.Begin()
+	db.HIncrBy("h", "n", 2)
+	db.HSet("h", "m", "5")
+	db.HIncrBy("h", "m", 1)
+	other.HIncrBy("h", "n", 100)
+	db.Commit()
+	pairs, _ := db.HGetAll("h")
+	if got := strings.Join(pairs, ","); got != "m,6,n,115" {
+		t.Errorf("Expected 'm,6,n,115', got '%s'", got)
+	}
+
+	// A field that stops holding an integer fails the commit
+	db.Begin()
+	db.HIncrBy("h", "n", 1)
+	other.HSet("h", "n", "text")
+	if err := db.Commit(); err != ErrConflict {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	db.Rollback()
+	if got, _ := db.HGet("h", "n"); got != "text" {
+		t.Errorf("Expected 'text', got '%s'", got)
+	}
+}
@@ -0,0 +1,182 @@
+package database
+
+// PreCommitHook is called by Commit with the ordered changes of the
+// transaction before they are applied. Returning an error vetoes the
+// commit. It runs with the database locked, so it must not call back into
+// the database, and it must not modify the changes.
+type PreCommitHook func(changes []TransactionChange) error
+
+// PostCommitHook is called by Commit with the changes it applied. It runs
+// after the database is unlocked, so it may use the database.
+type PostCommitHook func(changes []TransactionChange)
+
+// Write is a string write or a key removal on its way through the
+// interceptors
+type Write struct {
+	Key string
+	// Value is the value to store, or "NULL" for a removal
+	Value string
+	Unset bool
+	// Implied marks a write that a command works out for itself when it
+	// runs: both writes of Rename and Move, the destination of Copy, and
+	// the result of SetBit, BitOp, PFAdd and PFMerge. Value is then empty
+	// unless it is a removal, and interceptors can reject the write but not
+	// change it.
+	Implied bool
+}
+
+// Interceptor wraps every string write and key removal: Set, MSet and
+// their conditional variants, GetSet, CompareAndSwap, Unset, Del, GetDel,
+// CompareAndDelete, Rename, Copy, Move and the bitmap and HyperLogLog
+// writes. It calls next to pass the write on, possibly changed, or returns
+// an error without calling next to reject it; the error is returned by the
+// method.
+// Interceptors run before the database is locked, so they may use it.
+//
+// The writes of one call are applied together, under one lock, once every
+// one of them has passed, so next returns nil unless a later interceptor
+// rejects the write. Errors from applying them, such as a schema
+// violation, are returned by the method.
+type Interceptor func(write Write, next func(Write) error) error
+
+// hooks holds the registered hooks and interceptors, in registration order
+type hooks struct {
+	preCommit    []PreCommitHook
+	postCommit   []PostCommitHook
+	interceptors []Interceptor
+}
+
+// AddPreCommitHook registers a hook to run before every commit of every
+// session. Hooks run in the order they were added, and the first error
+// stops the commit.
+func (db *Database) AddPreCommitHook(hook PreCommitHook) {
+	db.lock()
+	defer db.unlock()
+
+	db.shared.hooks.preCommit = append(db.shared.hooks.preCommit, hook)
+}
+
+// AddPostCommitHook registers a hook to run after every commit of every
+// session. Hooks run in the order they were added.
+func (db *Database) AddPostCommitHook(hook PostCommitHook) {
+	db.lock()
+	defer db.unlock()
+
+	db.shared.hooks.postCommit = append(db.shared.hooks.postCommit, hook)
+}
+
+// AddInterceptor registers an interceptor around the string writes and
+// key removals of every session. The interceptor added first is the
+// outermost, so it sees a write first.
+func (db *Database) AddInterceptor(interceptor Interceptor) {
+	db.lock()
+	defer db.unlock()
+
+	db.shared.hooks.interceptors = append(db.shared.hooks.interceptors, interceptor)
+}
+
+// cloneChanges copies changes along with their structured values and patches
+func cloneChanges(changes []TransactionChange) []TransactionChange {
+	cloned := make([]TransactionChange, len(changes))
+	for i, change := range changes {
+		if change.Object != nil {
+			change.Object = change.Object.Clone()
+		}
+		if change.Patch != nil {
+			change.Patch = change.Patch.Clone()
+		}
+		cloned[i] = change
+	}
+	return cloned
+}
+
+// intercept passes writes through the interceptors and, if none rejects
+// one, calls apply with the writes they passed on, with the database locked
+func (db *Database) intercept(writes []Write, apply func(writes []Write) error) error {
+	db.lock()
+	interceptors := db.shared.hooks.interceptors
+	db.unlock()
+
+	var passed []Write
+	var call func(i int, write Write) error
+	call = func(i int, write Write) error {
+		if i == len(interceptors) {
+			passed = append(passed, write)
+			return nil
+		}
+		return interceptors[i](write, func(write Write) error {
+			return call(i+1, write)
+		})
+	}
+	for _, write := range writes {
+		if err := call(0, write); err != nil {
+			return err
+		}
+	}
+
+	db.lock()
+	defer db.unlock()
+
+	return apply(passed)
+}
+
+// writeThrough passes writes through the interceptors and applies them
+func (db *Database) writeThrough(writes []Write) error {
+	return db.intercept(writes, func(writes []Write) error {
+		_, err := db.write(writes)
+		return err
+	})
+}
+
+// write applies writes that passed the interceptors and returns how many
+// removals found a key. The values are checked against the schema rules
+// first, so if one breaks a rule, no write is applied.
+func (db *Database) write(writes []Write) (int, error) {
+	for _, write := range writes {
+		if write.Unset {
+			continue
+		}
+		if err := db.shared.schema.Check(write.Key, write.Value); err != nil {
+			return 0, err
+		}
+	}
+
+	removed := 0
+	for _, write := range writes {
+		if !write.Unset {
+			if err := db.set(write.Key, write.Value); err != nil {
+				return removed, err
+			}
+		} else if db.unset(write.Key) {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// setWrites returns the writes storing alternating keys and values
+func setWrites(pairs []string) []Write {
+	writes := make([]Write, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		writes = append(writes, Write{Key: pairs[i], Value: pairs[i+1]})
+	}
+	return writes
+}
+
+// unsetWrites returns the writes removing keys
+func unsetWrites(keys ...string) []Write {
+	writes := make([]Write, len(keys))
+	for i, key := range keys {
+		writes[i] = Write{Key: key, Value: "NULL", Unset: true}
+	}
+	return writes
+}
+
+// moveWrites returns the implied writes of moving the value at source to
+// destination
+func moveWrites(source, destination string) []Write {
+	return []Write{
+		{Key: source, Value: "NULL", Unset: true, Implied: true},
+		{Key: destination, Implied: true},
+	}
+}
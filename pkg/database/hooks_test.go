@@ -0,0 +1,209 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInterceptorsWrapSetAndUnset(t *testing.T) {
+	db := New()
+	var calls []string
+
+	db.AddInterceptor(func(write Write, next func(Write) error) error {
+		calls = append(calls, "outer:"+write.Key)
+		err := next(write)
+		calls = append(calls, "outer done")
+		return err
+	})
+	db.AddInterceptor(func(write Write, next func(Write) error) error {
+		calls = append(calls, "inner:"+write.Key)
+		if write.Key == "locked" {
+			return errors.New("LOCKED")
+		}
+		if !write.Unset {
+			write.Value = strings.ToUpper(write.Value)
+		}
+		return next(write)
+	})
+
+	if err := db.Set("a", "value"); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	if got, _ := db.Get("a"); got != "VALUE" {
+		t.Errorf("Expected 'VALUE', got '%s'", got)
+	}
+
+	expected := "outer:a,inner:a,outer done"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, got)
+	}
+
+	db.Set("locked", "x")
+	if err := db.Unset("locked"); err == nil || err.Error() != "LOCKED" {
+		t.Errorf("Expected LOCKED, got %v", err)
+	}
+
+	// Interceptors also see writes inside transactions, and may use the database
+	other := db.NewSession()
+	other.AddInterceptor(func(write Write, next func(Write) error) error {
+		if protect, _ := db.Get("protect"); write.Unset && protect == "1" {
+			return errors.New("PROTECTED")
+		}
+		return next(write)
+	})
+	db.Set("protect", "1")
+	db.Begin()
+	if err := db.Unset("a"); err == nil || err.Error() != "PROTECTED" {
+		t.Errorf("Expected PROTECTED, got %v", err)
+	}
+	db.Rollback()
+}
+
+func TestInterceptorsSeeEveryStringWriteAndRemoval(t *testing.T) {
+	db := New()
+	var calls []string
+	db.AddInterceptor(func(write Write, next func(Write) error) error {
+		kind := "set"
+		if write.Unset {
+			kind = "unset"
+		}
+		if write.Implied {
+			kind += "*"
+		}
+		calls = append(calls, kind+":"+write.Key)
+		if strings.HasPrefix(write.Key, "ro:") {
+			return errors.New("READ ONLY KEY")
+		}
+		return next(write)
+	})
+
+	db.MSet("a", "1", "b", "2")
+	db.SetNX("c", "3")
+	db.GetSet("c", "4")
+	db.CompareAndSwap("c", "4", "5")
+	db.Rename("c", "d")
+	db.Copy("d", "e", false)
+	db.SetBit("f", 0, 1)
+	db.Move("f", 1)
+	db.GetDel("a")
+	db.CompareAndDelete("b", "2")
+	db.Del("d", "e")
+
+	expected := "set:a,set:b,set:c,set:c,set:c,unset*:c,set*:d,set*:e,set*:f," +
+		"unset*:f,set*:f,unset:a,unset:b,unset:d,unset:e"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, got)
+	}
+	if got := db.DBSize(); got != 0 {
+		t.Errorf("Expected every key to be gone, got %d", got)
+	}
+
+	// A rejected write stops the whole call, so no key is removed
+	db.MSet("x", "1", "y", "1")
+	if _, err := db.Del("x", "ro:z", "y"); err == nil || err.Error() != "READ ONLY KEY" {
+		t.Errorf("Expected READ ONLY KEY, got %v", err)
+	}
+	if got := db.Exists("x", "y"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+	if err := db.Rename("x", "ro:x"); err == nil {
+		t.Error("Expected the rename to be rejected")
+	}
+	if ok, err := db.Copy("x", "ro:x", true); ok || err == nil {
+		t.Errorf("Expected (false, READ ONLY KEY), got (%v, %v)", ok, err)
+	}
+}
+
+func TestPreCommitHookSeesOrderedChangesAndCanVeto(t *testing.T) {
+	db := New()
+	db.Set("b", "1")
+
+	var seen string
+	veto := true
+	db.AddPreCommitHook(func(changes []TransactionChange) error {
+		var keys []string
+		for _, change := range changes {
+			keys = append(keys, change.Key)
+		}
+		seen = strings.Join(keys, ",")
+		if veto {
+			return errors.New("VETOED")
+		}
+		return nil
+	})
+
+	db.Begin()
+	db.Set("z", "1")
+	db.Begin()
+	db.Unset("b")
+	db.HSet("h", "f", "v")
+	db.Set("a", "1")
+	db.Set("z", "2")
+
+	if err := db.Commit(); err == nil || err.Error() != "VETOED" {
+		t.Errorf("Expected VETOED, got %v", err)
+	}
+	if seen != "b,h,a,z" {
+		t.Errorf("Expected 'b,h,a,z', got '%s'", seen)
+	}
+
+	// A vetoed commit applies nothing and leaves the transaction open
+	other := db.NewSession()
+	if got, _ := other.Get("b"); got != "1" {
+		t.Errorf("Expected '1', got '%s'", got)
+	}
+	if got, _ := db.Get("z"); got != "2" {
+		t.Errorf("Expected '2', got '%s'", got)
+	}
+
+	veto = false
+	db.Rollback()
+	db.FlushDB()
+	db.Set("y", "1")
+	if err := db.Commit(); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	if seen != ",y" {
+		t.Errorf("Expected ',y' (the flush, then y), got '%s'", seen)
+	}
+	if got := other.DBSize(); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestPostCommitHooksRunInOrder(t *testing.T) {
+	db := New()
+	var calls []string
+
+	db.AddPostCommitHook(func(changes []TransactionChange) {
+		calls = append(calls, "first:"+changes[0].Key)
+	})
+	db.AddPostCommitHook(func(changes []TransactionChange) {
+		// The database is unlocked, so the hook can read the result
+		value, _ := db.Get(changes[0].Key)
+		calls = append(calls, "second:"+value)
+	})
+	db.AddPreCommitHook(func(changes []TransactionChange) error {
+		if changes[0].Key == "bad" {
+			return errors.New("BAD")
+		}
+		return nil
+	})
+
+	db.Begin()
+	db.Set("a", "1")
+	db.Commit()
+
+	db.Begin()
+	db.Set("bad", "1")
+	db.Commit()
+	db.Rollback()
+
+	// Writes outside a transaction are not commits
+	db.Set("c", "1")
+
+	if got := strings.Join(calls, ","); got != "first:a,second:1" {
+		t.Errorf("Expected 'first:a,second:1', got '%s'", got)
+	}
+}
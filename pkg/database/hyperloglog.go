@@ -0,0 +1,87 @@
+package database
+
+import (
+	"errors"
+	"simple-database/pkg/storage"
+)
+
+var ErrNotHyperLogLog = errors.New("WRONGTYPE Key is not a valid HyperLogLog string value")
+
+// PFAdd adds elements to the HyperLogLog at key, creating it if needed.
+// It reports whether the key was created or its estimate may have changed.
+func (db *Database) PFAdd(key string, elements ...string) (bool, error) {
+	changed := false
+	err := db.intercept([]Write{{Key: key, Implied: true}}, func([]Write) error {
+		hll, exists, err := db.getHyperLogLog(key)
+		if err != nil {
+			return err
+		}
+
+		changed = !exists
+		for _, element := range elements {
+			if hll.Add(element) {
+				changed = true
+			}
+		}
+
+		if !changed {
+			return nil
+		}
+		return db.set(key, hll.String())
+	})
+	return changed && err == nil, err
+}
+
+// PFCount returns the estimated number of distinct elements added to the
+// HyperLogLogs at keys, counting each element once across all of them.
+// Missing keys count as empty. The estimate has a standard error of 0.81%.
+func (db *Database) PFCount(keys ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	union, err := db.mergeHyperLogLogs(keys)
+	if err != nil {
+		return 0, err
+	}
+	return union.Count(), nil
+}
+
+// PFMerge stores the union of the HyperLogLogs at keys and at destination
+// itself in destination
+func (db *Database) PFMerge(destination string, keys ...string) error {
+	return db.intercept([]Write{{Key: destination, Implied: true}}, func([]Write) error {
+		union, err := db.mergeHyperLogLogs(append([]string{destination}, keys...))
+		if err != nil {
+			return err
+		}
+		return db.set(destination, union.String())
+	})
+}
+
+// mergeHyperLogLogs returns the union of the HyperLogLogs at keys
+func (db *Database) mergeHyperLogLogs(keys []string) (*storage.HyperLogLog, error) {
+	union := storage.NewHyperLogLog()
+	for _, key := range keys {
+		hll, _, err := db.getHyperLogLog(key)
+		if err != nil {
+			return nil, err
+		}
+		union.Merge(hll)
+	}
+	return union, nil
+}
+
+// getHyperLogLog decodes the visible HyperLogLog at key. A missing key
+// yields an empty HyperLogLog and false.
+func (db *Database) getHyperLogLog(key string) (*storage.HyperLogLog, bool, error) {
+	switch db.typeOf(key) {
+	case storage.TypeNone:
+		return storage.NewHyperLogLog(), false, nil
+	case storage.TypeString:
+		if hll, ok := storage.ParseHyperLogLog(db.get(key)); ok {
+			return hll, true, nil
+		}
+		return nil, false, ErrNotHyperLogLog
+	}
+	return nil, false, ErrWrongType
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// hllTolerance allows three standard errors (0.81% each) between an
+// estimate and the exact count
+const hllTolerance = 3 * 0.0081
+
+func TestPFCountMatchesExactCounts(t *testing.T) {
+	db := New()
+	exact := make(map[string]bool)
+
+	checkpoints := []int{10, 100, 1000, 10000, 100000}
+	added := 0
+	for _, checkpoint := range checkpoints {
+		var batch []string
+		for ; added < checkpoint; added++ {
+			// Every element is added twice, so duplicates must not count
+			element := "visitor:" + strconv.Itoa(added)
+			batch = append(batch, element, element)
+			exact[element] = true
+		}
+		db.PFAdd("visitors", batch...)
+
+		estimate, err := db.PFCount("visitors")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if relative := math.Abs(float64(estimate-len(exact))) / float64(len(exact)); relative > hllTolerance {
+			t.Errorf("Exact count %d: estimate %d is off by %.2f%%", len(exact), estimate, relative*100)
+		}
+	}
+}
+
+func TestPFMergeEstimatesUnion(t *testing.T) {
+	db := New()
+	exact := make(map[string]bool)
+
+	// Two overlapping days of visitors: 0-5999 and 4000-9999
+	var monday, tuesday []string
+	for i := 0; i < 10000; i++ {
+		if i < 6000 {
+			monday = append(monday, strconv.Itoa(i))
+		}
+		if i >= 4000 {
+			tuesday = append(tuesday, strconv.Itoa(i))
+		}
+		exact[strconv.Itoa(i)] = true
+	}
+	db.PFAdd("monday", monday...)
+	db.PFAdd("tuesday", tuesday...)
+
+	if err := db.PFMerge("week", "monday", "tuesday"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	merged, _ := db.PFCount("week")
+	union, _ := db.PFCount("monday", "tuesday")
+	if merged != union {
+		t.Errorf("Expected PFMERGE and multi-key PFCOUNT to agree, got %d and %d", merged, union)
+	}
+	if relative := math.Abs(float64(merged-len(exact))) / float64(len(exact)); relative > hllTolerance {
+		t.Errorf("Exact count %d: estimate %d is off by %.2f%%", len(exact), merged, relative*100)
+	}
+}
+
+func TestPFAdd(t *testing.T) {
+	db := New()
+
+	if changed, err := db.PFAdd("hll"); !changed || err != nil {
+		t.Errorf("Expected (true, nil) when creating the key, got (%v, %v)", changed, err)
+	}
+	if changed, _ := db.PFAdd("hll", "a"); !changed {
+		t.Error("Expected a new element to change the estimate")
+	}
+	if changed, _ := db.PFAdd("hll", "a"); changed {
+		t.Error("Expected a repeated element not to change the estimate")
+	}
+	if got, _ := db.PFCount("hll", "missing"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	db.Set("plain", "value")
+	if _, err := db.PFAdd("plain", "a"); err != ErrNotHyperLogLog {
+		t.Errorf("Expected ErrNotHyperLogLog, got %v", err)
+	}
+	db.SAdd("set", "a")
+	if _, err := db.PFCount("hll", "set"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+}
+
+func TestPFAddRollback(t *testing.T) {
+	db := New()
+	db.PFAdd("hll", "a", "b")
+
+	db.Begin()
+	db.PFAdd("hll", "c", "d", "e")
+	if got, _ := db.PFCount("hll"); got != 5 {
+		t.Errorf("Expected 5, got %d", got)
+	}
+	db.Rollback()
+
+	if got, _ := db.PFCount("hll"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+}
+
+func TestHyperLogLogStaysOutOfValueIndex(t *testing.T) {
+	db := New()
+	db.PFAdd("hll", "a", "b")
+	db.Set("plain", "x")
+
+	if got := db.Type("hll"); got != "string" {
+		t.Errorf("Expected 'string', got '%s'", got)
+	}
+	if got, _ := db.Get("hll"); !strings.HasPrefix(got, "HYLL") {
+		t.Errorf("Expected the HYLL encoding, got %q", got[:min(len(got), 8)])
+	}
+	if got := db.DistinctValues(); got != 1 {
+		t.Errorf("Expected only 'x' to be indexed, got %d values", got)
+	}
+
+	// Staged HyperLogLogs are left out too, and renaming one keeps it usable
+	db.Begin()
+	db.PFMerge("union", "hll")
+	db.Rename("hll", "renamed")
+	if got := db.DistinctValues(); got != 1 {
+		t.Errorf("Expected only 'x' to be indexed, got %d values", got)
+	}
+	db.Commit()
+	if got := db.DistinctValues(); got != 1 {
+		t.Errorf("Expected only 'x' to be indexed, got %d values", got)
+	}
+	if got, _ := db.PFCount("renamed", "union"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	// Like any string write, PFADD goes through the interceptors
+	db.AddInterceptor(func(write Write, next func(Write) error) error {
+		if write.Key == "locked" {
+			return errors.New("LOCKED")
+		}
+		return next(write)
+	})
+	if changed, err := db.PFAdd("locked", "a"); changed || err == nil {
+		t.Errorf("Expected (false, LOCKED), got (%v, %v)", changed, err)
+	}
+	if got := db.Exists("locked"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
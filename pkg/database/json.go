@@ -0,0 +1,154 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"simple-database/pkg/storage"
+)
+
+var (
+	ErrInvalidJSON     = errors.New("INVALID JSON")
+	ErrInvalidJSONPath = errors.New("INVALID JSON PATH")
+	ErrNoSuchPath      = errors.New("NO SUCH PATH")
+	ErrNotAtRoot       = errors.New("NEW DOCUMENTS MUST BE CREATED AT THE ROOT")
+	ErrNotNumber       = errors.New("VALUE AT PATH IS NOT A NUMBER")
+)
+
+// JSONSet writes value, which must be valid JSON, at path in the JSON
+// document at key. A missing key can only be created at the root path.
+// With nx the write only happens if nothing exists at path, and with xx
+// only if something does. It returns false if the write did not happen,
+// either because of nx or xx or because the parent of path does not exist.
+func (db *Database) JSONSet(key, path, value string, nx, xx bool) (bool, error) {
+	db.lock()
+	defer db.unlock()
+
+	parsedPath, ok := storage.ParseJSONPath(path)
+	if !ok {
+		return false, ErrInvalidJSONPath
+	}
+	if _, ok := storage.ParseJSON(value); !ok {
+		return false, ErrInvalidJSON
+	}
+
+	doc, err := db.getJSON(key)
+	if err != nil {
+		return false, err
+	}
+
+	if doc == nil {
+		if len(parsedPath) > 0 {
+			return false, ErrNotAtRoot
+		}
+		if xx {
+			return false, nil
+		}
+	} else {
+		exists := doc.Has(parsedPath)
+		if (nx && exists) || (xx && !exists) || !doc.CanSet(parsedPath) {
+			return false, nil
+		}
+	}
+
+	patch := &storage.JSONPatch{}
+	patch.Set(parsedPath, value)
+	db.patch(key, patch)
+	return true, nil
+}
+
+// JSONGet returns the compact JSON of the value at path in the JSON
+// document at key, or "NULL" if the key does not exist
+func (db *Database) JSONGet(key, path string) (string, error) {
+	db.lock()
+	defer db.unlock()
+
+	parsedPath, ok := storage.ParseJSONPath(path)
+	if !ok {
+		return "", ErrInvalidJSONPath
+	}
+
+	doc, err := db.getJSON(key)
+	if err != nil || doc == nil {
+		return "NULL", err
+	}
+
+	text, ok := doc.Get(parsedPath)
+	if !ok {
+		return "", ErrNoSuchPath
+	}
+	return text, nil
+}
+
+// JSONDel removes the value at path in the JSON document at key and
+// returns how many values were removed. Removing the root removes the key.
+func (db *Database) JSONDel(key, path string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	parsedPath, ok := storage.ParseJSONPath(path)
+	if !ok {
+		return 0, ErrInvalidJSONPath
+	}
+
+	doc, err := db.getJSON(key)
+	if err != nil || doc == nil || !doc.Has(parsedPath) {
+		return 0, err
+	}
+
+	patch := &storage.JSONPatch{}
+	patch.Delete(parsedPath)
+	db.patch(key, patch)
+	return 1, nil
+}
+
+// JSONNumIncrBy adds increment, a JSON number, to the number at path in
+// the JSON document at key and returns the result. Integers stay integers
+// unless the sum would overflow.
+func (db *Database) JSONNumIncrBy(key, path, increment string) (string, error) {
+	db.lock()
+	defer db.unlock()
+
+	parsedPath, ok := storage.ParseJSONPath(path)
+	if !ok {
+		return "", ErrInvalidJSONPath
+	}
+	if _, err := json.Number(increment).Float64(); err != nil || !json.Valid([]byte(increment)) {
+		return "", ErrNotFloat
+	}
+
+	doc, err := db.getJSON(key)
+	if err != nil {
+		return "", err
+	}
+	if doc == nil {
+		return "", ErrNoSuchKey
+	}
+
+	current, ok := doc.Get(parsedPath)
+	if !ok {
+		return "", ErrNoSuchPath
+	}
+	if !doc.IsNumber(parsedPath) {
+		return "", ErrNotNumber
+	}
+
+	result, ok := storage.AddJSONNumbers(json.Number(current), json.Number(increment))
+	if !ok {
+		return "", ErrOverflow
+	}
+
+	patch := &storage.JSONPatch{}
+	patch.IncrBy(parsedPath, json.Number(increment))
+	db.patch(key, patch)
+	return string(result), nil
+}
+
+// getJSON returns the visible JSON document at key, or nil if the key is
+// missing. The result must not be modified.
+func (db *Database) getJSON(key string) (*storage.JSON, error) {
+	value, err := db.getValue(key, storage.TypeJSON)
+	if value == nil {
+		return nil, err
+	}
+	return value.(*storage.JSON), nil
+}
@@ -0,0 +1,184 @@
+package database
+
+import "testing"
+
+func TestJSONSetAndGet(t *testing.T) {
+	db := New()
+
+	if ok, err := db.JSONSet("doc", "$", `{"name":"kv","tags":["a","b"],"stats":{"hits":1}}`, false, false); !ok || err != nil {
+		t.Fatalf("Expected (true, nil), got (%v, %v)", ok, err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"$", `{"name":"kv","stats":{"hits":1},"tags":["a","b"]}`},
+		{"$.name", `"kv"`},
+		{".tags[1]", `"b"`},
+		{"$.tags[-1]", `"b"`},
+		{`$["stats"].hits`, "1"},
+	}
+
+	for _, test := range tests {
+		if got, err := db.JSONGet("doc", test.path); got != test.expected || err != nil {
+			t.Errorf("Path '%s': expected ('%s', nil), got ('%s', %v)", test.path, test.expected, got, err)
+		}
+	}
+
+	if _, err := db.JSONGet("doc", "$.missing"); err != ErrNoSuchPath {
+		t.Errorf("Expected ErrNoSuchPath, got %v", err)
+	}
+
+	if got, err := db.JSONGet("missing", "$"); got != "NULL" || err != nil {
+		t.Errorf("Expected ('NULL', nil), got ('%s', %v)", got, err)
+	}
+
+	if got := db.Type("doc"); got != "json" {
+		t.Errorf("Expected 'json', got '%s'", got)
+	}
+}
+
+func TestJSONSetValidation(t *testing.T) {
+	db := New()
+
+	if _, err := db.JSONSet("doc", "$", `{"a":`, false, false); err != ErrInvalidJSON {
+		t.Errorf("Expected ErrInvalidJSON, got %v", err)
+	}
+
+	if _, err := db.JSONSet("doc", "$.a", "1", false, false); err != ErrNotAtRoot {
+		t.Errorf("Expected ErrNotAtRoot, got %v", err)
+	}
+
+	if _, err := db.JSONSet("doc", "$..a", "1", false, false); err != ErrInvalidJSONPath {
+		t.Errorf("Expected ErrInvalidJSONPath, got %v", err)
+	}
+
+	db.Set("plain", "text")
+	if _, err := db.JSONSet("plain", "$", "1", false, false); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	db.JSONSet("doc", "$", `{"a":1}`, false, false)
+
+	// NX and XX look at the path, and a missing parent is never created
+	if ok, _ := db.JSONSet("doc", "$.a", "2", true, false); ok {
+		t.Error("Expected NX to fail on an existing path")
+	}
+	if ok, _ := db.JSONSet("doc", "$.b", "2", false, true); ok {
+		t.Error("Expected XX to fail on a missing path")
+	}
+	if ok, _ := db.JSONSet("doc", "$.x.y", "2", false, false); ok {
+		t.Error("Expected a write under a missing parent to fail")
+	}
+	if ok, _ := db.JSONSet("doc", "$.b", "[1,2]", true, false); !ok {
+		t.Error("Expected NX to succeed on a missing path")
+	}
+
+	if got, _ := db.JSONGet("doc", "$"); got != `{"a":1,"b":[1,2]}` {
+		t.Errorf("Expected '{\"a\":1,\"b\":[1,2]}', got '%s'", got)
+	}
+}
+
+func TestJSONDel(t *testing.T) {
+	db := New()
+	db.JSONSet("doc", "$", `{"a":1,"list":[1,2,3]}`, false, false)
+
+	if got, err := db.JSONDel("doc", "$.list[0]"); got != 1 || err != nil {
+		t.Errorf("Expected (1, nil), got (%d, %v)", got, err)
+	}
+	if got, _ := db.JSONGet("doc", "$.list"); got != "[2,3]" {
+		t.Errorf("Expected '[2,3]', got '%s'", got)
+	}
+
+	// Removing the last element leaves an empty array, not null
+	db.JSONSet("n", "$", "[1]", false, false)
+	db.JSONDel("n", "$[0]")
+	if got, _ := db.JSONGet("n", "$"); got != "[]" {
+		t.Errorf("Expected '[]', got '%s'", got)
+	}
+
+	if got, _ := db.JSONDel("doc", "$.missing"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	// Deleting the root removes the key
+	if got, _ := db.JSONDel("doc", "$"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+	if got := db.Type("doc"); got != "none" {
+		t.Errorf("Expected 'none', got '%s'", got)
+	}
+}
+
+func TestJSONNumIncrBy(t *testing.T) {
+	db := New()
+	db.JSONSet("doc", "$", `{"n":1,"f":1.5,"s":"x","big":9223372036854775807}`, false, false)
+
+	tests := []struct {
+		path      string
+		increment string
+		expected  string
+		err       error
+	}{
+		{"$.n", "2", "3", nil},
+		{"$.n", "-5", "-2", nil},
+		{"$.f", "1", "2.5", nil},
+		{"$.n", "0.5", "-1.5", nil},
+		{"$.big", "1", "9.223372036854776e+18", nil},
+		{"$.s", "1", "", ErrNotNumber},
+		{"$.missing", "1", "", ErrNoSuchPath},
+		{"$.n", "abc", "", ErrNotFloat},
+		{"$.big", "1e308", "", nil},
+		{"$.big", "1e308", "", ErrOverflow},
+	}
+
+	for _, test := range tests {
+		got, err := db.JSONNumIncrBy("doc", test.path, test.increment)
+		if err != test.err || (test.expected != "" && got != test.expected) {
+			t.Errorf("%s += %s: expected ('%s', %v), got ('%s', %v)", test.path, test.increment, test.expected, test.err, got, err)
+		}
+	}
+
+	if _, err := db.JSONNumIncrBy("missing", "$", "1"); err != ErrNoSuchKey {
+		t.Errorf("Expected ErrNoSuchKey, got %v", err)
+	}
+}
+
+func TestJSONTransactionRollsBackPaths(t *testing.T) {
+	db := New()
+	db.JSONSet("doc", "$", `{"a":1,"b":{"c":2}}`, false, false)
+
+	db.Begin()
+	db.JSONSet("doc", "$.a", "10", false, false)
+	db.JSONNumIncrBy("doc", "$.b.c", "5")
+
+	db.Begin()
+	db.JSONDel("doc", "$.b")
+	db.JSONSet("doc", "$.d", "true", false, false)
+	if got, _ := db.JSONGet("doc", "$"); got != `{"a":10,"d":true}` {
+		t.Errorf("Expected '{\"a\":10,\"d\":true}', got '%s'", got)
+	}
+	db.Rollback()
+
+	if got, _ := db.JSONGet("doc", "$"); got != `{"a":10,"b":{"c":7}}` {
+		t.Errorf("Expected '{\"a\":10,\"b\":{\"c\":7}}', got '%s'", got)
+	}
+
+	db.Rollback()
+	if got, _ := db.JSONGet("doc", "$"); got != `{"a":1,"b":{"c":2}}` {
+		t.Errorf("Expected '{\"a\":1,\"b\":{\"c\":2}}', got '%s'", got)
+	}
+
+	// Changes to different paths commit together, even when the base
+	// document was changed by another connection in the meantime
+	other := db.NewSession()
+	db.Begin()
+	db.JSONSet("doc", "$.a", "2", false, false)
+	other.JSONSet("doc", "$.e", `"x"`, false, false)
+	db.Commit()
+
+	if got, _ := db.JSONGet("doc", "$"); got != `{"a":2,"b":{"c":2},"e":"x"}` {
+		t.Errorf("Expected '{\"a\":2,\"b\":{\"c\":2},\"e\":\"x\"}', got '%s'", got)
+	}
+}
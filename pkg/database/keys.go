@@ -0,0 +1,155 @@
+package database
+
+import (
+	"encoding/hex"
+	"simple-database/pkg/glob"
+	"sort"
+	"strings"
+)
+
+// DefaultScanCount is the number of keys SCAN visits when no count is given
+const DefaultScanCount = 10
+
+// Range returns the keys between start and end (both inclusive) in sorted
+// order, including keys staged by open transactions. A limit of zero or less
+// returns every matching key.
+func (db *Database) Range(start, end string, limit int) []string {
+	db.lock()
+	defer db.unlock()
+
+	var keys []string
+	db.ascend(start, func(key string) bool {
+		if key > end {
+			return false
+		}
+		keys = append(keys, key)
+		return limit <= 0 || len(keys) < limit
+	})
+	return keys
+}
+
+// Prefix returns the keys starting with prefix in sorted order, including
+// keys staged by open transactions
+func (db *Database) Prefix(prefix string) []string {
+	db.lock()
+	defer db.unlock()
+
+	var keys []string
+	db.ascend(prefix, func(key string) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Keys returns every visible key matching the glob pattern in sorted order.
+// Only the part of the key index sharing the pattern's literal prefix is walked.
+func (db *Database) Keys(pattern string) []string {
+	db.lock()
+	defer db.unlock()
+
+	prefix := glob.Prefix(pattern)
+
+	var keys []string
+	db.ascend(prefix, func(key string) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+		if glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys
+}
+
+// Scan visits at most count keys starting at cursor and returns the ones
+// matching pattern, together with the cursor to continue from. The cursor
+// "0" starts a new iteration, and a returned cursor of "0" means the
+// iteration is complete. An empty pattern matches every key.
+//
+// Each call does a bounded amount of work, so iterating a large keyspace
+// never holds up other commands for long. Keys that exist for the whole
+// iteration are returned exactly once.
+func (db *Database) Scan(cursor, pattern string, count int) (string, []string, error) {
+	db.lock()
+	defer db.unlock()
+
+	from := ""
+	if cursor != "0" {
+		decoded, err := hex.DecodeString(cursor)
+		if err != nil || len(decoded) == 0 {
+			return "", nil, ErrInvalidCursor
+		}
+		from = string(decoded)
+	}
+	if count <= 0 {
+		count = DefaultScanCount
+	}
+
+	var keys []string
+	next := "0"
+	visited := 0
+	db.ascend(from, func(key string) bool {
+		if visited == count {
+			next = hex.EncodeToString([]byte(key))
+			return false
+		}
+		visited++
+		if pattern == "" || glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return next, keys, nil
+}
+
+// ascend calls fn for every visible key not less than from, in sorted order,
+// until fn returns false. It merges the ordered key index of the main storage
+// with the keys staged in the transaction layers: staged sets are added and
+// staged unsets are hidden.
+func (db *Database) ascend(from string, fn func(key string) bool) {
+	staged := db.transactions.StagedKeys()
+
+	var added []string
+	for key, exists := range staged {
+		if exists && key >= from {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+
+	stopped := false
+	db.baseStorage().Ascend(from, func(key string) bool {
+		for len(added) > 0 && added[0] < key {
+			if !fn(added[0]) {
+				stopped = true
+				return false
+			}
+			added = added[1:]
+		}
+		if len(added) > 0 && added[0] == key {
+			added = added[1:]
+		}
+		if exists, touched := staged[key]; touched && !exists {
+			return true
+		}
+		if !fn(key) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+
+	if stopped {
+		return
+	}
+	for _, key := range added {
+		if !fn(key) {
+			return
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	db := New()
+	db.MSet("b", "1", "d", "1", "a", "1", "c", "1", "e", "1")
+
+	if got := strings.Join(db.Range("b", "d", 0), ","); got != "b,c,d" {
+		t.Errorf("Expected 'b,c,d', got '%s'", got)
+	}
+
+	if got := strings.Join(db.Range("a", "z", 2), ","); got != "a,b" {
+		t.Errorf("Expected 'a,b', got '%s'", got)
+	}
+
+	if got := db.Range("x", "z", 0); len(got) != 0 {
+		t.Errorf("Expected no keys, got %v", got)
+	}
+
+	db.Unset("c")
+
+	if got := strings.Join(db.Range("a", "z", 0), ","); got != "a,b,d,e" {
+		t.Errorf("Expected 'a,b,d,e', got '%s'", got)
+	}
+}
+
+func TestRangeInTransaction(t *testing.T) {
+	db := New()
+	db.MSet("b", "1", "d", "1", "f", "1")
+
+	db.Begin()
+	db.Set("a", "2")
+	db.Set("d", "2")
+	db.Unset("f")
+
+	db.Begin()
+	db.Set("e", "3")
+	db.Unset("b")
+
+	if got := strings.Join(db.Range("a", "z", 0), ","); got != "a,d,e" {
+		t.Errorf("Expected 'a,d,e', got '%s'", got)
+	}
+
+	// The limit applies to the merged view, not the committed keys
+	if got := strings.Join(db.Range("a", "z", 2), ","); got != "a,d" {
+		t.Errorf("Expected 'a,d', got '%s'", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := strings.Join(db.Range("a", "z", 0), ","); got != "a,b,d" {
+		t.Errorf("Expected 'a,b,d', got '%s'", got)
+	}
+
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := strings.Join(db.Range("a", "z", 0), ","); got != "a,b,d" {
+		t.Errorf("Expected 'a,b,d', got '%s'", got)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	db := New()
+	db.MSet("user:1", "a", "user:2", "b", "users", "c", "order:1", "d")
+
+	if got := strings.Join(db.Prefix("user:"), ","); got != "user:1,user:2" {
+		t.Errorf("Expected 'user:1,user:2', got '%s'", got)
+	}
+
+	db.Begin()
+	db.Set("user:0", "e")
+	db.Unset("user:2")
+
+	if got := strings.Join(db.Prefix("user"), ","); got != "user:0,user:1,users" {
+		t.Errorf("Expected 'user:0,user:1,users', got '%s'", got)
+	}
+
+	if got := strings.Join(db.Prefix(""), ","); got != "order:1,user:0,user:1,users" {
+		t.Errorf("Expected every key, got '%s'", got)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	db := New()
+	db.MSet("user:1", "a", "user:2", "b", "user:10", "c", "order:1", "d")
+
+	if got := strings.Join(db.Keys("user:?"), ","); got != "user:1,user:2" {
+		t.Errorf("Expected 'user:1,user:2', got '%s'", got)
+	}
+
+	if got := strings.Join(db.Keys("*:1"), ","); got != "order:1,user:1" {
+		t.Errorf("Expected 'order:1,user:1', got '%s'", got)
+	}
+
+	db.Begin()
+	db.Unset("user:1")
+	db.Set("user:3", "e")
+
+	if got := strings.Join(db.Keys("user:*"), ","); got != "user:10,user:2,user:3" {
+		t.Errorf("Expected 'user:10,user:2,user:3', got '%s'", got)
+	}
+}
+
+func TestScan(t *testing.T) {
+	db := New()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		db.Set(key, "1")
+	}
+
+	var seen []string
+	cursor := "0"
+	calls := 0
+	for {
+		next, keys, err := db.Scan(cursor, "", 3)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(keys) > 3 {
+			t.Errorf("Expected at most 3 keys per call, got %d", len(keys))
+		}
+		seen = append(seen, keys...)
+		calls++
+
+		// Writes between calls must not disturb the iteration
+		if calls == 1 {
+			db.Unset("e")
+			db.Set("h", "1")
+		}
+
+		if next == "0" {
+			break
+		}
+		cursor = next
+	}
+
+	if got := strings.Join(seen, ","); got != "a,b,c,d,f,g,h" {
+		t.Errorf("Expected 'a,b,c,d,f,g,h', got '%s'", got)
+	}
+
+	if _, _, err := db.Scan("not-hex", "", 0); err != ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestScanWithMatchInTransaction(t *testing.T) {
+	db := New()
+	db.MSet("user:1", "a", "user:2", "b", "order:1", "c")
+
+	db.Begin()
+	db.Set("user:3", "d")
+	db.Unset("user:2")
+
+	var seen []string
+	cursor := "0"
+	for {
+		next, keys, err := db.Scan(cursor, "user:*", 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		seen = append(seen, keys...)
+		if next == "0" {
+			break
+		}
+		cursor = next
+	}
+
+	if got := strings.Join(seen, ","); got != "user:1,user:3" {
+		t.Errorf("Expected 'user:1,user:3', got '%s'", got)
+	}
+}
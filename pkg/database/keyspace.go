@@ -0,0 +1,197 @@
+package database
+
+import (
+	"errors"
+	"simple-database/pkg/storage"
+)
+
+var (
+	ErrInvalidDB     = errors.New("DB INDEX IS OUT OF RANGE")
+	ErrSameDB        = errors.New("SOURCE AND DESTINATION DB ARE THE SAME")
+	ErrInTransaction = errors.New("NOT ALLOWED IN A TRANSACTION")
+)
+
+// emptyStorage stands in for the committed data of a database that a
+// transaction layer cleared. It is never written to.
+var emptyStorage = storage.New()
+
+// keyspace is one numbered database: its storage, with its own value
+// counts, the clients blocked on its keys and where to report its changes
+type keyspace struct {
+	index         int
+	storage       *storage.Storage
+	waiters       map[string][]*waiter
+	ready         map[string]bool
+	streamWaiters map[string]map[*streamWaiter]bool
+	watchers      *watchers
+}
+
+// newKeyspace creates the empty keyspace for database index
+func newKeyspace(index int, watchers *watchers) *keyspace {
+	return &keyspace{
+		index:         index,
+		storage:       storage.New(),
+		waiters:       make(map[string][]*waiter),
+		ready:         make(map[string]bool),
+		streamWaiters: make(map[string]map[*streamWaiter]bool),
+		watchers:      watchers,
+	}
+}
+
+// flush removes every key from the keyspace's storage
+func (ks *keyspace) flush() {
+	ks.storage = storage.New()
+	ks.emit("", EventFlush)
+}
+
+// emit reports a committed change to the watchers
+func (ks *keyspace) emit(key string, eventType EventType) {
+	ks.watchers.emit(Event{DB: ks.index, Key: key, Type: eventType})
+}
+
+// Select switches the session to database index. Transactions are scoped
+// to the selected database, so it cannot switch inside one.
+func (db *Database) Select(index int) error {
+	db.lock()
+	defer db.unlock()
+
+	if err := db.checkDB(index); err != nil {
+		return err
+	}
+	db.keyspace = db.shared.keyspaces[index]
+	db.index = index
+	return nil
+}
+
+// Move moves key from the selected database to database index. It returns
+// false if the key does not exist or index already holds the key.
+func (db *Database) Move(key string, index int) (bool, error) {
+	moved := false
+	err := db.intercept(moveWrites(key, key), func([]Write) error {
+		if err := db.checkDB(index); err != nil {
+			return err
+		}
+		if index == db.index {
+			return ErrSameDB
+		}
+
+		source, target := db.keyspace, db.shared.keyspaces[index]
+		value, object := source.storage.Get(key), source.storage.GetObject(key)
+		if value == "NULL" && object == nil {
+			return nil
+		}
+		if target.storage.Get(key) != "NULL" || target.storage.GetObject(key) != nil {
+			return nil
+		}
+
+		if object == nil {
+			if err := db.shared.schema.Check(key, value); err != nil {
+				return err
+			}
+		}
+
+		// The value leaves the source, so the target can take it over as is
+		if object != nil {
+			target.applySetObject(key, object)
+		} else {
+			target.applySet(key, value)
+		}
+		source.applyUnset(key)
+		moved = true
+		return nil
+	})
+	return moved, err
+}
+
+// SwapDB swaps the contents of databases a and b. Sessions keep their
+// selected index, so they see the other database's data from then on, and
+// clients blocked on either database check their keys again.
+func (db *Database) SwapDB(a, b int) error {
+	db.lock()
+	defer db.unlock()
+
+	if err := db.checkDB(a); err != nil {
+		return err
+	}
+	if err := db.checkDB(b); err != nil {
+		return err
+	}
+
+	if a == b {
+		return nil
+	}
+
+	first, second := db.shared.keyspaces[a], db.shared.keyspaces[b]
+	first.storage, second.storage = second.storage, first.storage
+	for _, ks := range []*keyspace{first, second} {
+		ks.wakeAll()
+		ks.emit("", EventFlush)
+	}
+	return nil
+}
+
+// checkDB validates a database index for SELECT, MOVE and SWAPDB, which
+// are not allowed inside a transaction
+func (db *Database) checkDB(index int) error {
+	if db.transactions.InTransaction() {
+		return ErrInTransaction
+	}
+	if index < 0 || index >= len(db.shared.keyspaces) {
+		return ErrInvalidDB
+	}
+	return nil
+}
+
+// DBSize returns the number of keys in the selected database, including
+// the changes staged in the current transaction
+func (db *Database) DBSize() int {
+	db.lock()
+	defer db.unlock()
+
+	base := db.baseStorage()
+	size := base.Len()
+	for key, exists := range db.transactions.StagedKeys() {
+		if base.Type(key) != storage.TypeNone {
+			size--
+		}
+		if exists {
+			size++
+		}
+	}
+	return size
+}
+
+// FlushDB removes every key from the selected database. Inside a
+// transaction the removal is staged like any other change, so ROLLBACK
+// brings the keys back.
+func (db *Database) FlushDB() {
+	db.lock()
+	defer db.unlock()
+
+	if db.transactions.InTransaction() {
+		db.transactions.Flush()
+		return
+	}
+	db.keyspace.flush()
+}
+
+// FlushAll removes every key from every database. Inside a transaction the
+// removal is staged like FlushDB, and the other databases are emptied when
+// it commits, so ROLLBACK brings every key back.
+func (db *Database) FlushAll() {
+	db.lock()
+	defer db.unlock()
+
+	if db.transactions.InTransaction() {
+		db.transactions.FlushAll()
+		return
+	}
+	db.flushAll()
+}
+
+// flushAll removes every key from every database's storage
+func (db *Database) flushAll() {
+	for _, ks := range db.shared.keyspaces {
+		ks.flush()
+	}
+}
@@ -0,0 +1,304 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectIsolatesDatabases(t *testing.T) {
+	db := New()
+	db.Set("a", "10")
+
+	if err := db.Select(1); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if got, _ := db.Get("a"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+	db.Set("a", "20")
+	db.Set("b", "10")
+
+	// Value counts are kept per database
+	if got := db.NumEqualTo("10"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	db.Select(0)
+	if got, _ := db.Get("a"); got != "10" {
+		t.Errorf("Expected '10', got '%s'", got)
+	}
+	if got := db.NumEqualTo("20"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	// Sessions select their database independently
+	other := db.NewSession()
+	other.Select(1)
+	if got, _ := other.Get("a"); got != "20" {
+		t.Errorf("Expected '20', got '%s'", got)
+	}
+	if got, _ := db.Get("a"); got != "10" {
+		t.Errorf("Expected '10', got '%s'", got)
+	}
+
+	if err := db.Select(DefaultDatabases); err != ErrInvalidDB {
+		t.Errorf("Expected ErrInvalidDB, got %v", err)
+	}
+	if err := NewWithDatabases(2).Select(2); err != ErrInvalidDB {
+		t.Errorf("Expected ErrInvalidDB, got %v", err)
+	}
+}
+
+func TestTransactionsAreScopedToSelectedDatabase(t *testing.T) {
+	db := New()
+
+	db.Begin()
+	db.Set("a", "1")
+	if err := db.Select(1); err != ErrInTransaction {
+		t.Errorf("Expected ErrInTransaction, got %v", err)
+	}
+	if _, err := db.Move("a", 1); err != ErrInTransaction {
+		t.Errorf("Expected ErrInTransaction, got %v", err)
+	}
+	if err := db.SwapDB(0, 1); err != ErrInTransaction {
+		t.Errorf("Expected ErrInTransaction, got %v", err)
+	}
+	db.Commit()
+
+	db.Select(1)
+	if got, _ := db.Get("a"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+	db.Select(0)
+	if got, _ := db.Get("a"); got != "1" {
+		t.Errorf("Expected '1', got '%s'", got)
+	}
+}
+
+func TestMove(t *testing.T) {
+	db := New()
+	db.Set("s", "v")
+	db.HSet("h", "f", "1")
+
+	for _, key := range []string{"s", "h"} {
+		if ok, err := db.Move(key, 2); !ok || err != nil {
+			t.Errorf("Move '%s': expected (true, nil), got (%v, %v)", key, ok, err)
+		}
+	}
+	if got := db.Exists("s", "h"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+	if got := db.NumEqualTo("v"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	if ok, _ := db.Move("missing", 2); ok {
+		t.Error("Expected moving a missing key to fail")
+	}
+	db.Set("s", "other")
+	if ok, _ := db.Move("s", 2); ok {
+		t.Error("Expected moving onto an existing key to fail")
+	}
+	if _, err := db.Move("s", 0); err != ErrSameDB {
+		t.Errorf("Expected ErrSameDB, got %v", err)
+	}
+
+	db.Select(2)
+	if got, _ := db.Get("s"); got != "v" {
+		t.Errorf("Expected 'v', got '%s'", got)
+	}
+	if got, _ := db.HGet("h", "f"); got != "1" {
+		t.Errorf("Expected '1', got '%s'", got)
+	}
+	if got := db.NumEqualTo("v"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestSwapDB(t *testing.T) {
+	db := New()
+	db.Set("a", "zero")
+	db.Select(1)
+	db.Set("a", "one")
+
+	other := db.NewSession()
+	if err := other.SwapDB(0, 1); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	// Sessions keep their index and see the swapped data
+	if got, _ := db.Get("a"); got != "zero" {
+		t.Errorf("Expected 'zero', got '%s'", got)
+	}
+	if got, _ := other.Get("a"); got != "one" {
+		t.Errorf("Expected 'one', got '%s'", got)
+	}
+
+	if err := db.SwapDB(0, -1); err != ErrInvalidDB {
+		t.Errorf("Expected ErrInvalidDB, got %v", err)
+	}
+}
+
+func TestBlockedClientsFollowTheirDatabase(t *testing.T) {
+	db := New()
+	db.Select(1)
+	results := blockingPopAsync(db, []string{"q"}, time.Second)
+	waitForWaiters(t, db, "q", 1)
+
+	// A push to the same key in another database does not serve the client
+	writer := db.NewSession()
+	writer.RPush("q", "db0")
+
+	select {
+	case result := <-results:
+		t.Fatalf("Expected the client to keep waiting, got %v", result)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Moving the list into its database does
+	writer.Move("q", 1)
+	if result := <-results; !result.ok || result.value != "db0" {
+		t.Errorf("Expected (db0, true), got (%s, %v)", result.value, result.ok)
+	}
+
+	results = blockingPopAsync(db, []string{"q"}, time.Second)
+	waitForWaiters(t, db, "q", 1)
+	writer.RPush("q", "swapped")
+	writer.SwapDB(0, 1)
+	if result := <-results; !result.ok || result.value != "swapped" {
+		t.Errorf("Expected (swapped, true), got (%s, %v)", result.value, result.ok)
+	}
+}
+
+func TestDBSizeCountsStagedChanges(t *testing.T) {
+	db := New()
+	db.MSet("a", "1", "b", "2")
+	db.RPush("list", "x")
+
+	if got := db.DBSize(); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	db.Begin()
+	db.Unset("a")
+	db.Set("b", "3")
+	db.Set("c", "4")
+	if got := db.DBSize(); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+	db.LPop("list", 1)
+	if got := db.DBSize(); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+	db.Rollback()
+
+	if got := db.DBSize(); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+}
+
+func TestFlushDBRollsBack(t *testing.T) {
+	db := New()
+	db.MSet("a", "1", "b", "1")
+	db.HSet("h", "f", "v")
+
+	db.Begin()
+	db.Set("c", "1")
+
+	db.Begin()
+	db.FlushDB()
+	if got := db.DBSize(); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+	if got := db.NumEqualTo("1"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	// Writes after the flush start from an empty database
+	db.Set("a", "1")
+	db.HSet("h", "g", "w")
+	if got := strings.Join(db.Keys("*"), ","); got != "a,h" {
+		t.Errorf("Expected 'a,h', got '%s'", got)
+	}
+	if got, _ := db.HGet("h", "f"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+	if got := db.NumEqualTo("1"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+	db.Rollback()
+
+	if got := strings.Join(db.Keys("*"), ","); got != "a,b,c,h" {
+		t.Errorf("Expected 'a,b,c,h', got '%s'", got)
+	}
+	if got := db.NumEqualTo("1"); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	db.FlushDB()
+	db.Set("d", "1")
+	db.Commit()
+
+	if got := strings.Join(db.Keys("*"), ","); got != "d" {
+		t.Errorf("Expected 'd', got '%s'", got)
+	}
+	if got := db.NumEqualTo("1"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestFlushAll(t *testing.T) {
+	db := New()
+	db.Set("a", "1")
+	db.Select(1)
+	db.Set("a", "1")
+
+	db.FlushDB()
+	if got := db.DBSize(); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+	db.Select(0)
+	if got := db.DBSize(); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	other := db.NewSession()
+	other.Select(1)
+	other.Set("b", "2")
+
+	// A staged flush hides every key and can be rolled back
+	db.Begin()
+	db.FlushAll()
+	if got := db.DBSize(); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+	if got := other.DBSize(); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+	db.Rollback()
+	if got := db.DBSize(); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	// On commit every database is emptied before the later writes land
+	db.Begin()
+	db.FlushAll()
+	db.Set("c", "3")
+	db.FlushDB()
+	db.Set("d", "4")
+	if err := db.Commit(); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	if got := db.Keys("*"); strings.Join(got, ",") != "d" {
+		t.Errorf("Expected 'd', got %v", got)
+	}
+	if got := other.DBSize(); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	db.FlushAll()
+	if got := db.DBSize(); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
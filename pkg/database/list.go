@@ -0,0 +1,122 @@
+package database
+
+import "simple-database/pkg/storage"
+
+// LPush inserts values at the head of the list at key, one after another,
+// and returns the new length of the list
+func (db *Database) LPush(key string, values ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.push(key, true, values)
+}
+
+// RPush appends values to the tail of the list at key and returns the new
+// length of the list
+func (db *Database) RPush(key string, values ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.push(key, false, values)
+}
+
+// LPop removes and returns up to count elements from the head of the list
+func (db *Database) LPop(key string, count int) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.pop(key, true, count)
+}
+
+// RPop removes and returns up to count elements from the tail of the list
+func (db *Database) RPop(key string, count int) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.pop(key, false, count)
+}
+
+// LRange returns the elements between start and stop (both inclusive).
+// Negative indexes count from the end of the list.
+func (db *Database) LRange(key string, start, stop int) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	list, err := db.getList(key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), list.Range(start, stop)...), nil
+}
+
+// LLen returns the length of the list at key, or 0 if it is missing
+func (db *Database) LLen(key string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	list, err := db.getList(key)
+	return len(list), err
+}
+
+// LTrim keeps only the elements between start and stop (both inclusive).
+// Trimming away every element removes the key.
+func (db *Database) LTrim(key string, start, stop int) error {
+	db.lock()
+	defer db.unlock()
+
+	list, err := db.getList(key)
+	if err != nil || list == nil {
+		return err
+	}
+
+	patch := &storage.ListPatch{}
+	patch.Trim(start, stop)
+	db.patch(key, patch)
+	return nil
+}
+
+// push adds values to one end of the list at key
+func (db *Database) push(key string, left bool, values []string) (int, error) {
+	list, err := db.getList(key)
+	if err != nil {
+		return 0, err
+	}
+
+	patch := &storage.ListPatch{}
+	patch.Push(left, values...)
+	db.patch(key, patch)
+	return len(list) + len(values), nil
+}
+
+// pop removes up to count elements from one end of the list at key
+func (db *Database) pop(key string, left bool, count int) ([]string, error) {
+	list, err := db.getList(key)
+	if err != nil || list == nil || count <= 0 {
+		return nil, err
+	}
+
+	count = min(count, len(list))
+	popped := make([]string, count)
+	for i := range popped {
+		if left {
+			popped[i] = list[i]
+		} else {
+			popped[i] = list[len(list)-1-i]
+		}
+	}
+
+	patch := &storage.ListPatch{}
+	patch.Pop(left, popped...)
+	db.patch(key, patch)
+	return popped, nil
+}
+
+// getList returns the visible list at key, or nil if the key is missing.
+// The result must not be modified.
+func (db *Database) getList(key string) (storage.List, error) {
+	value, err := db.getValue(key, storage.TypeList)
+	if value == nil {
+		return nil, err
+	}
+	return value.(storage.List), nil
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListOperations(t *testing.T) {
+	db := New()
+
+	if got, err := db.RPush("queue", "a", "b"); got != 2 || err != nil {
+		t.Errorf("Expected (2, nil), got (%d, %v)", got, err)
+	}
+
+	if got, _ := db.LPush("queue", "y", "z"); got != 4 {
+		t.Errorf("Expected 4, got %d", got)
+	}
+
+	elements, _ := db.LRange("queue", 0, -1)
+	if got := strings.Join(elements, ","); got != "z,y,a,b" {
+		t.Errorf("Expected 'z,y,a,b', got '%s'", got)
+	}
+
+	elements, _ = db.LRange("queue", -2, 10)
+	if got := strings.Join(elements, ","); got != "a,b" {
+		t.Errorf("Expected 'a,b', got '%s'", got)
+	}
+
+	popped, _ := db.LPop("queue", 1)
+	if got := strings.Join(popped, ","); got != "z" {
+		t.Errorf("Expected 'z', got '%s'", got)
+	}
+
+	popped, _ = db.RPop("queue", 2)
+	if got := strings.Join(popped, ","); got != "b,a" {
+		t.Errorf("Expected 'b,a', got '%s'", got)
+	}
+
+	if got, _ := db.LLen("queue"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	// Popping the last element removes the key
+	db.LPop("queue", 5)
+	if got := db.Type("queue"); got != "none" {
+		t.Errorf("Expected 'none', got '%s'", got)
+	}
+
+	if popped, _ := db.LPop("queue", 1); popped != nil {
+		t.Errorf("Expected nil, got %v", popped)
+	}
+}
+
+func TestListTrim(t *testing.T) {
+	db := New()
+	db.RPush("list", "a", "b", "c", "d", "e")
+
+	if err := db.LTrim("list", 1, -2); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	elements, _ := db.LRange("list", 0, -1)
+	if got := strings.Join(elements, ","); got != "b,c,d" {
+		t.Errorf("Expected 'b,c,d', got '%s'", got)
+	}
+
+	db.LTrim("list", 5, 10)
+	if got := db.Exists("list"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestListWrongType(t *testing.T) {
+	db := New()
+	db.Set("name", "alice")
+	db.HSet("user", "name", "alice")
+
+	if _, err := db.LPush("name", "x"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	if _, err := db.LRange("user", 0, -1); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+
+	db.RPush("list", "a")
+	if _, err := db.HGet("list", "a"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+}
+
+func TestListRollbackRestoresPoppedElements(t *testing.T) {
+	db := New()
+	db.RPush("jobs", "1", "2", "3")
+
+	db.Begin()
+	db.RPush("jobs", "4")
+
+	db.Begin()
+	popped, _ := db.LPop("jobs", 2)
+	if got := strings.Join(popped, ","); got != "1,2" {
+		t.Errorf("Expected '1,2', got '%s'", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	elements, _ := db.LRange("jobs", 0, -1)
+	if got := strings.Join(elements, ","); got != "1,2,3,4" {
+		t.Errorf("Expected '1,2,3,4', got '%s'", got)
+	}
+
+	db.LPop("jobs", 1)
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	elements, _ = db.LRange("jobs", 0, -1)
+	if got := strings.Join(elements, ","); got != "2,3,4" {
+		t.Errorf("Expected '2,3,4', got '%s'", got)
+	}
+
+	// Emptying a list inside a transaction hides the key until rollback
+	db.Begin()
+	db.LPop("jobs", 3)
+	if got := db.Exists("jobs"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+	db.Rollback()
+
+	if got, _ := db.LLen("jobs"); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+}
+
+func TestListPopConflictsWithConcurrentPop(t *testing.T) {
+	db := New()
+	other := db.NewSession()
+	db.RPush("q", "x", "y", "z")
+
+	// Both sessions are handed x, so only one of them may commit
+	db.Begin()
+	popped, _ := db.LPop("q", 1)
+	if got := strings.Join(popped, ","); got != "x" {
+		t.Errorf("Expected 'x', got '%s'", got)
+	}
+	popped, _ = other.LPop("q", 1)
+	if got := strings.Join(popped, ","); got != "x" {
+		t.Errorf("Expected 'x', got '%s'", got)
+	}
+	if err := db.Commit(); err != ErrConflict {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	db.Rollback()
+
+	elements, _ := db.LRange("q", 0, -1)
+	if got := strings.Join(elements, ","); got != "y,z" {
+		t.Errorf("Expected 'y,z', got '%s'", got)
+	}
+
+	// Pushes by others don't get in the way of a pop from the other end
+	db.Begin()
+	db.RPop("q", 1)
+	other.LPush("q", "w")
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Emptying the list removes only what was popped
+	db.Begin()
+	db.LPop("q", 2)
+	other.RPush("q", "v")
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	elements, _ = db.LRange("q", 0, -1)
+	if got := strings.Join(elements, ","); got != "v" {
+		t.Errorf("Expected 'v', got '%s'", got)
+	}
+
+	// The emptied list stays hidden for the rest of the transaction, while
+	// what others push in the meantime survives the commit
+	db.Begin()
+	db.LPop("q", 1)
+	other.RPush("q", "u")
+	db.Begin()
+	if popped, _ := db.LPop("q", 1); popped != nil {
+		t.Errorf("Expected nothing, got %v", popped)
+	}
+	db.RPush("q", "t")
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	elements, _ = db.LRange("q", 0, -1)
+	if got := strings.Join(elements, ","); got != "u,t" {
+		t.Errorf("Expected 'u,t', got '%s'", got)
+	}
+}
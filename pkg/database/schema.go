@@ -0,0 +1,32 @@
+package database
+
+import "simple-database/pkg/schema"
+
+// AddRule registers a schema rule for every database and session and returns
+// its ID. Rules are checked on every string write, inside and outside
+// transactions, including the destinations of Rename, Copy and Move; values
+// already stored are not checked. Structured values are not checked, so a
+// key with rules can still hold a hash or a list.
+func (db *Database) AddRule(rule schema.Rule) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.shared.schema.Add(rule)
+}
+
+// Rules returns the schema rules in the order they were added
+func (db *Database) Rules() []schema.Rule {
+	db.lock()
+	defer db.unlock()
+
+	return db.shared.schema.Rules()
+}
+
+// RemoveRule unregisters the schema rule with the given ID and reports
+// whether there was one
+func (db *Database) RemoveRule(id int) bool {
+	db.lock()
+	defer db.unlock()
+
+	return db.shared.schema.Remove(id)
+}
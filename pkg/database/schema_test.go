@@ -0,0 +1,117 @@
+package database
+
+import (
+	"errors"
+	"simple-database/pkg/schema"
+	"testing"
+)
+
+func TestSchemaRulesAreEnforcedOnSet(t *testing.T) {
+	db := New()
+	if _, err := db.AddRule(schema.Rule{Pattern: "age:*", Kind: schema.Range, Min: 0, Max: 150}); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if err := db.Set("age:1", "200"); !errors.Is(err, schema.ErrViolation) {
+		t.Errorf("Expected a violation, got %v", err)
+	}
+	if got, _ := db.Get("age:1"); got != "NULL" {
+		t.Errorf("Expected 'NULL', got '%s'", got)
+	}
+	if err := db.Set("age:1", "42"); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	// Rules apply to every session and inside transactions
+	other := db.NewSession()
+	other.Begin()
+	if err := other.Set("age:2", "old"); !errors.Is(err, schema.ErrViolation) {
+		t.Errorf("Expected a violation, got %v", err)
+	}
+	other.Set("age:2", "7")
+	if err := other.Commit(); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	if got, _ := db.Get("age:2"); got != "7" {
+		t.Errorf("Expected '7', got '%s'", got)
+	}
+
+	// Values stored before a rule was added stay, but can be removed
+	db.Set("name", "a very long name")
+	id, _ := db.AddRule(schema.Rule{Pattern: "name", Kind: schema.MaxLen, MaxLen: 4})
+	if got, _ := db.Get("name"); got != "a very long name" {
+		t.Errorf("Expected the old value, got '%s'", got)
+	}
+	if err := db.Unset("name"); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if err := db.Set("name", "abcde"); !errors.Is(err, schema.ErrViolation) {
+		t.Errorf("Expected a violation, got %v", err)
+	}
+	if !db.RemoveRule(id) {
+		t.Error("Expected the rule to be removed")
+	}
+	if err := db.Set("name", "abcde"); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	if got := len(db.Rules()); got != 1 {
+		t.Errorf("Expected 1 rule, got %d", got)
+	}
+}
+
+func TestSchemaRulesCoverEveryStringWrite(t *testing.T) {
+	db := New()
+	db.AddRule(schema.Rule{Pattern: "n:*", Kind: schema.Range, Min: 0, Max: 10})
+	db.Set("tmp", "999")
+	db.Set("n:1", "5")
+
+	violation := func(name string, err error) {
+		t.Helper()
+		if !errors.Is(err, schema.ErrViolation) {
+			t.Errorf("%s: expected a violation, got %v", name, err)
+		}
+	}
+
+	_, _, err := db.SetNX("n:2", "11")
+	violation("SetNX", err)
+	_, _, err = db.SetXX("n:1", "11")
+	violation("SetXX", err)
+	_, err = db.GetSet("n:1", "11")
+	violation("GetSet", err)
+	_, err = db.CompareAndSwap("n:1", "5", "11")
+	violation("CompareAndSwap", err)
+	violation("MSet", db.MSet("n:3", "1", "n:4", "11"))
+	_, err = db.MSetNX("n:3", "1", "n:4", "11")
+	violation("MSetNX", err)
+	violation("Rename", db.Rename("tmp", "n:5"))
+	_, err = db.RenameNX("tmp", "n:5")
+	violation("RenameNX", err)
+	_, err = db.Copy("tmp", "n:5", false)
+	violation("Copy", err)
+	_, err = db.SetBit("n:1", 0, 1)
+	violation("SetBit", err)
+
+	// Nothing was written, and the source of the failed renames is intact
+	if got := db.Exists("n:2", "n:3", "n:4", "n:5", "tmp"); got != 1 {
+		t.Errorf("Expected only tmp to exist, got %d keys", got)
+	}
+	if got, _ := db.Get("n:1"); got != "5" {
+		t.Errorf("Expected '5', got '%s'", got)
+	}
+
+	// A value stored before the rule cannot be moved to another database
+	db.RemoveRule(1)
+	db.Set("n:9", "99")
+	db.AddRule(schema.Rule{Pattern: "n:*", Kind: schema.Range, Min: 0, Max: 10})
+	_, err = db.Move("n:9", 1)
+	violation("Move", err)
+	if got := db.Exists("n:9"); got != 1 {
+		t.Errorf("Expected n:9 to stay, got %d", got)
+	}
+
+	// Rules only constrain strings, so a structured value is allowed
+	if _, err := db.HSet("n:6", "f", "v"); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"simple-database/pkg/storage"
+	"sort"
+)
+
+// SAdd adds members to the set at key and returns how many were new
+func (db *Database) SAdd(key string, members ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.getSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	patch := make(storage.SetPatch)
+	for _, member := range members {
+		if _, exists := set[member]; !exists {
+			patch[member] = true
+		}
+	}
+
+	if len(patch) > 0 {
+		db.patch(key, patch)
+	}
+	return len(patch), nil
+}
+
+// SRem removes members from the set at key and returns how many existed.
+// Removing the last member removes the key.
+func (db *Database) SRem(key string, members ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.getSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	patch := make(storage.SetPatch)
+	for _, member := range members {
+		if _, exists := set[member]; exists {
+			patch[member] = false
+		}
+	}
+
+	if len(patch) > 0 {
+		db.patch(key, patch)
+	}
+	return len(patch), nil
+}
+
+// SMembers returns the members of the set at key in sorted order
+func (db *Database) SMembers(key string) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.getSet(key)
+	return sortedMembers(set), err
+}
+
+// SIsMember reports whether member belongs to the set at key
+func (db *Database) SIsMember(key, member string) (bool, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.getSet(key)
+	_, exists := set[member]
+	return exists, err
+}
+
+// SCard returns the number of members of the set at key
+func (db *Database) SCard(key string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.getSet(key)
+	return len(set), err
+}
+
+// SInter returns the members present in every one of the sets, sorted
+func (db *Database) SInter(keys ...string) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.combineSets(keys, intersectSets)
+	return sortedMembers(set), err
+}
+
+// SUnion returns the members present in any of the sets, sorted
+func (db *Database) SUnion(keys ...string) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.combineSets(keys, unionSets)
+	return sortedMembers(set), err
+}
+
+// SDiff returns the members of the first set that are in none of the
+// others, sorted
+func (db *Database) SDiff(keys ...string) ([]string, error) {
+	db.lock()
+	defer db.unlock()
+
+	set, err := db.combineSets(keys, diffSets)
+	return sortedMembers(set), err
+}
+
+// SInterStore stores the intersection of the sets at destination and
+// returns its size
+func (db *Database) SInterStore(destination string, keys ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.storeCombinedSets(destination, keys, intersectSets)
+}
+
+// SUnionStore stores the union of the sets at destination and returns its size
+func (db *Database) SUnionStore(destination string, keys ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.storeCombinedSets(destination, keys, unionSets)
+}
+
+// SDiffStore stores the difference of the sets at destination and returns
+// its size
+func (db *Database) SDiffStore(destination string, keys ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	return db.storeCombinedSets(destination, keys, diffSets)
+}
+
+// storeCombinedSets replaces destination, whatever it held, with the
+// combination of the sets. An empty result removes destination.
+func (db *Database) storeCombinedSets(destination string, keys []string, combine func(sets []storage.Set) storage.Set) (int, error) {
+	set, err := db.combineSets(keys, combine)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(set) == 0 {
+		db.unset(destination)
+		return 0, nil
+	}
+	db.storeObject(destination, set)
+	return len(set), nil
+}
+
+// combineSets loads the sets at keys, treating missing keys as empty sets,
+// and combines them. The result is a new set the caller owns.
+func (db *Database) combineSets(keys []string, combine func(sets []storage.Set) storage.Set) (storage.Set, error) {
+	sets := make([]storage.Set, len(keys))
+	for i, key := range keys {
+		set, err := db.getSet(key)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+	return combine(sets), nil
+}
+
+// intersectSets returns the members present in every set
+func intersectSets(sets []storage.Set) storage.Set {
+	result := make(storage.Set)
+	for member := range sets[0] {
+		inAll := true
+		for _, other := range sets[1:] {
+			if _, exists := other[member]; !exists {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			result[member] = struct{}{}
+		}
+	}
+	return result
+}
+
+// unionSets returns the members present in any set
+func unionSets(sets []storage.Set) storage.Set {
+	result := make(storage.Set)
+	for _, set := range sets {
+		for member := range set {
+			result[member] = struct{}{}
+		}
+	}
+	return result
+}
+
+// diffSets returns the members of the first set missing from all others
+func diffSets(sets []storage.Set) storage.Set {
+	result := make(storage.Set)
+	for member := range sets[0] {
+		result[member] = struct{}{}
+	}
+	for _, other := range sets[1:] {
+		for member := range other {
+			delete(result, member)
+		}
+	}
+	return result
+}
+
+// sortedMembers returns the members of a set in sorted order
+func sortedMembers(set storage.Set) []string {
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// getSet returns the visible set at key, or nil if the key is missing.
+// The result must not be modified.
+func (db *Database) getSet(key string) (storage.Set, error) {
+	value, err := db.getValue(key, storage.TypeSet)
+	if value == nil {
+		return nil, err
+	}
+	return value.(storage.Set), nil
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetOperations(t *testing.T) {
+	db := New()
+
+	if got, err := db.SAdd("tags", "go", "db", "go"); got != 2 || err != nil {
+		t.Errorf("Expected (2, nil), got (%d, %v)", got, err)
+	}
+
+	if got, _ := db.SAdd("tags", "db", "kv"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	members, _ := db.SMembers("tags")
+	if got := strings.Join(members, ","); got != "db,go,kv" {
+		t.Errorf("Expected 'db,go,kv', got '%s'", got)
+	}
+
+	if ok, _ := db.SIsMember("tags", "go"); !ok {
+		t.Error("Expected 'go' to be a member")
+	}
+
+	if got, _ := db.SRem("tags", "go", "missing"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	if got, _ := db.SCard("tags"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	// Removing the last member removes the key
+	db.SRem("tags", "db", "kv")
+	if got := db.Type("tags"); got != "none" {
+		t.Errorf("Expected 'none', got '%s'", got)
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	db := New()
+	db.SAdd("a", "1", "2", "3")
+	db.SAdd("b", "2", "3", "4")
+	db.SAdd("c", "3", "5")
+
+	tests := []struct {
+		name     string
+		combine  func(keys ...string) ([]string, error)
+		keys     []string
+		expected string
+	}{
+		{"SInter", db.SInter, []string{"a", "b", "c"}, "3"},
+		{"SInter missing", db.SInter, []string{"a", "missing"}, ""},
+		{"SUnion", db.SUnion, []string{"a", "b", "c"}, "1,2,3,4,5"},
+		{"SDiff", db.SDiff, []string{"a", "b"}, "1"},
+		{"SDiff single", db.SDiff, []string{"c"}, "3,5"},
+	}
+
+	for _, test := range tests {
+		members, err := test.combine(test.keys...)
+		if got := strings.Join(members, ","); got != test.expected || err != nil {
+			t.Errorf("%s: expected ('%s', nil), got ('%s', %v)", test.name, test.expected, got, err)
+		}
+	}
+}
+
+func TestSetStoreReplacesDestination(t *testing.T) {
+	db := New()
+	db.SAdd("a", "1", "2")
+	db.SAdd("b", "2", "3")
+	db.Set("dst", "value")
+
+	if got, err := db.SUnionStore("dst", "a", "b"); got != 3 || err != nil {
+		t.Errorf("Expected (3, nil), got (%d, %v)", got, err)
+	}
+	if got := db.Type("dst"); got != "set" {
+		t.Errorf("Expected 'set', got '%s'", got)
+	}
+	if got := db.NumEqualTo("value"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	// An empty result removes the destination
+	if got, _ := db.SInterStore("dst", "a", "missing"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+	if got := db.Exists("dst"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestSetWrongType(t *testing.T) {
+	db := New()
+	db.Set("name", "alice")
+	db.SAdd("tags", "a")
+
+	if _, err := db.SAdd("name", "a"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+	if _, err := db.SInter("tags", "name"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+	if _, err := db.SDiffStore("out", "tags", "name"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+	if got := db.Exists("out"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestSetTransactionRollsBackMembers(t *testing.T) {
+	db := New()
+	db.SAdd("tags", "a", "b")
+
+	db.Begin()
+	db.SRem("tags", "a")
+	db.SAdd("tags", "c")
+
+	db.Begin()
+	db.SRem("tags", "b", "c")
+	if got := db.Type("tags"); got != "none" {
+		t.Errorf("Expected 'none', got '%s'", got)
+	}
+	db.Rollback()
+
+	members, _ := db.SMembers("tags")
+	if got := strings.Join(members, ","); got != "b,c" {
+		t.Errorf("Expected 'b,c', got '%s'", got)
+	}
+
+	db.Rollback()
+	members, _ = db.SMembers("tags")
+	if got := strings.Join(members, ","); got != "a,b" {
+		t.Errorf("Expected 'a,b', got '%s'", got)
+	}
+
+	db.Begin()
+	db.SAdd("tags", "d")
+	db.SInterStore("both", "tags", "tags")
+	db.Commit()
+
+	members, _ = db.SMembers("both")
+	if got := strings.Join(members, ","); got != "a,b,d" {
+		t.Errorf("Expected 'a,b,d', got '%s'", got)
+	}
+}
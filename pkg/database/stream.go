@@ -0,0 +1,424 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"simple-database/pkg/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidStreamID  = errors.New("INVALID STREAM ID")
+	ErrStreamIDTooSmall = errors.New("STREAM ID MUST BE GREATER THAN THE LAST ONE")
+	ErrGroupExists      = errors.New("BUSYGROUP CONSUMER GROUP ALREADY EXISTS")
+	ErrNoGroup          = errors.New("NOGROUP NO SUCH KEY OR CONSUMER GROUP")
+)
+
+// XAdd appends an entry with fields, given as alternating field names and
+// values, to the stream at key and returns its ID. An id of "*" picks the
+// current time in milliseconds, and "ms-*" picks the next sequence number
+// for ms; either way IDs keep increasing even if the clock goes back. An
+// explicit id must be greater than every ID already added.
+func (db *Database) XAdd(key, id string, fields ...string) (string, error) {
+	db.lock()
+	defer db.unlock()
+
+	stream, _, err := db.getStream(key)
+	if err != nil {
+		return "", err
+	}
+
+	next, err := nextStreamID(stream.LastID(), id)
+	if err != nil {
+		return "", err
+	}
+
+	patch := &storage.StreamPatch{}
+	patch.Add(next, append([]string(nil), fields...))
+	db.patch(key, patch)
+	return next.String(), nil
+}
+
+// nextStreamID resolves the ID requested by XADD after last
+func nextStreamID(last storage.StreamID, id string) (storage.StreamID, error) {
+	if id == "*" {
+		now := uint64(time.Now().UnixMilli())
+		if now > last.Ms {
+			return storage.StreamID{Ms: now}, nil
+		}
+		return nextAfter(last)
+	}
+
+	if ms, found := strings.CutSuffix(id, "-*"); found {
+		parsed, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			return storage.StreamID{}, ErrInvalidStreamID
+		}
+		if parsed > last.Ms {
+			return storage.StreamID{Ms: parsed}, nil
+		}
+		if parsed == last.Ms {
+			return nextAfter(last)
+		}
+		return storage.StreamID{}, ErrStreamIDTooSmall
+	}
+
+	parsed, ok := storage.ParseStreamID(id, 0)
+	if !ok {
+		return storage.StreamID{}, ErrInvalidStreamID
+	}
+	if !last.Less(parsed) {
+		return storage.StreamID{}, ErrStreamIDTooSmall
+	}
+	return parsed, nil
+}
+
+// nextAfter returns the ID following last, failing if there is none
+func nextAfter(last storage.StreamID) (storage.StreamID, error) {
+	if last == storage.MaxStreamID {
+		return storage.StreamID{}, ErrStreamIDTooSmall
+	}
+	return last.Next(), nil
+}
+
+// XLen returns the number of entries in the stream at key
+func (db *Database) XLen(key string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	stream, _, err := db.getStream(key)
+	return stream.Len(), err
+}
+
+// XRange returns up to count entries of the stream at key with IDs from
+// start to end (inclusive); a negative count means no limit. start may be
+// "-" for the first entry and end may be "+" for the last, and an ID
+// without a sequence number covers the whole millisecond.
+func (db *Database) XRange(key, start, end string, count int) ([]storage.StreamEntry, error) {
+	db.lock()
+	defer db.unlock()
+
+	from, ok := parseRangeID(start, "-", 0)
+	to, ok2 := parseRangeID(end, "+", math.MaxUint64)
+	if !ok || !ok2 {
+		return nil, ErrInvalidStreamID
+	}
+
+	stream, _, err := db.getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	return stream.Range(from, to, count), nil
+}
+
+// parseRangeID parses a range bound, where special stands for the
+// smallest or largest ID and a missing sequence number is defaultSeq
+func parseRangeID(bound, special string, defaultSeq uint64) (storage.StreamID, bool) {
+	switch {
+	case bound == special && special == "-":
+		return storage.StreamID{}, true
+	case bound == special:
+		return storage.MaxStreamID, true
+	}
+	return storage.ParseStreamID(bound, defaultSeq)
+}
+
+// XTrim removes the oldest entries of the stream at key and returns how
+// many were removed. With strategy "MAXLEN" at most threshold entries are
+// kept; with "MINID" entries with IDs less than threshold are removed.
+func (db *Database) XTrim(key, strategy, threshold string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	stream, exists, err := db.getStream(key)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	patch := &storage.StreamPatch{}
+	removed := 0
+	switch strategy {
+	case "MAXLEN":
+		maxLen, err := strconv.Atoi(threshold)
+		if err != nil || maxLen < 0 {
+			return 0, ErrNotInteger
+		}
+		removed = max(stream.Len()-maxLen, 0)
+		patch.TrimMaxLen(maxLen)
+	case "MINID":
+		minID, ok := storage.ParseStreamID(threshold, 0)
+		if !ok {
+			return 0, ErrInvalidStreamID
+		}
+		removed = stream.CountBefore(minID)
+		patch.TrimMinID(minID)
+	default:
+		return 0, ErrInvalidStreamID
+	}
+
+	if removed > 0 {
+		db.patch(key, patch)
+	}
+	return removed, nil
+}
+
+// XRead returns up to count entries (a negative count means no limit)
+// from each stream at keys with IDs greater than the matching ID in ids,
+// listing only the streams that have some. An ID of "$" means the last ID
+// of the stream. With a non-negative block it waits up to that long
+// (forever if zero) for entries to be added and committed when there are
+// none yet; after a timeout both results are empty. Inside a transaction it
+// never blocks.
+func (db *Database) XRead(keys, ids []string, count int, block time.Duration) ([]string, [][]storage.StreamEntry, error) {
+	db.lock()
+
+	after := make([]storage.StreamID, len(keys))
+	for i, key := range keys {
+		stream, _, err := db.getStream(key)
+		if err != nil {
+			db.unlock()
+			return nil, nil, err
+		}
+
+		if ids[i] == "$" {
+			after[i] = stream.LastID()
+			continue
+		}
+		id, ok := storage.ParseStreamID(ids[i], 0)
+		if !ok {
+			db.unlock()
+			return nil, nil, ErrInvalidStreamID
+		}
+		after[i] = id
+	}
+
+	return db.blockingRead(keys, block, func() ([]string, [][]storage.StreamEntry, error) {
+		var names []string
+		var results [][]storage.StreamEntry
+		for i, key := range keys {
+			stream, _, err := db.getStream(key)
+			if err != nil {
+				return nil, nil, err
+			}
+			if entries := stream.After(after[i], count); len(entries) > 0 {
+				names = append(names, key)
+				results = append(results, entries)
+			}
+		}
+		return names, results, nil
+	})
+}
+
+// XGroupCreate creates a consumer group on the stream at key that will
+// deliver the entries after id ("$" for the entries added from now on).
+// A missing stream is created when mkStream is set.
+func (db *Database) XGroupCreate(key, group, id string, mkStream bool) error {
+	db.lock()
+	defer db.unlock()
+
+	stream, exists, err := db.getStream(key)
+	if err != nil {
+		return err
+	}
+	if !exists && !mkStream {
+		return ErrNoSuchKey
+	}
+	if stream.HasGroup(group) {
+		return ErrGroupExists
+	}
+
+	start := stream.LastID()
+	if id != "$" {
+		var ok bool
+		if start, ok = storage.ParseStreamID(id, 0); !ok {
+			return ErrInvalidStreamID
+		}
+	}
+
+	patch := &storage.StreamPatch{}
+	patch.CreateGroup(group, start)
+	db.patch(key, patch)
+	return nil
+}
+
+// XReadGroup reads entries for consumer as a member of group. For an ID of
+// ">" it delivers up to count entries (a negative count means no limit)
+// that the group has not delivered to anyone yet, blocking like XRead if
+// there are none. For any other ID it delivers again the entries pending
+// for this consumer with greater IDs, so a restarted worker can pick up
+// where it left off. Every delivered entry stays pending, with its delivery
+// count raised, until it is acknowledged with XAck. Entries trimmed from
+// the stream while pending come back with no fields.
+func (db *Database) XReadGroup(group, consumer string, keys, ids []string, count int, block time.Duration) ([]string, [][]storage.StreamEntry, error) {
+	db.lock()
+
+	history := false
+	for i, key := range keys {
+		stream, exists, err := db.getStream(key)
+		if err == nil && (!exists || !stream.HasGroup(group)) {
+			err = ErrNoGroup
+		}
+		if _, ok := storage.ParseStreamID(ids[i], 0); err == nil && ids[i] != ">" && !ok {
+			err = ErrInvalidStreamID
+		}
+		if err != nil {
+			db.unlock()
+			return nil, nil, err
+		}
+		history = history || ids[i] != ">"
+	}
+
+	// Re-reading pending entries never waits
+	if history {
+		block = -1
+	}
+
+	return db.blockingRead(keys, block, func() ([]string, [][]storage.StreamEntry, error) {
+		var names []string
+		var results [][]storage.StreamEntry
+		now := time.Now()
+
+		for i, key := range keys {
+			stream, exists, err := db.getStream(key)
+			if err == nil && (!exists || !stream.HasGroup(group)) {
+				err = ErrNoGroup
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+
+			var entries []storage.StreamEntry
+			if ids[i] == ">" {
+				entries = stream.After(stream.LastDelivered(group), count)
+			} else {
+				after, _ := storage.ParseStreamID(ids[i], 0)
+				entries = pendingEntries(stream, group, consumer, after, count)
+			}
+			if len(entries) == 0 && ids[i] == ">" {
+				continue
+			}
+
+			delivered := make([]storage.StreamID, len(entries))
+			for j, entry := range entries {
+				delivered[j] = entry.ID
+			}
+			if len(delivered) > 0 {
+				patch := &storage.StreamPatch{}
+				patch.Deliver(group, consumer, delivered, now)
+				db.patch(key, patch)
+			}
+
+			names = append(names, key)
+			results = append(results, entries)
+		}
+		return names, results, nil
+	})
+}
+
+// pendingEntries returns up to count of the entries pending for consumer
+// with IDs greater than after
+func pendingEntries(stream *storage.Stream, group, consumer string, after storage.StreamID, count int) []storage.StreamEntry {
+	var entries []storage.StreamEntry
+	for _, pending := range stream.Pending(group) {
+		if pending.Consumer != consumer || !after.Less(pending.ID) {
+			continue
+		}
+		if count >= 0 && len(entries) == count {
+			break
+		}
+
+		entry := storage.StreamEntry{ID: pending.ID}
+		if found := stream.Range(pending.ID, pending.ID, 1); len(found) > 0 {
+			entry = found[0]
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// XAck acknowledges entries delivered to group, removing them from its
+// pending entries, and returns how many were pending
+func (db *Database) XAck(key, group string, ids ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	parsed := make([]storage.StreamID, len(ids))
+	for i, id := range ids {
+		var ok bool
+		if parsed[i], ok = storage.ParseStreamID(id, 0); !ok {
+			return 0, ErrInvalidStreamID
+		}
+	}
+
+	stream, _, err := db.getStream(key)
+	if err != nil || !stream.HasGroup(group) {
+		return 0, err
+	}
+
+	pending := make(map[storage.StreamID]bool)
+	for _, entry := range stream.Pending(group) {
+		pending[entry.ID] = true
+	}
+
+	var acked []storage.StreamID
+	for _, id := range parsed {
+		if pending[id] {
+			acked = append(acked, id)
+			delete(pending, id)
+		}
+	}
+
+	if len(acked) > 0 {
+		patch := &storage.StreamPatch{}
+		patch.Ack(group, acked)
+		db.patch(key, patch)
+	}
+	return len(acked), nil
+}
+
+// XPending returns up to count (a negative count means no limit) of the
+// entries delivered to group and not yet acknowledged, with IDs from start
+// to end (inclusive, "-" and "+" as in XRange), sorted by ID. A non-empty
+// consumer limits them to that consumer.
+func (db *Database) XPending(key, group, start, end string, count int, consumer string) ([]storage.PendingEntry, error) {
+	db.lock()
+	defer db.unlock()
+
+	from, ok := parseRangeID(start, "-", 0)
+	to, ok2 := parseRangeID(end, "+", math.MaxUint64)
+	if !ok || !ok2 {
+		return nil, ErrInvalidStreamID
+	}
+
+	stream, exists, err := db.getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists || !stream.HasGroup(group) {
+		return nil, ErrNoGroup
+	}
+
+	var pending []storage.PendingEntry
+	for _, entry := range stream.Pending(group) {
+		if entry.ID.Less(from) || to.Less(entry.ID) || (consumer != "" && entry.Consumer != consumer) {
+			continue
+		}
+		if count >= 0 && len(pending) == count {
+			break
+		}
+		pending = append(pending, entry)
+	}
+	return pending, nil
+}
+
+// getStream returns the visible stream at key and whether it exists. A
+// missing key yields an empty stream. The result must not be modified.
+func (db *Database) getStream(key string) (*storage.Stream, bool, error) {
+	value, err := db.getValue(key, storage.TypeStream)
+	if value == nil {
+		return storage.NewStream(), false, err
+	}
+	return value.(*storage.Stream), true, nil
+}
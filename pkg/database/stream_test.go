@@ -0,0 +1,267 @@
+package database
+
+import (
+	"simple-database/pkg/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+// entryIDs joins the IDs of stream entries with commas
+func entryIDs(entries []storage.StreamEntry) string {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID.String()
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestXAddIDs(t *testing.T) {
+	db := New()
+
+	tests := []struct {
+		id       string
+		expected string
+		err      error
+	}{
+		{"5-1", "5-1", nil},
+		{"5-*", "5-2", nil},
+		{"5", "", ErrStreamIDTooSmall},
+		{"7-*", "7-0", nil},
+		{"7-0", "", ErrStreamIDTooSmall},
+		{"8", "8-0", nil},
+		{"x-1", "", ErrInvalidStreamID},
+	}
+	for _, test := range tests {
+		id, err := db.XAdd("events", test.id, "k", "v")
+		if id != test.expected || err != test.err {
+			t.Errorf("XAdd %s: expected (%s, %v), got (%s, %v)", test.id, test.expected, test.err, id, err)
+		}
+	}
+
+	// Automatic IDs use the clock but never go backwards
+	db.XAdd("future", "99999999999999-5", "k", "v")
+	if id, _ := db.XAdd("future", "*", "k", "v"); id != "99999999999999-6" {
+		t.Errorf("Expected '99999999999999-6', got '%s'", id)
+	}
+
+	id, _ := db.XAdd("now", "*", "k", "v")
+	if parsed, ok := storage.ParseStreamID(id, 0); !ok || parsed.Ms == 0 {
+		t.Errorf("Expected a time-based ID, got '%s'", id)
+	}
+
+	if got := db.Type("events"); got != "stream" {
+		t.Errorf("Expected 'stream', got '%s'", got)
+	}
+}
+
+func TestXRangeAndTrim(t *testing.T) {
+	db := New()
+	for _, id := range []string{"1-0", "1-1", "2-0", "3-0", "4-0"} {
+		db.XAdd("s", id, "n", id)
+	}
+
+	tests := []struct {
+		start, end string
+		count      int
+		expected   string
+	}{
+		{"-", "+", -1, "1-0,1-1,2-0,3-0,4-0"},
+		{"1", "1", -1, "1-0,1-1"},
+		{"1-1", "3", -1, "1-1,2-0,3-0"},
+		{"-", "+", 2, "1-0,1-1"},
+		{"5", "+", -1, ""},
+	}
+	for _, test := range tests {
+		entries, _ := db.XRange("s", test.start, test.end, test.count)
+		if got := entryIDs(entries); got != test.expected {
+			t.Errorf("XRange %s %s: expected '%s', got '%s'", test.start, test.end, test.expected, got)
+		}
+	}
+
+	entries, _ := db.XRange("s", "2", "2", -1)
+	if len(entries) != 1 || strings.Join(entries[0].Fields, " ") != "n 2-0" {
+		t.Errorf("Expected fields 'n 2-0', got %v", entries)
+	}
+
+	if removed, _ := db.XTrim("s", "MINID", "2-0"); removed != 2 {
+		t.Errorf("Expected 2, got %d", removed)
+	}
+	if removed, _ := db.XTrim("s", "MAXLEN", "1"); removed != 2 {
+		t.Errorf("Expected 2, got %d", removed)
+	}
+	if got, _ := db.XLen("s"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	// Trimming never lowers the last ID
+	if _, err := db.XAdd("s", "3-0", "n", "again"); err != ErrStreamIDTooSmall {
+		t.Errorf("Expected ErrStreamIDTooSmall, got %v", err)
+	}
+}
+
+func TestXRead(t *testing.T) {
+	db := New()
+	db.XAdd("a", "1-0", "k", "1")
+	db.XAdd("a", "2-0", "k", "2")
+	db.XAdd("b", "1-0", "k", "3")
+
+	keys, results, _ := db.XRead([]string{"a", "b"}, []string{"1-0", "1-0"}, -1, -1)
+	if strings.Join(keys, ",") != "a" || entryIDs(results[0]) != "2-0" {
+		t.Errorf("Expected only a with 2-0, got %v %v", keys, results)
+	}
+
+	keys, _, _ = db.XRead([]string{"a"}, []string{"$"}, -1, -1)
+	if keys != nil {
+		t.Errorf("Expected nothing after $, got %v", keys)
+	}
+
+	db.HSet("hash", "f", "v")
+	if _, _, err := db.XRead([]string{"hash"}, []string{"0"}, -1, -1); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+}
+
+func TestXReadBlocksUntilCommit(t *testing.T) {
+	db := New()
+	writer := db.NewSession()
+	db.XAdd("events", "1-0", "k", "old")
+
+	done := make(chan string)
+	go func() {
+		keys, results, _ := db.XRead([]string{"events"}, []string{"$"}, -1, 0)
+		if len(keys) != 1 {
+			done <- ""
+			return
+		}
+		done <- keys[0] + " " + entryIDs(results[0])
+	}()
+
+	waitForWaiters(t, db, "events", 1)
+	writer.Begin()
+	writer.XAdd("events", "2-0", "k", "new")
+
+	select {
+	case got := <-done:
+		t.Fatalf("Expected XRead to wait for the commit, got '%s'", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	writer.Commit()
+	select {
+	case got := <-done:
+		if got != "events 2-0" {
+			t.Errorf("Expected 'events 2-0', got '%s'", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("XRead was not woken by the commit")
+	}
+
+	keys, _, _ := db.XRead([]string{"events"}, []string{"$"}, -1, 10*time.Millisecond)
+	if keys != nil {
+		t.Errorf("Expected a timeout, got %v", keys)
+	}
+}
+
+func TestConsumerGroups(t *testing.T) {
+	db := New()
+	if err := db.XGroupCreate("jobs", "workers", "$", false); err != ErrNoSuchKey {
+		t.Errorf("Expected ErrNoSuchKey, got %v", err)
+	}
+	db.XGroupCreate("jobs", "workers", "$", true)
+	if err := db.XGroupCreate("jobs", "workers", "0", false); err != ErrGroupExists {
+		t.Errorf("Expected ErrGroupExists, got %v", err)
+	}
+
+	for _, id := range []string{"1-0", "2-0", "3-0"} {
+		db.XAdd("jobs", id, "task", id)
+	}
+
+	// Each new entry goes to exactly one consumer
+	_, first, _ := db.XReadGroup("workers", "alice", []string{"jobs"}, []string{">"}, 2, -1)
+	_, second, _ := db.XReadGroup("workers", "bob", []string{"jobs"}, []string{">"}, -1, -1)
+	if entryIDs(first[0]) != "1-0,2-0" || entryIDs(second[0]) != "3-0" {
+		t.Errorf("Expected alice 1-0,2-0 and bob 3-0, got %v and %v", first, second)
+	}
+	if keys, _, _ := db.XReadGroup("workers", "bob", []string{"jobs"}, []string{">"}, -1, -1); keys != nil {
+		t.Errorf("Expected nothing new, got %v", keys)
+	}
+
+	if acked, _ := db.XAck("jobs", "workers", "1-0", "9-0"); acked != 1 {
+		t.Errorf("Expected 1, got %d", acked)
+	}
+
+	pending, _ := db.XPending("jobs", "workers", "-", "+", -1, "")
+	if len(pending) != 2 || pending[0].ID.String() != "2-0" || pending[0].Consumer != "alice" || pending[1].Consumer != "bob" {
+		t.Errorf("Expected 2-0 for alice and 3-0 for bob, got %v", pending)
+	}
+
+	// A restarted consumer re-reads what it was given but never acknowledged
+	_, history, _ := db.XReadGroup("workers", "alice", []string{"jobs"}, []string{"0"}, -1, -1)
+	if entryIDs(history[0]) != "2-0" || strings.Join(history[0][0].Fields, " ") != "task 2-0" {
+		t.Errorf("Expected 2-0 with its fields, got %v", history)
+	}
+
+	pending, _ = db.XPending("jobs", "workers", "-", "+", -1, "alice")
+	if len(pending) != 1 || pending[0].DeliveryCount != 2 {
+		t.Errorf("Expected 2-0 delivered twice, got %v", pending)
+	}
+
+	if _, _, err := db.XReadGroup("nobody", "alice", []string{"jobs"}, []string{">"}, -1, -1); err != ErrNoGroup {
+		t.Errorf("Expected ErrNoGroup, got %v", err)
+	}
+}
+
+func TestConsumerGroupRollback(t *testing.T) {
+	db := New()
+	db.XGroupCreate("jobs", "workers", "0", true)
+	db.XAdd("jobs", "1-0", "task", "a")
+
+	db.Begin()
+	db.XReadGroup("workers", "alice", []string{"jobs"}, []string{">"}, -1, -1)
+	db.XAdd("jobs", "2-0", "task", "b")
+	db.Rollback()
+
+	// The delivery was undone, so the entry is handed out again
+	_, results, _ := db.XReadGroup("workers", "bob", []string{"jobs"}, []string{">"}, -1, -1)
+	if len(results) != 1 || entryIDs(results[0]) != "1-0" {
+		t.Errorf("Expected 1-0, got %v", results)
+	}
+	if got, _ := db.XLen("jobs"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestCommitFailsWhenStagedEntriesFallBehind(t *testing.T) {
+	db := New()
+	other := db.NewSession()
+
+	db.Begin()
+	if id, _ := db.XAdd("log", "7-0", "from", "db"); id != "7-0" {
+		t.Errorf("Expected '7-0', got '%s'", id)
+	}
+
+	other.XAdd("log", "9-0", "from", "other")
+	if err := db.Commit(); err != ErrStreamIDTooSmall {
+		t.Errorf("Expected ErrStreamIDTooSmall, got %v", err)
+	}
+
+	// Nothing was applied and the transaction is still open
+	if entries, _ := other.XRange("log", "-", "+", -1); entryIDs(entries) != "9-0" {
+		t.Errorf("Expected '9-0', got '%s'", entryIDs(entries))
+	}
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Expected the transaction to stay open, got %v", err)
+	}
+
+	// Entries staged after the other session's still commit
+	db.Begin()
+	db.XAdd("log", "*", "from", "db")
+	other.XAdd("log", "9-1", "from", "other")
+	if err := db.Commit(); err != nil {
+		t.Errorf("Expected the commit to succeed, got %v", err)
+	}
+	if got, _ := db.XLen("log"); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+}
@@ -1,9 +1,14 @@
 package database
 
-import "errors"
+import (
+	"errors"
+	"simple-database/pkg/storage"
+	"sort"
+)
 
 var (
 	ErrNoTransaction = errors.New("NO TRANSACTION")
+	ErrConflict      = errors.New("CONFLICT WITH A CHANGE COMMITTED BY ANOTHER CLIENT")
 )
 
 // Operation represents the type of transaction operation
@@ -12,20 +17,43 @@ type Operation int
 const (
 	OpSet Operation = iota
 	OpUnset
+	OpSetObject
+	OpPatch
+	OpFlush
+	OpFlushAll
 )
 
-// TransactionChange represents a change made within a transaction
+// TransactionChange represents a change made within a transaction.
+// OpSet and OpUnset work on string values. OpSetObject replaces the key
+// with Object, while OpPatch applies Patch on top of whatever the key held
+// before, so only the parts of a structured value that were touched are staged.
+// An OpUnset left by a patch that emptied the value keeps that Patch, so
+// COMMIT replays it on the committed value instead of removing the key
+// outright. OpFlush removes every key and has no Key. OpFlushAll does the same in
+// every database.
 type TransactionChange struct {
 	Key       string
 	OldValue  string
 	NewValue  string
+	Object    storage.Value
+	Patch     storage.Patch
 	Operation Operation
+	seq       int
 }
 
-// TransactionLayer represents a single transaction layer
+// TransactionLayer represents a single transaction layer. Besides the
+// changes themselves it tracks, per value, the net change in count and
+// which keys gained (+1) or lost (-1) that value within the layer.
+// A cleared layer starts from an empty database: everything committed or
+// staged in earlier layers is hidden, and its changes and counts are
+// relative to nothing. If it cleared every database, the others are
+// emptied on commit too.
 type TransactionLayer struct {
 	changes     map[string]TransactionChange
 	valueCounts map[string]int
+	valueKeys   map[string]map[string]int
+	cleared     bool
+	clearedAll  bool
 }
 
 // newTransactionLayer creates a new transaction layer
@@ -33,12 +61,41 @@ func newTransactionLayer() *TransactionLayer {
 	return &TransactionLayer{
 		changes:     make(map[string]TransactionChange),
 		valueCounts: make(map[string]int),
+		valueKeys:   make(map[string]map[string]int),
+	}
+}
+
+// adjustValue records that key gained (delta 1) or lost (delta -1) value,
+// in both the value counts and the value keys. Values left out of the
+// value index are skipped.
+func (layer *TransactionLayer) adjustValue(key, value string, delta int) {
+	if !storage.Indexed(value) {
+		return
+	}
+	layer.valueCounts[value] += delta
+	layer.adjustValueKey(key, value, delta)
+}
+
+// adjustValueKey records that key gained (delta 1) or lost (delta -1) value
+func (layer *TransactionLayer) adjustValueKey(key, value string, delta int) {
+	keys, exists := layer.valueKeys[value]
+	if !exists {
+		keys = make(map[string]int)
+		layer.valueKeys[value] = keys
+	}
+	keys[key] += delta
+	if keys[key] == 0 {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(layer.valueKeys, value)
+		}
 	}
 }
 
 // TransactionManager manages nested transactions
 type TransactionManager struct {
 	layers []*TransactionLayer
+	seq    int
 }
 
 // NewTransactionManager creates a new transaction manager
@@ -67,6 +124,63 @@ func (tm *TransactionManager) Rollback() error {
 	return nil
 }
 
+// Flush records the removal of every key in the current transaction.
+// Whatever the layer staged before is dropped, since it is cleared too.
+func (tm *TransactionManager) Flush() {
+	if !tm.InTransaction() {
+		return
+	}
+
+	layer := newTransactionLayer()
+	layer.cleared = true
+	layer.clearedAll = tm.getCurrentLayer().clearedAll
+	tm.layers[len(tm.layers)-1] = layer
+}
+
+// FlushAll records the removal of every key in every database in the
+// current transaction. The selected database is cleared like with Flush;
+// the others are only emptied on commit, since a transaction never sees them.
+func (tm *TransactionManager) FlushAll() {
+	if !tm.InTransaction() {
+		return
+	}
+
+	tm.Flush()
+	tm.getCurrentLayer().clearedAll = true
+}
+
+// Cleared reports whether a transaction layer cleared the database, so the
+// committed data is hidden
+func (tm *TransactionManager) Cleared() bool {
+	for _, layer := range tm.layers {
+		if layer.cleared {
+			return true
+		}
+	}
+	return false
+}
+
+// ClearedAll reports whether a transaction layer cleared every database
+func (tm *TransactionManager) ClearedAll() bool {
+	for _, layer := range tm.layers {
+		if layer.clearedAll {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleLayers returns the layers from the most recent cleared one on,
+// since earlier layers are hidden by it
+func (tm *TransactionManager) visibleLayers() []*TransactionLayer {
+	for i := len(tm.layers) - 1; i >= 0; i-- {
+		if tm.layers[i].cleared {
+			return tm.layers[i:]
+		}
+	}
+	return tm.layers
+}
+
 // Set records a SET operation in the current transaction
 func (tm *TransactionManager) Set(key, value, oldValue string) {
 	if !tm.InTransaction() {
@@ -76,15 +190,16 @@ func (tm *TransactionManager) Set(key, value, oldValue string) {
 	layer := tm.getCurrentLayer()
 
 	if oldValue != "NULL" {
-		layer.valueCounts[oldValue]--
+		layer.adjustValue(key, oldValue, -1)
 	}
 
-	layer.valueCounts[value]++
+	layer.adjustValue(key, value, 1)
 	layer.changes[key] = TransactionChange{
 		Key:       key,
 		OldValue:  oldValue,
 		NewValue:  value,
 		Operation: OpSet,
+		seq:       tm.nextSeq(),
 	}
 }
 
@@ -95,56 +210,245 @@ func (tm *TransactionManager) Unset(key, currentValue string) {
 	}
 
 	layer := tm.getCurrentLayer()
-	layer.valueCounts[currentValue]--
+	if currentValue != "NULL" {
+		layer.adjustValue(key, currentValue, -1)
+	}
 	layer.changes[key] = TransactionChange{
 		Key:       key,
 		OldValue:  currentValue,
 		NewValue:  "NULL",
 		Operation: OpUnset,
+		seq:       tm.nextSeq(),
+	}
+}
+
+// SetObject records the replacement of a key with a structured value in the
+// current transaction. oldValue is the string the key held, if any. The
+// layer takes ownership of object.
+func (tm *TransactionManager) SetObject(key string, object storage.Value, oldValue string) {
+	if !tm.InTransaction() {
+		return
+	}
+
+	layer := tm.getCurrentLayer()
+	if oldValue != "NULL" {
+		layer.adjustValue(key, oldValue, -1)
+	}
+	layer.changes[key] = TransactionChange{
+		Key:       key,
+		OldValue:  oldValue,
+		NewValue:  "NULL",
+		Object:    object,
+		Operation: OpSetObject,
+		seq:       tm.nextSeq(),
 	}
 }
 
-// Get retrieves a value from the transaction layers
+// Patch records a partial change to the structured value at key in the
+// current transaction, combining it with any change already staged for the
+// key in the same layer. empty reports whether the change leaves the key
+// without a value, in which case it is staged as an OpUnset that keeps the
+// patch. The layer takes ownership of patch.
+func (tm *TransactionManager) Patch(key string, patch storage.Patch, empty bool) {
+	if !tm.InTransaction() {
+		return
+	}
+
+	layer := tm.getCurrentLayer()
+	change, exists := layer.changes[key]
+	switch {
+	case !exists:
+		change = TransactionChange{
+			Key:       key,
+			OldValue:  "NULL",
+			NewValue:  "NULL",
+			Patch:     patch,
+			Operation: OpPatch,
+		}
+	case change.Patch != nil:
+		change.Patch.Merge(patch)
+		change.Operation = OpPatch
+	case change.Operation == OpSetObject:
+		change.Object = patch.Apply(change.Object)
+	default:
+		change.Object = patch.Apply(nil)
+		change.Operation = OpSetObject
+	}
+	if (change.Operation == OpPatch && empty) || (change.Operation == OpSetObject && change.Object == nil) {
+		change.Operation = OpUnset
+	}
+	change.seq = tm.nextSeq()
+	layer.changes[key] = change
+}
+
+// nextSeq returns the sequence number of a newly recorded change, which
+// orders the changes returned by GetAllChanges
+func (tm *TransactionManager) nextSeq() int {
+	tm.seq++
+	return tm.seq
+}
+
+// Get retrieves a value from the transaction layers. A key untouched since
+// the database was cleared is found as "NULL".
 func (tm *TransactionManager) Get(key string) (string, bool) {
 	// Search from most recent transaction to oldest
-	for i := len(tm.layers) - 1; i >= 0; i-- {
-		if change, exists := tm.layers[i].changes[key]; exists {
-			if change.Operation == OpUnset {
+	layers := tm.visibleLayers()
+	for i := len(layers) - 1; i >= 0; i-- {
+		if change, exists := layers[i].changes[key]; exists {
+			if change.Operation != OpSet {
 				return "NULL", true
 			}
 			return change.NewValue, true
 		}
 	}
+	if tm.Cleared() {
+		return "NULL", true
+	}
 	return "", false
 }
 
+// GetObject resolves the structured value of key through the transaction
+// layers, starting from base (the committed value) unless a layer replaced
+// the key outright or cleared the database. The result must not be modified.
+func (tm *TransactionManager) GetObject(key string, base storage.Value) storage.Value {
+	var patches []storage.Patch
+
+	layers := tm.visibleLayers()
+	if tm.Cleared() {
+		base = nil
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		change, exists := layers[i].changes[key]
+		if !exists {
+			continue
+		}
+		if change.Operation == OpPatch {
+			patches = append(patches, change.Patch)
+			continue
+		}
+
+		base = change.Object
+		break
+	}
+
+	if len(patches) == 0 {
+		return base
+	}
+
+	object := base
+	if object != nil {
+		object = object.Clone()
+	}
+	for i := len(patches) - 1; i >= 0; i-- {
+		object = patches[i].Apply(object)
+	}
+	return object
+}
+
+// StagedKeys returns every key touched by the transaction layers, mapped to
+// whether the key exists once the staged changes are applied
+func (tm *TransactionManager) StagedKeys() map[string]bool {
+	staged := make(map[string]bool)
+	for _, layer := range tm.visibleLayers() {
+		for key, change := range layer.changes {
+			staged[key] = change.Operation != OpUnset
+		}
+	}
+	return staged
+}
+
 // GetValueCount returns the net change in value count across all transaction layers
 func (tm *TransactionManager) GetValueCount(value string) int {
 	total := 0
-	for _, layer := range tm.layers {
+	for _, layer := range tm.visibleLayers() {
 		total += layer.valueCounts[value]
 	}
 	return total
 }
 
-// GetAllChanges returns all changes from all transaction layers
+// GetValueKeyChanges returns the keys whose association with value is changed
+// by the transaction layers, mapped to whether they hold the value afterwards
+func (tm *TransactionManager) GetValueKeyChanges(value string) map[string]bool {
+	changes := make(map[string]bool)
+	for _, layer := range tm.visibleLayers() {
+		for key, delta := range layer.valueKeys[value] {
+			changes[key] = delta > 0
+		}
+	}
+	return changes
+}
+
+// GetValueCountChanges returns the net change in count of every value
+// touched by the transaction layers
+func (tm *TransactionManager) GetValueCountChanges() map[string]int {
+	changes := make(map[string]int)
+	for _, layer := range tm.visibleLayers() {
+		for value, delta := range layer.valueCounts {
+			changes[value] += delta
+		}
+	}
+	return changes
+}
+
+// GetAllChanges returns all changes from all transaction layers, one per
+// key, ordered by when each key was last changed. If a layer cleared the
+// database, the changes start with an OpFlush, or an OpFlushAll if one
+// cleared every database, and leave out whatever the flush hid.
 func (tm *TransactionManager) GetAllChanges() []TransactionChange {
 	var allChanges []TransactionChange
+	switch {
+	case tm.ClearedAll():
+		allChanges = append(allChanges, TransactionChange{NewValue: "NULL", OldValue: "NULL", Operation: OpFlushAll})
+	case tm.Cleared():
+		allChanges = append(allChanges, TransactionChange{NewValue: "NULL", OldValue: "NULL", Operation: OpFlush})
+	}
 
 	// Collect changes from all layers, with later layers overriding earlier ones
 	changeMap := make(map[string]TransactionChange)
 
-	for _, layer := range tm.layers {
+	for _, layer := range tm.visibleLayers() {
 		for key, change := range layer.changes {
+			if previous, exists := changeMap[key]; exists && change.Patch != nil {
+				change = combineChanges(previous, change)
+			}
 			changeMap[key] = change
 		}
 	}
 
+	staged := make([]TransactionChange, 0, len(changeMap))
 	for _, change := range changeMap {
-		allChanges = append(allChanges, change)
+		staged = append(staged, change)
 	}
+	sort.Slice(staged, func(i, j int) bool {
+		return staged[i].seq < staged[j].seq
+	})
 
-	return allChanges
+	return append(allChanges, staged...)
+}
+
+// combineChanges folds a patch staged in a later layer into the change
+// staged for the same key in an earlier one, without modifying either
+func combineChanges(earlier, later TransactionChange) TransactionChange {
+	combined := later
+	combined.OldValue = earlier.OldValue
+
+	switch {
+	case earlier.Patch != nil:
+		combined.Patch = earlier.Patch.Clone()
+		combined.Patch.Merge(later.Patch)
+		return combined
+	case earlier.Operation == OpSetObject:
+		combined.Object = later.Patch.Apply(earlier.Object.Clone())
+	default:
+		combined.Object = later.Patch.Apply(nil)
+	}
+
+	combined.Patch = nil
+	combined.Operation = OpSetObject
+	if combined.Object == nil {
+		combined.Operation = OpUnset
+	}
+	return combined
 }
 
 // Clear removes all transaction layers
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"simple-database/pkg/storage"
+	"sort"
+)
+
+// CountRange returns how many keys hold a numeric value between min and max
+// (both inclusive). Values that are not numbers are ignored.
+func (db *Database) CountRange(min, max float64) int {
+	db.lock()
+	defer db.unlock()
+
+	inRange := func(value string) bool {
+		number, ok := storage.ParseNumber(value)
+		return ok && number >= min && number <= max
+	}
+
+	count := 0
+	db.baseStorage().EachValueInNumericRange(min, max, func(value string) {
+		count += db.baseStorage().GetValueCount(value)
+	})
+	return count + db.countChangesInRange(inRange)
+}
+
+// CountLexRange returns how many keys hold a value between min and max
+// (both inclusive) in lexicographic order
+func (db *Database) CountLexRange(min, max string) int {
+	db.lock()
+	defer db.unlock()
+
+	inRange := func(value string) bool {
+		return value >= min && value <= max
+	}
+
+	count := 0
+	db.baseStorage().EachValueInLexRange(min, max, func(value string) {
+		count += db.baseStorage().GetValueCount(value)
+	})
+	return count + db.countChangesInRange(inRange)
+}
+
+// KeysInRange returns the keys holding a numeric value between min and max
+// (both inclusive) in sorted order
+func (db *Database) KeysInRange(min, max float64) []string {
+	db.lock()
+	defer db.unlock()
+
+	var values []string
+	db.baseStorage().EachValueInNumericRange(min, max, func(value string) {
+		values = append(values, value)
+	})
+	return db.keysHoldingValues(values, func(value string) bool {
+		number, ok := storage.ParseNumber(value)
+		return ok && number >= min && number <= max
+	})
+}
+
+// KeysInLexRange returns the keys holding a value between min and max
+// (both inclusive, lexicographic order) in sorted order
+func (db *Database) KeysInLexRange(min, max string) []string {
+	db.lock()
+	defer db.unlock()
+
+	var values []string
+	db.baseStorage().EachValueInLexRange(min, max, func(value string) {
+		values = append(values, value)
+	})
+	return db.keysHoldingValues(values, func(value string) bool {
+		return value >= min && value <= max
+	})
+}
+
+// TopValues returns up to n of the most common values with their counts,
+// most frequent first and ties broken by value. A non-positive n returns
+// every value.
+func (db *Database) TopValues(n int) ([]string, []int) {
+	db.lock()
+	defer db.unlock()
+
+	counts := db.valueCounts()
+
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	if n > 0 && n < len(values) {
+		values = values[:n]
+	}
+
+	frequencies := make([]int, len(values))
+	for i, value := range values {
+		frequencies[i] = counts[value]
+	}
+	return values, frequencies
+}
+
+// ValueHistogram maps each frequency to the number of distinct values that
+// are held by exactly that many keys
+func (db *Database) ValueHistogram() map[int]int {
+	db.lock()
+	defer db.unlock()
+
+	histogram := make(map[int]int)
+	for _, count := range db.valueCounts() {
+		histogram[count]++
+	}
+	return histogram
+}
+
+// DistinctValues returns the number of distinct values currently held
+func (db *Database) DistinctValues() int {
+	db.lock()
+	defer db.unlock()
+
+	return len(db.valueCounts())
+}
+
+// valueCounts combines the committed value counts with the transaction
+// deltas, the same way NumEqualTo does for a single value
+func (db *Database) valueCounts() map[string]int {
+	counts := make(map[string]int)
+	db.baseStorage().EachValueCount(func(value string, count int) {
+		counts[value] = count
+	})
+	for value, delta := range db.transactions.GetValueCountChanges() {
+		counts[value] += delta
+		if counts[value] <= 0 {
+			delete(counts, value)
+		}
+	}
+	return counts
+}
+
+// countChangesInRange sums the transaction count changes of every value
+// accepted by inRange
+func (db *Database) countChangesInRange(inRange func(value string) bool) int {
+	total := 0
+	for value, delta := range db.transactions.GetValueCountChanges() {
+		if inRange(value) {
+			total += delta
+		}
+	}
+	return total
+}
+
+// keysHoldingValues returns the sorted union of the keys holding any of the
+// committed values, plus any value accepted by inRange that the transaction
+// layers touched, as seen through the transaction layers
+func (db *Database) keysHoldingValues(values []string, inRange func(value string) bool) []string {
+	for value := range db.transactions.GetValueCountChanges() {
+		if inRange(value) && db.baseStorage().GetValueCount(value) == 0 {
+			values = append(values, value)
+		}
+	}
+
+	var keys []string
+	for _, value := range values {
+		keys = append(keys, db.keysEqualTo(value)...)
+	}
+	sort.Strings(keys)
+	return keys
+}
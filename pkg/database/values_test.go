@@ -0,0 +1,145 @@
+package database
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCountRange(t *testing.T) {
+	db := New()
+	db.MSet("a", "100", "b", "150", "c", "150", "d", "200.5", "e", "abc", "f", "-3")
+
+	if got := db.CountRange(100, 200); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	if got := db.CountRange(math.Inf(-1), math.Inf(1)); got != 5 {
+		t.Errorf("Expected 5, got %d", got)
+	}
+
+	if got := strings.Join(db.KeysInRange(-10, 100), ","); got != "a,f" {
+		t.Errorf("Expected 'a,f', got '%s'", got)
+	}
+
+	db.Set("b", "500")
+	db.Unset("a")
+
+	if got := db.CountRange(100, 200); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
+
+func TestCountLexRange(t *testing.T) {
+	db := New()
+	db.MSet("a", "apple", "b", "banana", "c", "cherry", "d", "banana")
+
+	if got := db.CountLexRange("b", "c"); got != 2 {
+		t.Errorf("Expected 2, got %d", got)
+	}
+
+	if got := strings.Join(db.KeysInLexRange("apple", "banana"), ","); got != "a,b,d" {
+		t.Errorf("Expected 'a,b,d', got '%s'", got)
+	}
+}
+
+func TestValueRangesInTransaction(t *testing.T) {
+	db := New()
+	db.MSet("a", "10", "b", "20", "c", "30")
+
+	db.Begin()
+	db.Set("a", "25")
+	db.Set("d", "15")
+	db.Unset("c")
+
+	db.Begin()
+	db.Set("e", "12")
+	db.Set("b", "50")
+
+	if got := db.CountRange(10, 30); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	if got := strings.Join(db.KeysInRange(10, 30), ","); got != "a,d,e" {
+		t.Errorf("Expected 'a,d,e', got '%s'", got)
+	}
+
+	if got := strings.Join(db.KeysInLexRange("1", "2"), ","); got != "d,e" {
+		t.Errorf("Expected 'd,e', got '%s'", got)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := strings.Join(db.KeysInRange(10, 30), ","); got != "a,b,d" {
+		t.Errorf("Expected 'a,b,d', got '%s'", got)
+	}
+
+	if err := db.Commit(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := db.CountRange(10, 30); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	if got := db.CountRange(30, 30); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestTopValues(t *testing.T) {
+	db := New()
+	db.MSet("a", "x", "b", "x", "c", "y", "d", "x", "e", "y", "f", "z")
+
+	values, counts := db.TopValues(2)
+	if len(values) != 2 || values[0] != "x" || counts[0] != 3 || values[1] != "y" || counts[1] != 2 {
+		t.Errorf("Expected [x y] [3 2], got %v %v", values, counts)
+	}
+
+	db.Begin()
+	db.Set("a", "z")
+	db.Set("b", "z")
+	db.Set("g", "z")
+
+	values, counts = db.TopValues(0)
+	if len(values) != 3 || values[0] != "z" || counts[0] != 4 || values[2] != "x" || counts[2] != 1 {
+		t.Errorf("Expected [z y x] [4 2 1], got %v %v", values, counts)
+	}
+
+	if err := db.Rollback(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if values, _ := db.TopValues(1); len(values) != 1 || values[0] != "x" {
+		t.Errorf("Expected [x], got %v", values)
+	}
+}
+
+func TestValueHistogram(t *testing.T) {
+	db := New()
+	db.MSet("a", "x", "b", "x", "c", "y", "d", "z")
+
+	histogram := db.ValueHistogram()
+	if histogram[1] != 2 || histogram[2] != 1 || len(histogram) != 2 {
+		t.Errorf("Expected map[1:2 2:1], got %v", histogram)
+	}
+
+	if got := db.DistinctValues(); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	db.Begin()
+	db.Unset("c")
+	db.Set("d", "x")
+
+	histogram = db.ValueHistogram()
+	if histogram[3] != 1 || len(histogram) != 1 {
+		t.Errorf("Expected map[3:1], got %v", histogram)
+	}
+
+	if got := db.DistinctValues(); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+}
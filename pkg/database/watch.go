@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// WatchBufferSize is the number of events a watcher can fall behind by
+// before it is dropped
+const WatchBufferSize = 1024
+
+// EventType identifies the kind of change an Event reports
+type EventType int
+
+const (
+	// EventSet reports that a key was written, whatever its type
+	EventSet EventType = iota
+	// EventDel reports that a key was removed
+	EventDel
+	// EventFlush reports that every key of a database was removed or, for
+	// SWAPDB, replaced. Its Key is empty.
+	EventFlush
+)
+
+// String returns the name used for the event in notifications
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventDel:
+		return "del"
+	case EventFlush:
+		return "flush"
+	}
+	return "unknown"
+}
+
+// Event describes a committed change to a key. Changes staged in a
+// transaction produce events only when the transaction commits.
+type Event struct {
+	DB   int
+	Key  string
+	Type EventType
+}
+
+// watcher is a subscription made with Watch
+type watcher struct {
+	prefix string
+	events chan Event
+}
+
+// watchers is the registry of Watch subscriptions, shared by every session
+// and keyspace. It is guarded by the shared lock.
+type watchers struct {
+	subscribed map[*watcher]struct{}
+}
+
+// newWatchers creates an empty registry
+func newWatchers() *watchers {
+	return &watchers{subscribed: make(map[*watcher]struct{})}
+}
+
+// emit hands event to every watcher whose prefix matches its key, without
+// waiting. A watcher whose buffer is full is dropped and its channel
+// closed, so a stalled reader cannot hold the database lock.
+func (ws *watchers) emit(event Event) {
+	for w := range ws.subscribed {
+		if event.Type != EventFlush && !strings.HasPrefix(event.Key, w.prefix) {
+			continue
+		}
+		select {
+		case w.events <- event:
+		default:
+			ws.remove(w)
+		}
+	}
+}
+
+// remove unregisters w and closes its channel, if it is still registered
+func (ws *watchers) remove(w *watcher) {
+	if _, subscribed := ws.subscribed[w]; subscribed {
+		delete(ws.subscribed, w)
+		close(w.events)
+	}
+}
+
+// Watch returns a channel of the events for committed changes to keys
+// starting with prefix, in every database. Flush events are sent whatever
+// the prefix. The channel is closed when ctx is done, or if the caller
+// falls more than WatchBufferSize events behind.
+func (db *Database) Watch(ctx context.Context, prefix string) <-chan Event {
+	db.lock()
+	defer db.unlock()
+
+	w := &watcher{prefix: prefix, events: make(chan Event, WatchBufferSize)}
+	db.shared.watchers.subscribed[w] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		db.lock()
+		defer db.unlock()
+		db.shared.watchers.remove(w)
+	}()
+	return w.events
+}
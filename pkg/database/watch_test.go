@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// received returns the events waiting on events, formatted as
+// "db:type:key"
+func received(events <-chan Event) string {
+	var lines []string
+	for {
+		select {
+		case event := <-events:
+			lines = append(lines, fmt.Sprintf("%d:%s:%s", event.DB, event.Type, event.Key))
+		default:
+			return strings.Join(lines, ",")
+		}
+	}
+}
+
+func TestWatchReportsWrites(t *testing.T) {
+	db := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := db.Watch(ctx, "user:")
+
+	db.Set("user:1", "a")
+	db.Set("order:1", "b")
+	db.HSet("user:2", "f", "v")
+	db.Unset("user:1")
+	db.Unset("user:missing")
+	db.RPush("user:list", "x")
+	db.LPop("user:list", 1)
+
+	expected := "0:set:user:1,0:set:user:2,0:del:user:1,0:set:user:list,0:del:user:list"
+	if got := received(events); got != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, got)
+	}
+
+	db.Select(3)
+	db.Move("user:2", 0)
+	db.Select(0)
+	db.Move("user:2", 3)
+	if got := received(events); got != "3:set:user:2,0:del:user:2" {
+		t.Errorf("Expected '3:set:user:2,0:del:user:2', got '%s'", got)
+	}
+}
+
+func TestWatchOnlyReportsCommittedChanges(t *testing.T) {
+	db := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := db.Watch(ctx, "")
+
+	db.Begin()
+	db.Set("a", "1")
+	db.Begin()
+	db.Set("b", "2")
+	db.Rollback()
+	if got := received(events); got != "" {
+		t.Errorf("Expected no events before COMMIT, got '%s'", got)
+	}
+
+	db.Commit()
+	if got := received(events); got != "0:set:a" {
+		t.Errorf("Expected '0:set:a', got '%s'", got)
+	}
+
+	db.Begin()
+	db.FlushDB()
+	db.Set("c", "3")
+	db.Commit()
+	if got := received(events); got != "0:flush:,0:set:c" {
+		t.Errorf("Expected '0:flush:,0:set:c', got '%s'", got)
+	}
+
+	db.SwapDB(0, 1)
+	if got := received(events); got != "0:flush:,1:flush:" {
+		t.Errorf("Expected '0:flush:,1:flush:', got '%s'", got)
+	}
+}
+
+func TestWatchEndsWithContext(t *testing.T) {
+	db := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := db.Watch(ctx, "")
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Expected no events")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the channel to be closed")
+	}
+
+	db.lock()
+	defer db.unlock()
+	if got := len(db.shared.watchers.subscribed); got != 0 {
+		t.Errorf("Expected no watchers left, got %d", got)
+	}
+}
+
+func TestSlowWatcherIsDropped(t *testing.T) {
+	db := New()
+	events := db.Watch(context.Background(), "")
+
+	for i := 0; i <= WatchBufferSize; i++ {
+		db.Set("k", "v")
+	}
+
+	count := 0
+	for range events {
+		count++
+	}
+	if count != WatchBufferSize {
+		t.Errorf("Expected %d events before the channel closed, got %d", WatchBufferSize, count)
+	}
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"errors"
+	"math"
+	"simple-database/pkg/storage"
+)
+
+var (
+	ErrNotFloat = errors.New("NOT A VALID FLOAT")
+	ErrScoreNaN = errors.New("RESULTING SCORE IS NOT A NUMBER")
+)
+
+// ZAdd writes score-member pairs, given as alternating scores and members,
+// to the sorted set at key. It returns how many members were newly added.
+func (db *Database) ZAdd(key string, pairs ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	patch := make(storage.SortedSetPatch)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		score, ok := storage.ParseNumber(pairs[i])
+		if !ok {
+			return 0, ErrNotFloat
+		}
+
+		member := pairs[i+1]
+		if _, exists := zset.Score(member); !exists {
+			if _, staged := patch[member]; !staged {
+				added++
+			}
+		}
+		patch.SetScore(member, score)
+	}
+
+	db.patch(key, patch)
+	return added, nil
+}
+
+// ZRem removes members from the sorted set at key and returns how many
+// existed. Removing the last member removes the key.
+func (db *Database) ZRem(key string, members ...string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	patch := make(storage.SortedSetPatch)
+	for _, member := range members {
+		if _, exists := zset.Score(member); exists {
+			patch.RemoveMember(member)
+		}
+	}
+
+	if len(patch) > 0 {
+		db.patch(key, patch)
+	}
+	return len(patch), nil
+}
+
+// ZScore returns the score of member. The boolean is false if the key or
+// the member does not exist.
+func (db *Database) ZScore(key, member string) (float64, bool, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return 0, false, err
+	}
+
+	score, exists := zset.Score(member)
+	return score, exists, nil
+}
+
+// ZIncrBy adds increment to the score of member (missing members count as
+// 0) and returns the new score
+func (db *Database) ZIncrBy(key, member string, increment float64) (float64, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, _ := zset.Score(member)
+	score += increment
+	if math.IsNaN(score) {
+		return 0, ErrScoreNaN
+	}
+
+	patch := make(storage.SortedSetPatch)
+	patch.SetScore(member, score)
+	db.patch(key, patch)
+	return score, nil
+}
+
+// ZRank returns the zero-based position of member in ascending score
+// order. The boolean is false if the key or the member does not exist.
+func (db *Database) ZRank(key, member string) (int, bool, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return 0, false, err
+	}
+
+	rank, exists := zset.Rank(member)
+	return rank, exists, nil
+}
+
+// ZCard returns the number of members of the sorted set at key
+func (db *Database) ZCard(key string) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	return zset.Len(), err
+}
+
+// ZRange returns the members between two positions (inclusive) in
+// ascending score order, along with their scores. Negative positions count
+// from the end.
+func (db *Database) ZRange(key string, start, stop int) ([]string, []float64, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return splitScoredMembers(zset.Range(start, stop, false))
+}
+
+// ZRevRange is ZRange with positions counted from the highest score down
+func (db *Database) ZRevRange(key string, start, stop int) ([]string, []float64, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return splitScoredMembers(zset.Range(start, stop, true))
+}
+
+// ZRangeByScore returns the members scored between min and max (inclusive)
+// in ascending order, along with their scores. The first offset matches
+// are skipped and at most count are returned; a negative count means no
+// limit.
+func (db *Database) ZRangeByScore(key string, min, max float64, offset, count int) ([]string, []float64, error) {
+	db.lock()
+	defer db.unlock()
+
+	zset, err := db.getSortedSet(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return splitScoredMembers(zset.RangeByScore(min, max, offset, count))
+}
+
+// splitScoredMembers separates members and scores into parallel slices
+func splitScoredMembers(scored []storage.ScoredMember) ([]string, []float64, error) {
+	members := make([]string, len(scored))
+	scores := make([]float64, len(scored))
+	for i, item := range scored {
+		members[i] = item.Member
+		scores[i] = item.Score
+	}
+	return members, scores, nil
+}
+
+// getSortedSet returns the visible sorted set at key, or an empty one if
+// the key is missing. The result must not be modified.
+func (db *Database) getSortedSet(key string) (*storage.SortedSet, error) {
+	value, err := db.getValue(key, storage.TypeSortedSet)
+	if value == nil {
+		return storage.NewSortedSet(), err
+	}
+	return value.(*storage.SortedSet), nil
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSortedSetOperations(t *testing.T) {
+	db := New()
+
+	if got, err := db.ZAdd("board", "30", "carol", "10", "alice", "20", "bob"); got != 3 || err != nil {
+		t.Errorf("Expected (3, nil), got (%d, %v)", got, err)
+	}
+
+	// Updating a score does not count as an addition
+	if got, _ := db.ZAdd("board", "5", "carol", "40", "dave"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	members, _, _ := db.ZRange("board", 0, -1)
+	if got := strings.Join(members, ","); got != "carol,alice,bob,dave" {
+		t.Errorf("Expected 'carol,alice,bob,dave', got '%s'", got)
+	}
+
+	if score, ok, _ := db.ZScore("board", "bob"); score != 20 || !ok {
+		t.Errorf("Expected (20, true), got (%v, %v)", score, ok)
+	}
+
+	if score, _ := db.ZIncrBy("board", "alice", 25); score != 35 {
+		t.Errorf("Expected 35, got %v", score)
+	}
+
+	if rank, ok, _ := db.ZRank("board", "alice"); rank != 2 || !ok {
+		t.Errorf("Expected (2, true), got (%d, %v)", rank, ok)
+	}
+
+	if _, ok, _ := db.ZRank("board", "nobody"); ok {
+		t.Error("Expected missing member to have no rank")
+	}
+
+	if got, _ := db.ZRem("board", "carol", "nobody"); got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	// Removing the last member removes the key
+	db.ZRem("board", "alice", "bob", "dave")
+	if got := db.Type("board"); got != "none" {
+		t.Errorf("Expected 'none', got '%s'", got)
+	}
+}
+
+func TestSortedSetRanges(t *testing.T) {
+	db := New()
+	db.ZAdd("z", "1", "a", "2", "b", "2", "c", "3", "d", "5", "e")
+
+	tests := []struct {
+		name     string
+		members  []string
+		expected string
+	}{
+		{"ZRange tail", rangeMembers(db.ZRange("z", -2, -1)), "d,e"},
+		{"ZRange out of range", rangeMembers(db.ZRange("z", 10, 20)), ""},
+		{"ZRevRange", rangeMembers(db.ZRevRange("z", 0, 1)), "e,d"},
+		{"ZRevRange middle", rangeMembers(db.ZRevRange("z", 1, 3)), "d,c,b"},
+		{"ZRangeByScore", rangeMembers(db.ZRangeByScore("z", 2, 3, 0, -1)), "b,c,d"},
+		{"ZRangeByScore limit", rangeMembers(db.ZRangeByScore("z", 2, math.Inf(1), 1, 2)), "c,d"},
+		{"ZRangeByScore zero count", rangeMembers(db.ZRangeByScore("z", 0, 10, 0, 0)), ""},
+	}
+
+	for _, test := range tests {
+		if got := strings.Join(test.members, ","); got != test.expected {
+			t.Errorf("%s: expected '%s', got '%s'", test.name, test.expected, got)
+		}
+	}
+}
+
+// rangeMembers returns the members of a sorted set range result
+func rangeMembers(members []string, _ []float64, _ error) []string {
+	return members
+}
+
+func TestSortedSetWrongType(t *testing.T) {
+	db := New()
+	db.Set("name", "alice")
+
+	if _, err := db.ZAdd("name", "1", "a"); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+	if _, _, err := db.ZRange("name", 0, -1); err != ErrWrongType {
+		t.Errorf("Expected ErrWrongType, got %v", err)
+	}
+	if _, err := db.ZAdd("z", "x", "a"); err != ErrNotFloat {
+		t.Errorf("Expected ErrNotFloat, got %v", err)
+	}
+	if got := db.Exists("z"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+
+	db.ZAdd("z", "inf", "a")
+	if _, err := db.ZIncrBy("z", "a", math.Inf(-1)); err != ErrScoreNaN {
+		t.Errorf("Expected ErrScoreNaN, got %v", err)
+	}
+}
+
+func TestSortedSetRollbackRestoresScores(t *testing.T) {
+	db := New()
+	db.ZAdd("board", "10", "alice", "20", "bob")
+
+	db.Begin()
+	db.ZIncrBy("board", "alice", 15)
+	db.ZAdd("board", "1", "carol")
+
+	db.Begin()
+	db.ZRem("board", "alice", "bob", "carol")
+	if got := db.Type("board"); got != "none" {
+		t.Errorf("Expected 'none', got '%s'", got)
+	}
+	db.Rollback()
+
+	if members := rangeMembers(db.ZRange("board", 0, -1)); strings.Join(members, ",") != "carol,bob,alice" {
+		t.Errorf("Expected 'carol,bob,alice', got '%s'", strings.Join(members, ","))
+	}
+
+	db.Rollback()
+	if score, _, _ := db.ZScore("board", "alice"); score != 10 {
+		t.Errorf("Expected 10, got %v", score)
+	}
+	if _, ok, _ := db.ZScore("board", "carol"); ok {
+		t.Error("Expected carol to be rolled back")
+	}
+
+	db.Begin()
+	db.ZIncrBy("board", "bob", -15)
+	db.Commit()
+
+	if rank, _, _ := db.ZRank("board", "bob"); rank != 0 {
+		t.Errorf("Expected 0, got %d", rank)
+	}
+}
@@ -0,0 +1,121 @@
+package glob
+
+// Match reports whether s matches the glob pattern. The syntax follows the
+// usual shell-style rules:
+//
+//   - '*' matches any sequence of bytes, including none
+//   - '?' matches exactly one byte
+//   - "[abc]" matches one of the listed bytes, "[^abc]" any other byte
+//   - "[a-z]" matches a byte in the range
+//   - '\' matches the following byte literally
+//
+// A '[' without a closing ']' is matched literally.
+func Match(pattern, s string) bool {
+	px, sx := 0, 0
+	starPx, starSx := -1, -1
+
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				// Remember where to resume if the rest fails to match
+				starPx, starSx = px, sx+1
+				px++
+				continue
+			case '?':
+				if sx < len(s) {
+					px++
+					sx++
+					continue
+				}
+			case '[':
+				if sx < len(s) {
+					matched, width, ok := matchClass(pattern[px:], s[sx])
+					if !ok {
+						matched, width = s[sx] == '[', 1
+					}
+					if matched {
+						px += width
+						sx++
+						continue
+					}
+				}
+			case '\\':
+				literal := byte('\\')
+				width := 1
+				if px+1 < len(pattern) {
+					literal, width = pattern[px+1], 2
+				}
+				if sx < len(s) && s[sx] == literal {
+					px += width
+					sx++
+					continue
+				}
+			default:
+				if sx < len(s) && s[sx] == c {
+					px++
+					sx++
+					continue
+				}
+			}
+		}
+
+		if starPx >= 0 && starSx <= len(s) {
+			px, sx = starPx, starSx
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Prefix returns the literal text a pattern starts with, before its first
+// special character. Every string matching the pattern begins with it.
+func Prefix(pattern string) string {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[', '\\':
+			return pattern[:i]
+		}
+	}
+	return pattern
+}
+
+// matchClass matches c against the character class at the start of pattern.
+// It returns whether c matched, how many bytes of pattern the class spans,
+// and false for ok if the class is not terminated.
+func matchClass(pattern string, c byte) (matched bool, width int, ok bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && pattern[i] == '^' {
+		negate = true
+		i++
+	}
+
+	for i < len(pattern) && pattern[i] != ']' {
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+			if hi < lo {
+				lo, hi = hi, lo
+			}
+		}
+
+		if c >= lo && c <= hi {
+			matched = true
+		}
+		i++
+	}
+
+	if i >= len(pattern) {
+		return false, 0, false
+	}
+	return matched != negate, i + 1, true
+}
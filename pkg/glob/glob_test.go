@@ -0,0 +1,57 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		input    string
+		expected bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"user:*", "user:1", true},
+		{"user:*", "order:1", false},
+		{"*:1", "user:1", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "hllo", true},
+		{"h*llo", "heeeello", true},
+		{"h*l*o", "hello world o", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{"h\\*llo", "h*llo", true},
+		{"h\\*llo", "hello", false},
+		{"a[b", "a[b", true},
+		{"a*b*c", "abbbc", true},
+		{"a*b*c", "abbb", false},
+		{"", "", true},
+		{"", "a", false},
+	}
+
+	for _, test := range tests {
+		if got := Match(test.pattern, test.input); got != test.expected {
+			t.Errorf("Match(%q, %q): expected %v, got %v", test.pattern, test.input, test.expected, got)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := map[string]string{
+		"user:*":  "user:",
+		"user:?x": "user:",
+		"[ab]*":   "",
+		"plain":   "plain",
+		"a\\*b":   "a",
+	}
+
+	for pattern, expected := range tests {
+		if got := Prefix(pattern); got != expected {
+			t.Errorf("Prefix(%q): expected %q, got %q", pattern, expected, got)
+		}
+	}
+}
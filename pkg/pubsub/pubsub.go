@@ -0,0 +1,256 @@
+package pubsub
+
+import (
+	"simple-database/pkg/glob"
+	"sort"
+	"sync"
+)
+
+// DefaultBufferSize is the number of messages a subscriber can fall behind
+// by before the slow consumer policy applies
+const DefaultBufferSize = 1024
+
+// Policy decides what happens when a message is published to a subscriber
+// whose buffer is full
+type Policy int
+
+const (
+	// Disconnect drops the subscriber and closes its message channel, so
+	// one slow client cannot hold up the others or grow without bound
+	Disconnect Policy = iota
+	// DropMessages keeps the subscriber but discards the messages that do
+	// not fit, counting them
+	DropMessages
+)
+
+// ParsePolicy returns the policy named "disconnect" or "drop"
+func ParsePolicy(name string) (Policy, bool) {
+	switch name {
+	case "disconnect":
+		return Disconnect, true
+	case "drop":
+		return DropMessages, true
+	}
+	return 0, false
+}
+
+// Message is a published message as delivered to one subscription
+type Message struct {
+	// Pattern is the pattern the subscriber matched the channel with, or
+	// "" for a subscription to the channel itself
+	Pattern string
+	Channel string
+	Payload string
+}
+
+// Broker is a registry of subscribers to channels and channel patterns.
+// It is safe for concurrent use.
+type Broker struct {
+	mu         sync.Mutex
+	bufferSize int
+	policy     Policy
+	channels   map[string]map[*Subscriber]struct{}
+	patterns   map[string]map[*Subscriber]struct{}
+}
+
+// NewBroker creates a broker whose subscribers buffer up to bufferSize
+// messages, applying policy when a buffer is full
+func NewBroker(bufferSize int, policy Policy) *Broker {
+	return &Broker{
+		bufferSize: max(bufferSize, 1),
+		policy:     policy,
+		channels:   make(map[string]map[*Subscriber]struct{}),
+		patterns:   make(map[string]map[*Subscriber]struct{}),
+	}
+}
+
+// Subscriber receives the messages published to the channels and patterns
+// it subscribed to, in publishing order
+type Subscriber struct {
+	broker   *Broker
+	messages chan Message
+	channels map[string]struct{}
+	patterns map[string]struct{}
+	dropped  int
+	closed   bool
+}
+
+// NewSubscriber creates a subscriber with no subscriptions
+func (b *Broker) NewSubscriber() *Subscriber {
+	return &Subscriber{
+		broker:   b,
+		messages: make(chan Message, b.bufferSize),
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+	}
+}
+
+// Publish sends payload to every subscription matching channel and returns
+// how many received it. A subscriber matching through several
+// subscriptions gets one message for each.
+func (b *Broker) Publish(channel, payload string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	received := 0
+	for s := range b.channels[channel] {
+		if b.deliver(s, Message{Channel: channel, Payload: payload}) {
+			received++
+		}
+	}
+	for pattern, subscribers := range b.patterns {
+		if !glob.Match(pattern, channel) {
+			continue
+		}
+		for s := range subscribers {
+			if b.deliver(s, Message{Pattern: pattern, Channel: channel, Payload: payload}) {
+				received++
+			}
+		}
+	}
+	return received
+}
+
+// deliver hands message to s without waiting, applying the slow consumer
+// policy if its buffer is full. It reports whether the message was delivered.
+func (b *Broker) deliver(s *Subscriber, message Message) bool {
+	if s.closed {
+		return false
+	}
+
+	select {
+	case s.messages <- message:
+		return true
+	default:
+	}
+
+	if b.policy == DropMessages {
+		s.dropped++
+	} else {
+		b.remove(s)
+	}
+	return false
+}
+
+// remove unregisters every subscription of s and closes its message channel
+func (b *Broker) remove(s *Subscriber) {
+	for channel := range s.channels {
+		unregister(b.channels, channel, s)
+	}
+	for pattern := range s.patterns {
+		unregister(b.patterns, pattern, s)
+	}
+	s.channels = make(map[string]struct{})
+	s.patterns = make(map[string]struct{})
+	s.closed = true
+	close(s.messages)
+}
+
+// unregister removes s from the subscribers of name in registry
+func unregister(registry map[string]map[*Subscriber]struct{}, name string, s *Subscriber) {
+	delete(registry[name], s)
+	if len(registry[name]) == 0 {
+		delete(registry, name)
+	}
+}
+
+// Messages returns the channel messages are delivered on. It is closed when
+// the subscriber is closed, including by the Disconnect policy.
+func (s *Subscriber) Messages() <-chan Message {
+	return s.messages
+}
+
+// Subscribe subscribes to channels and returns the number of subscriptions
+// after each one. A closed subscriber does not subscribe.
+func (s *Subscriber) Subscribe(channels ...string) []int {
+	return s.subscribe(s.broker.channels, s.channels, channels)
+}
+
+// PSubscribe subscribes to every channel matching the glob patterns and
+// returns the number of subscriptions after each one
+func (s *Subscriber) PSubscribe(patterns ...string) []int {
+	return s.subscribe(s.broker.patterns, s.patterns, patterns)
+}
+
+// Unsubscribe unsubscribes from channels, or from every channel if none are
+// given, and returns the channels and the number of subscriptions left
+// after each one
+func (s *Subscriber) Unsubscribe(channels ...string) ([]string, []int) {
+	return s.unsubscribe(s.broker.channels, s.channels, channels)
+}
+
+// PUnsubscribe is like Unsubscribe for patterns
+func (s *Subscriber) PUnsubscribe(patterns ...string) ([]string, []int) {
+	return s.unsubscribe(s.broker.patterns, s.patterns, patterns)
+}
+
+// subscribe implements Subscribe and PSubscribe
+func (s *Subscriber) subscribe(registry map[string]map[*Subscriber]struct{}, own map[string]struct{}, names []string) []int {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	counts := make([]int, len(names))
+	for i, name := range names {
+		if registry[name] == nil {
+			registry[name] = make(map[*Subscriber]struct{})
+		}
+		registry[name][s] = struct{}{}
+		own[name] = struct{}{}
+		counts[i] = len(s.channels) + len(s.patterns)
+	}
+	return counts
+}
+
+// unsubscribe implements Unsubscribe and PUnsubscribe
+func (s *Subscriber) unsubscribe(registry map[string]map[*Subscriber]struct{}, own map[string]struct{}, names []string) ([]string, []int) {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if len(names) == 0 {
+		for name := range own {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	counts := make([]int, len(names))
+	for i, name := range names {
+		if _, subscribed := own[name]; subscribed {
+			delete(own, name)
+			unregister(registry, name, s)
+		}
+		counts[i] = len(s.channels) + len(s.patterns)
+	}
+	return names, counts
+}
+
+// Count returns the number of channels and patterns s is subscribed to
+func (s *Subscriber) Count() int {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	return len(s.channels) + len(s.patterns)
+}
+
+// Dropped returns the number of messages discarded because the buffer was
+// full, under the DropMessages policy
+func (s *Subscriber) Dropped() int {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	return s.dropped
+}
+
+// Close unsubscribes from everything and closes the message channel
+func (s *Subscriber) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if !s.closed {
+		s.broker.remove(s)
+	}
+}
@@ -0,0 +1,136 @@
+package pubsub
+
+import (
+	"strings"
+	"testing"
+)
+
+// drain returns the messages waiting for s, formatted as
+// "pattern|channel|payload"
+func drain(s *Subscriber) []string {
+	var messages []string
+	for {
+		select {
+		case m, ok := <-s.Messages():
+			if !ok {
+				return messages
+			}
+			messages = append(messages, m.Pattern+"|"+m.Channel+"|"+m.Payload)
+		default:
+			return messages
+		}
+	}
+}
+
+func TestPublishToChannelsAndPatterns(t *testing.T) {
+	broker := NewBroker(DefaultBufferSize, Disconnect)
+	news := broker.NewSubscriber()
+	all := broker.NewSubscriber()
+
+	if got := news.Subscribe("news", "sports"); len(got) != 2 || got[1] != 2 {
+		t.Errorf("Expected counts [1 2], got %v", got)
+	}
+	all.PSubscribe("n*")
+	all.Subscribe("news")
+
+	if got := broker.Publish("news", "hello world"); got != 3 {
+		t.Errorf("Expected 3 receivers, got %d", got)
+	}
+	if got := broker.Publish("nothing", "x"); got != 1 {
+		t.Errorf("Expected 1 receiver, got %d", got)
+	}
+	if got := broker.Publish("weather", "x"); got != 0 {
+		t.Errorf("Expected 0 receivers, got %d", got)
+	}
+
+	if got := strings.Join(drain(news), ","); got != "|news|hello world" {
+		t.Errorf("Expected '|news|hello world', got '%s'", got)
+	}
+	got := drain(all)
+	if len(got) != 3 || got[2] != "n*|nothing|x" {
+		t.Errorf("Expected three messages ending with 'n*|nothing|x', got %v", got)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	broker := NewBroker(DefaultBufferSize, Disconnect)
+	s := broker.NewSubscriber()
+	s.Subscribe("b", "a")
+	s.PSubscribe("p*")
+
+	names, counts := s.Unsubscribe("a", "missing")
+	if strings.Join(names, ",") != "a,missing" || counts[0] != 2 || counts[1] != 2 {
+		t.Errorf("Expected ([a missing], [2 2]), got (%v, %v)", names, counts)
+	}
+
+	// With no arguments every channel is dropped, but patterns stay
+	names, counts = s.Unsubscribe()
+	if strings.Join(names, ",") != "b" || counts[0] != 1 {
+		t.Errorf("Expected ([b], [1]), got (%v, %v)", names, counts)
+	}
+	if got := broker.Publish("b", "x"); got != 0 {
+		t.Errorf("Expected 0 receivers, got %d", got)
+	}
+
+	s.PUnsubscribe()
+	if got := s.Count(); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+	if len(broker.channels) != 0 || len(broker.patterns) != 0 {
+		t.Error("Expected the registry to be empty")
+	}
+}
+
+func TestSlowConsumerIsDisconnected(t *testing.T) {
+	broker := NewBroker(2, Disconnect)
+	slow := broker.NewSubscriber()
+	fast := broker.NewSubscriber()
+	slow.Subscribe("c")
+	fast.Subscribe("c")
+
+	for i := 0; i < 3; i++ {
+		broker.Publish("c", "m")
+		drain(fast)
+	}
+
+	if got := len(drain(slow)); got != 2 {
+		t.Errorf("Expected the 2 buffered messages, got %d", got)
+	}
+	if _, ok := <-slow.Messages(); ok {
+		t.Error("Expected the slow subscriber's channel to be closed")
+	}
+	if got := slow.Subscribe("c"); got != nil {
+		t.Errorf("Expected a closed subscriber not to subscribe, got %v", got)
+	}
+
+	if got := broker.Publish("c", "m"); got != 1 {
+		t.Errorf("Expected 1 receiver, got %d", got)
+	}
+}
+
+func TestSlowConsumerDropsMessages(t *testing.T) {
+	broker := NewBroker(2, DropMessages)
+	s := broker.NewSubscriber()
+	s.Subscribe("c")
+
+	for _, payload := range []string{"1", "2", "3", "4"} {
+		broker.Publish("c", payload)
+	}
+
+	if got := s.Dropped(); got != 2 {
+		t.Errorf("Expected 2 dropped, got %d", got)
+	}
+	if got := strings.Join(drain(s), ","); got != "|c|1,|c|2" {
+		t.Errorf("Expected '|c|1,|c|2', got '%s'", got)
+	}
+
+	broker.Publish("c", "5")
+	if got := strings.Join(drain(s), ","); got != "|c|5" {
+		t.Errorf("Expected '|c|5', got '%s'", got)
+	}
+
+	s.Close()
+	if _, ok := <-s.Messages(); ok {
+		t.Error("Expected the channel to be closed")
+	}
+}
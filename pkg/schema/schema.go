@@ -0,0 +1,191 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"simple-database/pkg/glob"
+	"strconv"
+)
+
+var (
+	ErrInvalidRule = errors.New("INVALID SCHEMA RULE")
+	ErrViolation   = errors.New("SCHEMA VIOLATION")
+)
+
+// Kind identifies the constraint a Rule places on values
+type Kind int
+
+const (
+	// Regex requires values to match the regular expression Rule.Regex.
+	// Anchor it with ^ and $ to match the whole value.
+	Regex Kind = iota
+	// Range requires values to be integers from Rule.Min to Rule.Max
+	Range
+	// MaxLen requires values to be at most Rule.MaxLen bytes long
+	MaxLen
+	// Type requires values to parse as Rule.Type, one of "integer",
+	// "float", "boolean" or "json"
+	Type
+)
+
+// ParseKind returns the kind named "REGEX", "RANGE", "MAXLEN" or "TYPE"
+func ParseKind(name string) (Kind, bool) {
+	for _, kind := range []Kind{Regex, Range, MaxLen, Type} {
+		if kind.String() == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+// String returns the name used for the kind by the SCHEMA command
+func (k Kind) String() string {
+	switch k {
+	case Regex:
+		return "REGEX"
+	case Range:
+		return "RANGE"
+	case MaxLen:
+		return "MAXLEN"
+	case Type:
+		return "TYPE"
+	}
+	return "UNKNOWN"
+}
+
+// Rule is a constraint on the string values written to keys matching the
+// glob Pattern. Only the fields for its Kind are used.
+type Rule struct {
+	// ID is assigned by Schema.Add and identifies the rule for Remove
+	ID      int
+	Pattern string
+	Kind    Kind
+	Regex   string
+	Min     int64
+	Max     int64
+	MaxLen  int
+	Type    string
+
+	regex *regexp.Regexp
+}
+
+// Spec returns the kind and arguments of the rule, as given to SCHEMA ADD
+func (r Rule) Spec() string {
+	switch r.Kind {
+	case Regex:
+		return "REGEX " + r.Regex
+	case Range:
+		return fmt.Sprintf("RANGE %d %d", r.Min, r.Max)
+	case MaxLen:
+		return "MAXLEN " + strconv.Itoa(r.MaxLen)
+	case Type:
+		return "TYPE " + r.Type
+	}
+	return r.Kind.String()
+}
+
+// allows reports whether value satisfies the rule
+func (r Rule) allows(value string) bool {
+	switch r.Kind {
+	case Regex:
+		return r.regex.MatchString(value)
+	case Range:
+		n, err := strconv.ParseInt(value, 10, 64)
+		return err == nil && n >= r.Min && n <= r.Max
+	case MaxLen:
+		return len(value) <= r.MaxLen
+	case Type:
+		return hasType(value, r.Type)
+	}
+	return true
+}
+
+// hasType reports whether value parses as the named type
+func hasType(value, name string) bool {
+	switch name {
+	case "integer":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "float":
+		f, err := strconv.ParseFloat(value, 64)
+		return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	case "boolean":
+		return value == "true" || value == "false"
+	case "json":
+		return json.Valid([]byte(value))
+	}
+	return false
+}
+
+// Schema is a set of rules, checked in the order they were added. The zero
+// value is an empty schema. It is not safe for concurrent use.
+type Schema struct {
+	rules  []Rule
+	lastID int
+}
+
+// Add registers rule and returns the ID assigned to it. It returns
+// ErrInvalidRule for a bad regular expression, an empty range, a negative
+// length or an unknown type.
+func (s *Schema) Add(rule Rule) (int, error) {
+	switch rule.Kind {
+	case Regex:
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return 0, ErrInvalidRule
+		}
+		rule.regex = regex
+	case Range:
+		if rule.Min > rule.Max {
+			return 0, ErrInvalidRule
+		}
+	case MaxLen:
+		if rule.MaxLen < 0 {
+			return 0, ErrInvalidRule
+		}
+	case Type:
+		switch rule.Type {
+		case "integer", "float", "boolean", "json":
+		default:
+			return 0, ErrInvalidRule
+		}
+	default:
+		return 0, ErrInvalidRule
+	}
+
+	s.lastID++
+	rule.ID = s.lastID
+	s.rules = append(s.rules, rule)
+	return rule.ID, nil
+}
+
+// Remove unregisters the rule with the given ID and reports whether there
+// was one
+func (s *Schema) Remove(id int) bool {
+	for i, rule := range s.rules {
+		if rule.ID == id {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns a copy of the rules in the order they were added
+func (s *Schema) Rules() []Rule {
+	return append([]Rule(nil), s.rules...)
+}
+
+// Check returns an error wrapping ErrViolation for the first rule matching
+// key that value breaks, or nil if value may be written to key
+func (s *Schema) Check(key, value string) error {
+	for _, rule := range s.rules {
+		if glob.Match(rule.Pattern, key) && !rule.allows(value) {
+			return fmt.Errorf("%w: RULE %d %s FOR %s", ErrViolation, rule.ID, rule.Spec(), key)
+		}
+	}
+	return nil
+}
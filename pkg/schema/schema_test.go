@@ -0,0 +1,95 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	var s Schema
+	rules := []Rule{
+		{Pattern: "user:*", Kind: Regex, Regex: "^[a-z]+$"},
+		{Pattern: "age:*", Kind: Range, Min: 0, Max: 150},
+		{Pattern: "bio:*", Kind: MaxLen, MaxLen: 5},
+		{Pattern: "flag:*", Kind: Type, Type: "boolean"},
+		{Pattern: "doc:*", Kind: Type, Type: "json"},
+		{Pattern: "price:*", Kind: Type, Type: "float"},
+	}
+	for i, rule := range rules {
+		if id, err := s.Add(rule); err != nil || id != i+1 {
+			t.Errorf("Expected (%d, nil), got (%d, %v)", i+1, id, err)
+		}
+	}
+
+	tests := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"user:1", "alice", true},
+		{"user:1", "Alice", false},
+		{"age:1", "150", true},
+		{"age:1", "151", false},
+		{"age:1", "ten", false},
+		{"bio:1", "hello", true},
+		{"bio:1", "hello!", false},
+		{"flag:1", "true", true},
+		{"flag:1", "yes", false},
+		{"doc:1", `{"a": [1]}`, true},
+		{"doc:1", `{"a"`, false},
+		{"price:1", "9.99", true},
+		{"price:1", "NaN", false},
+		{"other", "anything", true},
+	}
+
+	for _, test := range tests {
+		err := s.Check(test.key, test.value)
+		if test.ok && err != nil {
+			t.Errorf("Check(%s, %s): expected nil, got %v", test.key, test.value, err)
+		}
+		if !test.ok && !errors.Is(err, ErrViolation) {
+			t.Errorf("Check(%s, %s): expected a violation, got %v", test.key, test.value, err)
+		}
+	}
+
+	expected := "SCHEMA VIOLATION: RULE 2 RANGE 0 150 FOR age:1"
+	if err := s.Check("age:1", "-1"); err == nil || err.Error() != expected {
+		t.Errorf("Expected '%s', got %v", expected, err)
+	}
+}
+
+func TestAddAndRemove(t *testing.T) {
+	var s Schema
+	invalid := []Rule{
+		{Pattern: "*", Kind: Regex, Regex: "("},
+		{Pattern: "*", Kind: Range, Min: 2, Max: 1},
+		{Pattern: "*", Kind: MaxLen, MaxLen: -1},
+		{Pattern: "*", Kind: Type, Type: "date"},
+	}
+	for _, rule := range invalid {
+		if _, err := s.Add(rule); err != ErrInvalidRule {
+			t.Errorf("Add(%s): expected ErrInvalidRule, got %v", rule.Spec(), err)
+		}
+	}
+
+	s.Add(Rule{Pattern: "a*", Kind: MaxLen, MaxLen: 1})
+	s.Add(Rule{Pattern: "*", Kind: MaxLen, MaxLen: 3})
+	if got := s.Check("ab", "xx"); got == nil {
+		t.Error("Expected rule 1 to reject 'xx'")
+	}
+
+	if !s.Remove(1) || s.Remove(1) {
+		t.Error("Expected rule 1 to be removed once")
+	}
+	if got := s.Check("ab", "xx"); got != nil {
+		t.Errorf("Expected nil, got %v", got)
+	}
+	if rules := s.Rules(); len(rules) != 1 || rules[0].ID != 2 {
+		t.Errorf("Expected only rule 2, got %v", rules)
+	}
+
+	// IDs are not reused after a removal
+	if id, _ := s.Add(Rule{Pattern: "*", Kind: Type, Type: "integer"}); id != 3 {
+		t.Errorf("Expected 3, got %d", id)
+	}
+}
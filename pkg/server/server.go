@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"simple-database/pkg/command"
+	"simple-database/pkg/database"
+	"simple-database/pkg/pubsub"
+	"strconv"
+	"sync"
+)
+
+// slowConsumerMessage is the last line sent to a subscriber disconnected
+// for falling too far behind on its messages
+const slowConsumerMessage = "DISCONNECTED: TOO FAR BEHIND ON PUBLISHED MESSAGES"
+
+// Server accepts client connections and runs their commands against a
+// database using the same line-based protocol as standard input: one
+// command per line, with any output written back followed by a newline.
+// Every connection gets its own session, so transactions are per client.
+// Clients can also publish and subscribe to messages; messages for a
+// client's subscriptions are pushed to it as they arrive. Committed changes
+// to keys are published as keyspace notifications.
+type Server struct {
+	db         *database.Database
+	broker     *pubsub.Broker
+	stopEvents context.CancelFunc
+	mu         sync.Mutex
+	listener   net.Listener
+	conns      map[net.Conn]struct{}
+	closed     bool
+}
+
+// New creates a server for the given database, with a message broker
+// that disconnects subscribers falling DefaultBufferSize messages behind
+func New(db *database.Database) *Server {
+	return NewWithBroker(db, pubsub.NewBroker(pubsub.DefaultBufferSize, pubsub.Disconnect))
+}
+
+// NewWithBroker creates a server for the given database whose clients
+// publish and subscribe through broker
+func NewWithBroker(db *database.Database, broker *pubsub.Broker) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		db:         db,
+		broker:     broker,
+		stopEvents: cancel,
+		conns:      make(map[net.Conn]struct{}),
+	}
+	go s.publishEvents(ctx, db.Watch(ctx, ""))
+	return s
+}
+
+// publishEvents publishes every committed change in two channels: the key
+// is published to "__keyevent@<db>__:<event>", and the event name to
+// "__keyspace@<db>__:<key>". Flushes are only published as key events,
+// with "*" as the key. If the database drops the watch for falling behind,
+// it watches again, missing the events in between.
+func (s *Server) publishEvents(ctx context.Context, events <-chan database.Event) {
+	for {
+		for event := range events {
+			db := strconv.Itoa(event.DB)
+			if event.Type == database.EventFlush {
+				s.broker.Publish("__keyevent@"+db+"__:"+event.Type.String(), "*")
+				continue
+			}
+			s.broker.Publish("__keyspace@"+db+"__:"+event.Key, event.Type.String())
+			s.broker.Publish("__keyevent@"+db+"__:"+event.Type.String(), event.Key)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		events = s.db.Watch(ctx, "")
+	}
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until the server is closed
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return net.ErrClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops accepting connections, closes every open one and stops
+// publishing keyspace notifications
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopEvents()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
+}
+
+// track registers an open connection, returning false if the server is closed
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+// untrack forgets a closed connection
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, conn)
+}
+
+// handle runs the commands of a single connection and pushes the messages
+// for its subscriptions. Lines are read on a separate goroutine so that a
+// client disconnecting while a blocking command is running ends that
+// command, and so that messages can be pushed while waiting for input.
+func (s *Server) handle(conn net.Conn) {
+	session := s.db.NewSession()
+	executor := command.NewExecutor(session)
+	executor.UseBroker(s.broker)
+	defer func() {
+		executor.Close()
+		session.Close()
+		conn.Close()
+		s.untrack(conn)
+	}()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer session.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	writer := bufio.NewWriter(conn)
+	write := func(output string) bool {
+		writer.WriteString(output + "\n")
+		return writer.Flush() == nil
+	}
+
+serve:
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				break serve
+			}
+			output, shouldExit := executor.Execute(line)
+			if output != "" && !write(output) {
+				break serve
+			}
+			if shouldExit {
+				break serve
+			}
+
+		case message, ok := <-executor.Messages():
+			if !ok {
+				write(slowConsumerMessage)
+				break serve
+			}
+			if !write(command.FormatMessage(message)) {
+				break serve
+			}
+		}
+	}
+
+	// Let the reader finish if we stopped early
+	conn.Close()
+	for range lines {
+	}
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"simple-database/pkg/database"
+	"testing"
+	"time"
+)
+
+// startServer runs a server on a random local port
+func startServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	srv := New(database.New())
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+	return srv, listener.Addr().String()
+}
+
+// client is a test connection to the server
+type client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *client) send(t *testing.T, line string) {
+	t.Helper()
+	if _, err := c.conn.Write([]byte(line + "\n")); err != nil {
+		t.Fatalf("Failed to send '%s': %v", line, err)
+	}
+}
+
+func (c *client) expect(t *testing.T, expected string) {
+	t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Expected '%s', got error: %v", expected, err)
+	}
+	if line[:len(line)-1] != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, line[:len(line)-1])
+	}
+}
+
+func TestServerRunsCommands(t *testing.T) {
+	_, addr := startServer(t)
+	c := dial(t, addr)
+
+	c.send(t, "SET a 10")
+	c.send(t, "GET a")
+	c.expect(t, "10")
+
+	c.send(t, "MSET b 1 c 2")
+	c.send(t, "MGET b c")
+	c.expect(t, "1")
+	c.expect(t, "2")
+}
+
+func TestServerTransactionsArePerConnection(t *testing.T) {
+	_, addr := startServer(t)
+	first := dial(t, addr)
+	second := dial(t, addr)
+
+	first.send(t, "BEGIN")
+	first.send(t, "SET a 1")
+	first.send(t, "GET a")
+	first.expect(t, "1")
+
+	second.send(t, "GET a")
+	second.expect(t, "NULL")
+	second.send(t, "COMMIT")
+	second.expect(t, "NO TRANSACTION")
+
+	first.send(t, "COMMIT")
+	first.send(t, "GET a")
+	first.expect(t, "1")
+
+	second.send(t, "GET a")
+	second.expect(t, "1")
+}
+
+func TestServerBlockingPop(t *testing.T) {
+	srv, addr := startServer(t)
+	consumer := dial(t, addr)
+	producer := dial(t, addr)
+
+	consumer.send(t, "BLPOP jobs 5")
+
+	// Wait for the consumer to block, so the push has to wake it
+	deadline := time.Now().Add(time.Second)
+	for srv.db.Blocked("jobs") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the consumer to be blocked on 'jobs'")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	producer.send(t, "BEGIN")
+	producer.send(t, "RPUSH jobs job1")
+	producer.expect(t, "1")
+	producer.send(t, "COMMIT")
+
+	consumer.expect(t, "jobs")
+	consumer.expect(t, "job1")
+
+	producer.send(t, "LLEN jobs")
+	producer.expect(t, "0")
+
+	if got := srv.db.Exists("jobs"); got != 0 {
+		t.Errorf("Expected 0, got %d", got)
+	}
+}
+
+func TestServerEndClosesConnection(t *testing.T) {
+	_, addr := startServer(t)
+	c := dial(t, addr)
+
+	c.send(t, "END")
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := c.reader.ReadString('\n'); err == nil {
+		t.Error("Expected the connection to be closed")
+	}
+}
+
+func TestServerPushesPublishedMessages(t *testing.T) {
+	_, addr := startServer(t)
+	subscriber := dial(t, addr)
+	publisher := dial(t, addr)
+
+	subscriber.send(t, "SUBSCRIBE news")
+	subscriber.expect(t, "subscribe news 1")
+	subscriber.send(t, "PSUBSCRIBE user:*")
+	subscriber.expect(t, "psubscribe user:* 2")
+
+	// Subscribed connections only take subscription commands
+	subscriber.send(t, "GET a")
+	subscriber.expect(t, "ONLY SUBSCRIBE, UNSUBSCRIBE, PSUBSCRIBE AND PUNSUBSCRIBE ARE ALLOWED WHILE SUBSCRIBED")
+
+	publisher.send(t, "PUBLISH news hello  world")
+	publisher.expect(t, "1")
+	subscriber.expect(t, "message news hello  world")
+
+	publisher.send(t, "PUBLISH user:1 signed in")
+	publisher.expect(t, "1")
+	subscriber.expect(t, "pmessage user:* user:1 signed in")
+
+	publisher.send(t, "PUBLISH other x")
+	publisher.expect(t, "0")
+
+	subscriber.send(t, "UNSUBSCRIBE")
+	subscriber.expect(t, "unsubscribe news 1")
+	subscriber.send(t, "PUNSUBSCRIBE")
+	subscriber.expect(t, "punsubscribe user:* 0")
+
+	subscriber.send(t, "SET a 1")
+	subscriber.send(t, "GET a")
+	subscriber.expect(t, "1")
+}
+
+func TestServerDropsSubscriptionsOnDisconnect(t *testing.T) {
+	_, addr := startServer(t)
+	subscriber := dial(t, addr)
+	publisher := dial(t, addr)
+
+	subscriber.send(t, "SUBSCRIBE news")
+	subscriber.expect(t, "subscribe news 1")
+	subscriber.conn.Close()
+
+	// The subscription goes away once the server notices the disconnect
+	deadline := time.Now().Add(time.Second)
+	for {
+		publisher.send(t, "PUBLISH news x")
+		line, _ := publisher.reader.ReadString('\n')
+		if line == "0\n" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 0 receivers, got '%s'", line)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServerPublishesKeyspaceNotifications(t *testing.T) {
+	_, addr := startServer(t)
+	subscriber := dial(t, addr)
+	writer := dial(t, addr)
+
+	subscriber.send(t, "PSUBSCRIBE __key*@0__:*")
+	subscriber.expect(t, "psubscribe __key*@0__:* 1")
+
+	writer.send(t, "BEGIN")
+	writer.send(t, "SET a 1")
+	writer.send(t, "ROLLBACK")
+	writer.send(t, "BEGIN")
+	writer.send(t, "SET b 2")
+	writer.send(t, "COMMIT")
+	writer.send(t, "UNSET b")
+
+	subscriber.expect(t, "pmessage __key*@0__:* __keyspace@0__:b set")
+	subscriber.expect(t, "pmessage __key*@0__:* __keyevent@0__:set b")
+	subscriber.expect(t, "pmessage __key*@0__:* __keyspace@0__:b del")
+	subscriber.expect(t, "pmessage __key*@0__:* __keyevent@0__:del b")
+
+	writer.send(t, "FLUSHDB")
+	subscriber.expect(t, "pmessage __key*@0__:* __keyevent@0__:flush *")
+}
@@ -0,0 +1,146 @@
+package storage
+
+import "math/bits"
+
+// Bit helpers treat a string value as a bitmap. Bits are numbered from the
+// most significant bit of the first byte, and bits past the end of the
+// value read as 0.
+
+// GetBit returns the bit at offset in value
+func GetBit(value string, offset int) int {
+	index := offset / 8
+	if index >= len(value) {
+		return 0
+	}
+	return int(value[index]>>(7-offset%8)) & 1
+}
+
+// SetBit returns value with the bit at offset set to bit, padding it with
+// zero bytes if it is too short, along with the previous bit. Strings are
+// immutable, so it copies the whole value into a buffer and back, which
+// costs time proportional to the length of the value.
+func SetBit(value string, offset, bit int) (string, int) {
+	index := offset / 8
+	buf := []byte(value)
+	if index >= len(buf) {
+		buf = append(buf, make([]byte, index+1-len(buf))...)
+	}
+
+	mask := byte(1) << (7 - offset%8)
+	old := 0
+	if buf[index]&mask != 0 {
+		old = 1
+	}
+	if bit == 1 {
+		buf[index] |= mask
+	} else {
+		buf[index] &^= mask
+	}
+	return string(buf), old
+}
+
+// BitCount counts the set bits between start and end (inclusive). The
+// positions are bytes, or bits when bitUnit is set, and negative positions
+// count from the end as in List.Range.
+func BitCount(value string, start, end int, bitUnit bool) int {
+	first, last, ok := bitRange(value, start, end, bitUnit)
+	if !ok {
+		return 0
+	}
+
+	count := 0
+	for offset := first; offset <= last; {
+		if offset%8 == 0 && offset+7 <= last {
+			count += bits.OnesCount8(value[offset/8])
+			offset += 8
+			continue
+		}
+		count += GetBit(value, offset)
+		offset++
+	}
+	return count
+}
+
+// BitPos returns the offset of the first bit equal to bit between start
+// and end (inclusive), with positions as in BitCount, or -1 if there is
+// none. When end is -1 the search for a 0 bit runs past the end of the
+// value, which is treated as padded with zeros, so a value of all ones
+// yields the first offset after it.
+func BitPos(value string, bit, start, end int, bitUnit bool) int {
+	first, last, ok := bitRange(value, start, end, bitUnit)
+	if !ok {
+		if bit == 0 && len(value) == 0 {
+			return 0
+		}
+		return -1
+	}
+
+	// Whole bytes that cannot hold the bit are skipped
+	skip := byte(0x00)
+	if bit == 0 {
+		skip = 0xff
+	}
+	for offset := first; offset <= last; {
+		if offset%8 == 0 && offset+7 <= last && value[offset/8] == skip {
+			offset += 8
+			continue
+		}
+		if GetBit(value, offset) == bit {
+			return offset
+		}
+		offset++
+	}
+
+	if bit == 0 && end == -1 {
+		return last + 1
+	}
+	return -1
+}
+
+// bitRange converts start and end, in bytes or bits, to an inclusive
+// range of bit offsets within value
+func bitRange(value string, start, end int, bitUnit bool) (int, int, bool) {
+	if !bitUnit {
+		start, end, ok := normalizeRange(start, end, len(value))
+		return start * 8, end*8 + 7, ok
+	}
+	return normalizeRange(start, end, len(value)*8)
+}
+
+// BitOp combines values with the bitwise operation op, one of "AND", "OR",
+// "XOR" or "NOT" (which takes a single value). Shorter values are padded
+// with zero bytes to the length of the longest.
+func BitOp(op string, values []string) string {
+	length := 0
+	for _, value := range values {
+		length = max(length, len(value))
+	}
+
+	result := make([]byte, length)
+	for i := range result {
+		b := byteAt(values[0], i)
+		for _, value := range values[1:] {
+			switch op {
+			case "AND":
+				b &= byteAt(value, i)
+			case "OR":
+				b |= byteAt(value, i)
+			case "XOR":
+				b ^= byteAt(value, i)
+			}
+		}
+		if op == "NOT" {
+			b = ^b
+		}
+		result[i] = b
+	}
+	return string(result)
+}
+
+// byteAt returns the byte at index in value, or 0 past its end
+func byteAt(value string, index int) byte {
+	if index >= len(value) {
+		return 0
+	}
+	return value[index]
+}
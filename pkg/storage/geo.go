@@ -0,0 +1,159 @@
+package storage
+
+import "math"
+
+// Geo bounds and precision. Latitudes are limited to the range covered by
+// the Web Mercator projection, and positions are stored as 52-bit
+// geohashes (26 bits per axis), precise to well under a meter.
+const (
+	GeoMinLongitude = -180.0
+	GeoMaxLongitude = 180.0
+	GeoMinLatitude  = -85.05112878
+	GeoMaxLatitude  = 85.05112878
+
+	geoStep        = 26
+	geoEarthRadius = 6372797.560856
+)
+
+// ValidGeoPosition reports whether a longitude and latitude can be indexed
+func ValidGeoPosition(longitude, latitude float64) bool {
+	return longitude >= GeoMinLongitude && longitude <= GeoMaxLongitude &&
+		latitude >= GeoMinLatitude && latitude <= GeoMaxLatitude
+}
+
+// GeohashEncode returns the 52-bit geohash of a position. Longitude and
+// latitude bits are interleaved starting with longitude, so positions that
+// share a cell share a prefix and sort next to each other as sorted set
+// scores.
+func GeohashEncode(longitude, latitude float64) uint64 {
+	lonCell := geoCell(longitude, GeoMinLongitude, GeoMaxLongitude)
+	latCell := geoCell(latitude, GeoMinLatitude, GeoMaxLatitude)
+	return interleave(latCell, lonCell)
+}
+
+// GeohashDecode returns the position at the center of a geohash cell
+func GeohashDecode(hash uint64) (float64, float64) {
+	latCell, lonCell := deinterleave(hash)
+	cells := float64(uint64(1) << geoStep)
+	longitude := GeoMinLongitude + (float64(lonCell)+0.5)*(GeoMaxLongitude-GeoMinLongitude)/cells
+	latitude := GeoMinLatitude + (float64(latCell)+0.5)*(GeoMaxLatitude-GeoMinLatitude)/cells
+	return longitude, latitude
+}
+
+// GeoDistance returns the great-circle distance in meters between two
+// positions, using the haversine formula
+func GeoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	lat1r, lat2r := radians(lat1), radians(lat2)
+	u := math.Sin((lat2r - lat1r) / 2)
+	v := math.Sin(radians(lon2-lon1) / 2)
+	return 2 * geoEarthRadius * math.Asin(math.Sqrt(u*u+math.Cos(lat1r)*math.Cos(lat2r)*v*v))
+}
+
+// GeoHashRanges returns the score ranges, as inclusive [min, max] pairs,
+// holding every geohash that might lie within width by height meters of
+// the center. It picks the finest grid whose cells are at least as large
+// as the area, so the area overlaps at most a few cells; callers still
+// filter the members of those cells by exact distance.
+func GeoHashRanges(longitude, latitude, width, height float64) [][2]float64 {
+	latDelta := degrees(height / 2 / geoEarthRadius)
+	minLat := max(latitude-latDelta, GeoMinLatitude)
+	maxLat := min(latitude+latDelta, GeoMaxLatitude)
+
+	// Degrees of longitude shrink towards the poles, so the widest part
+	// of the area is at the latitude closest to one
+	lonDelta := GeoMaxLongitude
+	if cos := math.Cos(radians(max(math.Abs(minLat), math.Abs(maxLat)))); cos > 0 {
+		lonDelta = min(degrees(width/2/(geoEarthRadius*cos)), GeoMaxLongitude)
+	}
+
+	step := geoStep
+	for step > 0 {
+		cells := float64(uint64(1) << step)
+		if (GeoMaxLongitude-GeoMinLongitude)/cells >= 2*lonDelta &&
+			(GeoMaxLatitude-GeoMinLatitude)/cells >= maxLat-minLat {
+			break
+		}
+		step--
+	}
+
+	// Cell indices at the chosen step. Longitude cells past either end of
+	// the grid wrap around, so areas crossing the antimeridian are covered.
+	cells := int64(1) << step
+	lonWidth := (GeoMaxLongitude - GeoMinLongitude) / float64(cells)
+	firstLon := int64(math.Floor((longitude - lonDelta - GeoMinLongitude) / lonWidth))
+	lastLon := int64(math.Floor((longitude + lonDelta - GeoMinLongitude) / lonWidth))
+	firstLat := geoCell(minLat, GeoMinLatitude, GeoMaxLatitude) >> (geoStep - step)
+	lastLat := geoCell(maxLat, GeoMinLatitude, GeoMaxLatitude) >> (geoStep - step)
+
+	shift := 2 * (geoStep - step)
+	var ranges [][2]float64
+	seen := make(map[uint64]bool)
+	for latCell := firstLat; latCell <= lastLat; latCell++ {
+		for lonCell := firstLon; lonCell <= lastLon && lonCell < firstLon+cells; lonCell++ {
+			cell := interleave(latCell, uint64((lonCell%cells+cells)%cells))
+			if seen[cell] {
+				continue
+			}
+			seen[cell] = true
+			ranges = append(ranges, [2]float64{float64(cell << shift), float64((cell+1)<<shift - 1)})
+		}
+	}
+	return ranges
+}
+
+// geoCell returns the index of the cell holding value when the range from
+// min to max is divided into 2^26 cells
+func geoCell(value, min, max float64) uint64 {
+	cells := float64(uint64(1) << geoStep)
+	cell := (value - min) / (max - min) * cells
+	if cell < 0 {
+		return 0
+	}
+	if cell >= cells {
+		return uint64(cells) - 1
+	}
+	return uint64(cell)
+}
+
+// interleave spreads the bits of x into the even positions and the bits
+// of y into the odd positions of the result
+func interleave(x, y uint64) uint64 {
+	return spread(x) | spread(y)<<1
+}
+
+// deinterleave reverses interleave
+func deinterleave(hash uint64) (uint64, uint64) {
+	return squash(hash), squash(hash >> 1)
+}
+
+// spread moves the low 32 bits of v to the even bit positions
+func spread(v uint64) uint64 {
+	v &= 0xffffffff
+	v = (v | v<<16) & 0x0000ffff0000ffff
+	v = (v | v<<8) & 0x00ff00ff00ff00ff
+	v = (v | v<<4) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v<<2) & 0x3333333333333333
+	v = (v | v<<1) & 0x5555555555555555
+	return v
+}
+
+// squash collects the even bits of v into the low 32 bits
+func squash(v uint64) uint64 {
+	v &= 0x5555555555555555
+	v = (v | v>>1) & 0x3333333333333333
+	v = (v | v>>2) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v>>4) & 0x00ff00ff00ff00ff
+	v = (v | v>>8) & 0x0000ffff0000ffff
+	v = (v | v>>16) & 0x00000000ffffffff
+	return v
+}
+
+// radians converts degrees to radians
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// degrees converts radians to degrees
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
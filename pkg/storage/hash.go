@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"math"
+	"strconv"
+)
+
+// Hash is a value holding a map of fields to values
+type Hash map[string]string
+
+// Type returns TypeHash
+func (h Hash) Type() ValueType {
+	return TypeHash
+}
+
+// Clone returns a copy of the hash
+func (h Hash) Clone() Value {
+	clone := make(Hash, len(h))
+	for field, value := range h {
+		clone[field] = value
+	}
+	return clone
+}
+
+// HashPatch records field writes, removals and increments on a hash
+type HashPatch map[string]fieldChange
+
+// fieldChange is the change recorded for one field: a write of value, a
+// removal, or increments to add to whatever the field holds when the patch
+// is applied
+type fieldChange struct {
+	value      string
+	removed    bool
+	increments []int64
+}
+
+// then returns the change made by later on top of c. Only an increment
+// depends on what came before.
+func (c fieldChange) then(later fieldChange) fieldChange {
+	if len(later.increments) == 0 {
+		return later
+	}
+	if len(c.increments) > 0 {
+		return fieldChange{increments: append(append([]int64(nil), c.increments...), later.increments...)}
+	}
+	if sum, ok := incrementField(c.value, !c.removed, later.increments); ok {
+		return fieldChange{value: sum}
+	}
+	return later
+}
+
+// incrementField adds increments to the integer in value, or to 0 if the
+// field does not exist. It returns false if value is not an integer or the
+// result would overflow.
+func incrementField(value string, exists bool, increments []int64) (string, bool) {
+	current := int64(0)
+	if exists {
+		var err error
+		if current, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return "", false
+		}
+	}
+
+	for _, increment := range increments {
+		if (increment > 0 && current > math.MaxInt64-increment) ||
+			(increment < 0 && current < math.MinInt64-increment) {
+			return "", false
+		}
+		current += increment
+	}
+	return strconv.FormatInt(current, 10), true
+}
+
+// SetField records a write of value to field
+func (p HashPatch) SetField(field, value string) {
+	p[field] = fieldChange{value: value}
+}
+
+// DeleteField records the removal of field
+func (p HashPatch) DeleteField(field string) {
+	p[field] = fieldChange{removed: true}
+}
+
+// IncrField records increment being added to the integer in field, whatever
+// it holds when the patch is applied
+func (p HashPatch) IncrField(field string, increment int64) {
+	change := fieldChange{increments: []int64{increment}}
+	if earlier, exists := p[field]; exists {
+		change = earlier.then(change)
+	}
+	p[field] = change
+}
+
+// Type returns TypeHash
+func (p HashPatch) Type() ValueType {
+	return TypeHash
+}
+
+// Conflicts reports whether an increment recorded in the patch no longer
+// applies to v, because its field stopped holding an integer or the sum
+// would overflow. That happens when the hash was changed after the patch
+// was recorded.
+func (p HashPatch) Conflicts(v Value) bool {
+	hash, _ := v.(Hash)
+	for field, change := range p {
+		if len(change.increments) == 0 {
+			continue
+		}
+		current, exists := hash[field]
+		if _, ok := incrementField(current, exists, change.increments); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply writes, removes and increments the patched fields. A hash left
+// without fields is returned as nil. Increments that no longer apply are
+// skipped, so callers check Conflicts first.
+func (p HashPatch) Apply(v Value) Value {
+	hash, _ := v.(Hash)
+	if hash == nil {
+		hash = make(Hash)
+	}
+
+	for field, change := range p {
+		switch {
+		case len(change.increments) > 0:
+			current, exists := hash[field]
+			if sum, ok := incrementField(current, exists, change.increments); ok {
+				hash[field] = sum
+			}
+		case change.removed:
+			delete(hash, field)
+		default:
+			hash[field] = change.value
+		}
+	}
+
+	if len(hash) == 0 {
+		return nil
+	}
+	return hash
+}
+
+// Merge folds the field changes of a later hash patch into this one
+func (p HashPatch) Merge(later Patch) {
+	for field, change := range later.(HashPatch) {
+		if earlier, exists := p[field]; exists {
+			change = earlier.then(change)
+		}
+		p[field] = change
+	}
+}
+
+// Clone returns a copy of the patch. Recorded increments are never
+// modified, so they are shared.
+func (p HashPatch) Clone() Patch {
+	clone := make(HashPatch, len(p))
+	for field, change := range p {
+		clone[field] = change
+	}
+	return clone
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog parameters. 2^14 registers give a standard error of
+// 1.04/sqrt(16384), about 0.81%.
+const (
+	hllMagic     = "HYLL"
+	hllPrecision = 14
+	hllRegisters = 1 << hllPrecision
+	hllMaxRank   = 64 - hllPrecision + 1
+	hllBits      = 6
+	hllSize      = len(hllMagic) + hllRegisters*hllBits/8
+)
+
+// HyperLogLog estimates the number of distinct elements added to it. It is
+// stored as a string value: the "HYLL" magic followed by the registers
+// packed six bits each, 12292 bytes in all.
+type HyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+// NewHyperLogLog creates an empty HyperLogLog
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{}
+}
+
+// isHyperLogLog reports whether value looks like the string encoding of a
+// HyperLogLog
+func isHyperLogLog(value string) bool {
+	return len(value) == hllSize && value[:len(hllMagic)] == hllMagic
+}
+
+// ParseHyperLogLog decodes a HyperLogLog from its string encoding,
+// returning false if value is not one
+func ParseHyperLogLog(value string) (*HyperLogLog, bool) {
+	if !isHyperLogLog(value) {
+		return nil, false
+	}
+
+	h := NewHyperLogLog()
+	packed := value[len(hllMagic):]
+	for i := range h.registers {
+		h.registers[i] = readRegister(packed, i)
+		if h.registers[i] > hllMaxRank {
+			return nil, false
+		}
+	}
+	return h, true
+}
+
+// String returns the string encoding of the HyperLogLog
+func (h *HyperLogLog) String() string {
+	packed := make([]byte, hllSize-len(hllMagic))
+	for i, rank := range h.registers {
+		writeRegister(packed, i, rank)
+	}
+	return hllMagic + string(packed)
+}
+
+// Add records an element and reports whether the estimate may have
+// changed
+func (h *HyperLogLog) Add(element string) bool {
+	hash := hashElement(element)
+	index := hash & (hllRegisters - 1)
+
+	// The extra high bit caps the rank for hashes whose remaining bits
+	// are all zero
+	rest := hash>>hllPrecision | 1<<(64-hllPrecision)
+	rank := uint8(bits.TrailingZeros64(rest) + 1)
+
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+		return true
+	}
+	return false
+}
+
+// Merge folds other into h, so h estimates the union of both
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	for i, rank := range other.registers {
+		h.registers[i] = max(h.registers[i], rank)
+	}
+}
+
+// Count returns the estimated number of distinct elements, using Ertl's
+// improved estimator ("New cardinality estimation algorithms for
+// HyperLogLog sketches", 2017), which needs no bias tables and stays
+// accurate for small counts
+func (h *HyperLogLog) Count() int {
+	var histogram [hllMaxRank + 1]int
+	for _, rank := range h.registers {
+		histogram[rank]++
+	}
+
+	m := float64(hllRegisters)
+	z := m * hllTau((m-float64(histogram[hllMaxRank]))/m)
+	for k := hllMaxRank - 1; k >= 1; k-- {
+		z += float64(histogram[k])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histogram[0])/m)
+
+	return int(math.Round(0.5 / math.Ln2 * m * m / z))
+}
+
+// hllSigma is the small-range correction of Ertl's estimator
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+
+	y, z := 1.0, x
+	for {
+		x *= x
+		previous := z
+		z += x * y
+		y += y
+		if z == previous {
+			return z
+		}
+	}
+}
+
+// hllTau is the large-range correction of Ertl's estimator
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		previous := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == previous {
+			return z / 3
+		}
+	}
+}
+
+// hashElement returns a well-mixed 64-bit hash of element: FNV-1a followed
+// by the MurmurHash3 finalizer, since FNV alone spreads short keys poorly
+// across the high bits
+func hashElement(element string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(element))
+	hash := hasher.Sum64()
+
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
+
+// readRegister extracts the six-bit register i from packed
+func readRegister(packed string, i int) uint8 {
+	bit := i * hllBits
+	b0 := uint16(packed[bit/8])
+	var b1 uint16
+	if bit/8+1 < len(packed) {
+		b1 = uint16(packed[bit/8+1])
+	}
+	return uint8((b0|b1<<8)>>(bit%8)) & (1<<hllBits - 1)
+}
+
+// writeRegister stores rank as the six-bit register i in packed
+func writeRegister(packed []byte, i int, rank uint8) {
+	bit := i * hllBits
+	shift := bit % 8
+	packed[bit/8] |= rank << shift
+	if shift > 8-hllBits {
+		packed[bit/8+1] |= rank >> (8 - shift)
+	}
+}
@@ -0,0 +1,399 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSON is a value holding a parsed JSON document. Numbers are kept as
+// json.Number so integers survive unchanged.
+type JSON struct {
+	root any
+}
+
+// ParseJSON parses a JSON document, returning false if text is not valid
+// JSON
+func ParseJSON(text string) (*JSON, bool) {
+	root, ok := decodeJSON(text)
+	if !ok {
+		return nil, false
+	}
+	return &JSON{root: root}, true
+}
+
+// decodeJSON parses a single JSON value
+func decodeJSON(text string) (any, bool) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	// Anything after the value makes the text invalid
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, false
+	}
+	return value, true
+}
+
+// Type returns TypeJSON
+func (j *JSON) Type() ValueType {
+	return TypeJSON
+}
+
+// Clone returns a deep copy of the document
+func (j *JSON) Clone() Value {
+	return &JSON{root: cloneJSON(j.root)}
+}
+
+// Get returns the compact JSON text of the value at path. Object keys are
+// printed in sorted order.
+func (j *JSON) Get(path JSONPath) (string, bool) {
+	value, ok := lookupJSON(j.root, path)
+	if !ok {
+		return "", false
+	}
+	return encodeJSON(value), true
+}
+
+// IsNumber reports whether the value at path is a number
+func (j *JSON) IsNumber(path JSONPath) bool {
+	value, _ := lookupJSON(j.root, path)
+	_, ok := value.(json.Number)
+	return ok
+}
+
+// Has reports whether a value exists at path
+func (j *JSON) Has(path JSONPath) bool {
+	_, ok := lookupJSON(j.root, path)
+	return ok
+}
+
+// CanSet reports whether a value could be written at path: the root, a key
+// of an existing object, or an existing index of an array
+func (j *JSON) CanSet(path JSONPath) bool {
+	if len(path) == 0 {
+		return true
+	}
+	parent, ok := lookupJSON(j.root, path[:len(path)-1])
+	if !ok {
+		return false
+	}
+
+	last := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		return !last.IsIndex
+	case []any:
+		_, ok := arrayIndex(container, last)
+		return ok
+	}
+	return false
+}
+
+// encodeJSON renders a value as compact JSON
+func encodeJSON(value any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// JSONPathSegment is one step of a JSONPath: an object key or, when
+// IsIndex is set, an array index (negative indexes count from the end)
+type JSONPathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// JSONPath is a path to a value inside a JSON document. An empty path is
+// the root.
+type JSONPath []JSONPathSegment
+
+// ParseJSONPath parses a path such as "$.users[0].name", ".users[0].name"
+// or "users[-1]". The leading "$" or "." is optional, "$" or "." alone is
+// the root, and keys that are not plain names can be written as ["key"].
+// Wildcards and filters are not supported.
+func ParseJSONPath(path string) (JSONPath, bool) {
+	path = strings.TrimPrefix(path, "$")
+	if path == "." {
+		return JSONPath{}, true
+	}
+
+	segments := JSONPath{}
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, false
+			}
+			inner := path[i+1 : i+end]
+			if quoted, err := strconv.Unquote(inner); err == nil && strings.HasPrefix(inner, `"`) {
+				segments = append(segments, JSONPathSegment{Key: quoted})
+			} else if index, err := strconv.Atoi(inner); err == nil {
+				segments = append(segments, JSONPathSegment{Index: index, IsIndex: true})
+			} else {
+				return nil, false
+			}
+			i += end + 1
+		default:
+			if path[i] == '.' {
+				i++
+			} else if i > 0 {
+				return nil, false
+			}
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, false
+			}
+			segments = append(segments, JSONPathSegment{Key: path[i:end]})
+			i = end
+		}
+	}
+	return segments, true
+}
+
+// lookupJSON follows path from value
+func lookupJSON(value any, path JSONPath) (any, bool) {
+	for _, segment := range path {
+		switch container := value.(type) {
+		case map[string]any:
+			if segment.IsIndex {
+				return nil, false
+			}
+			child, exists := container[segment.Key]
+			if !exists {
+				return nil, false
+			}
+			value = child
+		case []any:
+			index, ok := arrayIndex(container, segment)
+			if !ok {
+				return nil, false
+			}
+			value = container[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// arrayIndex resolves a segment to a position inside array
+func arrayIndex(array []any, segment JSONPathSegment) (int, bool) {
+	if !segment.IsIndex {
+		return 0, false
+	}
+	index := segment.Index
+	if index < 0 {
+		index += len(array)
+	}
+	return index, index >= 0 && index < len(array)
+}
+
+// cloneJSON deep-copies a decoded JSON value
+func cloneJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		clone := make(map[string]any, len(v))
+		for key, child := range v {
+			clone[key] = cloneJSON(child)
+		}
+		return clone
+	case []any:
+		clone := make([]any, len(v))
+		for i, child := range v {
+			clone[i] = cloneJSON(child)
+		}
+		return clone
+	}
+	return value
+}
+
+// AddJSONNumbers adds two JSON numbers. Integers stay integers unless the
+// sum overflows; anything else is added as a float. It returns false if
+// the result is not finite.
+func AddJSONNumbers(a, b json.Number) (json.Number, bool) {
+	x, errX := strconv.ParseInt(string(a), 10, 64)
+	y, errY := strconv.ParseInt(string(b), 10, 64)
+	if errX == nil && errY == nil && !((y > 0 && x > math.MaxInt64-y) || (y < 0 && x < math.MinInt64-y)) {
+		return json.Number(strconv.FormatInt(x+y, 10)), true
+	}
+
+	fx, errX := a.Float64()
+	fy, errY := b.Float64()
+	sum := fx + fy
+	if errX != nil || errY != nil || math.IsInf(sum, 0) || math.IsNaN(sum) {
+		return "", false
+	}
+	return json.Number(strconv.FormatFloat(sum, 'g', -1, 64)), true
+}
+
+// jsonOpKind identifies a recorded JSON operation
+type jsonOpKind int
+
+const (
+	jsonSet jsonOpKind = iota
+	jsonDelete
+	jsonIncr
+)
+
+// jsonOp is a single recorded JSON operation
+type jsonOp struct {
+	kind      jsonOpKind
+	path      JSONPath
+	value     any
+	increment json.Number
+}
+
+// JSONPatch records changes at paths inside a JSON document in the order
+// they happened, to be replayed like a ListPatch. A change whose path no
+// longer fits the document when it is replayed is skipped.
+type JSONPatch struct {
+	ops []jsonOp
+}
+
+// Set records text, which must be valid JSON, being written at path
+func (p *JSONPatch) Set(path JSONPath, text string) {
+	value, _ := decodeJSON(text)
+	p.ops = append(p.ops, jsonOp{kind: jsonSet, path: path, value: value})
+}
+
+// Delete records the value at path being removed. Removing the root
+// removes the whole document.
+func (p *JSONPatch) Delete(path JSONPath) {
+	p.ops = append(p.ops, jsonOp{kind: jsonDelete, path: path})
+}
+
+// IncrBy records increment being added to the number at path
+func (p *JSONPatch) IncrBy(path JSONPath, increment json.Number) {
+	p.ops = append(p.ops, jsonOp{kind: jsonIncr, path: path, increment: increment})
+}
+
+// Type returns TypeJSON
+func (p *JSONPatch) Type() ValueType {
+	return TypeJSON
+}
+
+// Apply replays the recorded operations. A document whose root was
+// removed is returned as nil.
+func (p *JSONPatch) Apply(v Value) Value {
+	doc, _ := v.(*JSON)
+
+	for _, op := range p.ops {
+		if len(op.path) == 0 {
+			switch op.kind {
+			case jsonSet:
+				doc = &JSON{root: cloneJSON(op.value)}
+			case jsonDelete:
+				doc = nil
+			case jsonIncr:
+				if doc != nil {
+					if sum, ok := incrementJSON(doc.root, op.increment); ok {
+						doc.root = sum
+					}
+				}
+			}
+			continue
+		}
+		if doc == nil {
+			continue
+		}
+
+		parent, ok := lookupJSON(doc.root, op.path[:len(op.path)-1])
+		if !ok {
+			continue
+		}
+		last := op.path[len(op.path)-1]
+
+		switch container := parent.(type) {
+		case map[string]any:
+			if last.IsIndex {
+				continue
+			}
+			switch op.kind {
+			case jsonSet:
+				container[last.Key] = cloneJSON(op.value)
+			case jsonDelete:
+				delete(container, last.Key)
+			case jsonIncr:
+				if sum, ok := incrementJSON(container[last.Key], op.increment); ok {
+					container[last.Key] = sum
+				}
+			}
+		case []any:
+			index, ok := arrayIndex(container, last)
+			if !ok {
+				continue
+			}
+			switch op.kind {
+			case jsonSet:
+				container[index] = cloneJSON(op.value)
+			case jsonDelete:
+				// Removing an element shortens the array, which means
+				// replacing the slice in its own parent
+				shortened := make([]any, 0, len(container)-1)
+				shortened = append(append(shortened, container[:index]...), container[index+1:]...)
+				doc.root = replaceJSON(doc.root, op.path[:len(op.path)-1], shortened)
+			case jsonIncr:
+				if sum, ok := incrementJSON(container[index], op.increment); ok {
+					container[index] = sum
+				}
+			}
+		}
+	}
+
+	if doc == nil {
+		return nil
+	}
+	return doc
+}
+
+// incrementJSON adds increment to value if it is a number
+func incrementJSON(value any, increment json.Number) (json.Number, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return "", false
+	}
+	return AddJSONNumbers(number, increment)
+}
+
+// replaceJSON returns root with the value at path, which must exist,
+// replaced by value
+func replaceJSON(root any, path JSONPath, value any) any {
+	if len(path) == 0 {
+		return value
+	}
+	parent, _ := lookupJSON(root, path[:len(path)-1])
+	last := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		container[last.Key] = value
+	case []any:
+		index, _ := arrayIndex(container, last)
+		container[index] = value
+	}
+	return root
+}
+
+// Merge appends the operations of a later JSON patch
+func (p *JSONPatch) Merge(later Patch) {
+	p.ops = append(p.ops, later.(*JSONPatch).ops...)
+}
+
+// Clone returns a copy of the patch. Recorded values are copied whenever
+// they are applied, so they can be shared.
+func (p *JSONPatch) Clone() Patch {
+	return &JSONPatch{ops: append([]jsonOp(nil), p.ops...)}
+}
@@ -0,0 +1,178 @@
+package storage
+
+// List is a value holding an ordered sequence of elements
+type List []string
+
+// Type returns TypeList
+func (l List) Type() ValueType {
+	return TypeList
+}
+
+// Clone returns a copy of the list
+func (l List) Clone() Value {
+	return append(List(nil), l...)
+}
+
+// Range returns the elements between start and stop (both inclusive).
+// Negative indexes count from the end of the list, and out of range
+// indexes are clamped.
+func (l List) Range(start, stop int) List {
+	start, stop, ok := normalizeRange(start, stop, len(l))
+	if !ok {
+		return nil
+	}
+	return l[start : stop+1]
+}
+
+// normalizeRange resolves negative indexes against length and clamps the
+// range to the list, reporting false if nothing is left
+func normalizeRange(start, stop, length int) (int, int, bool) {
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+// listOpKind identifies a recorded list operation
+type listOpKind int
+
+const (
+	listPushLeft listOpKind = iota
+	listPushRight
+	listPopLeft
+	listPopRight
+	listTrim
+)
+
+// listOp is a single recorded list operation
+type listOp struct {
+	kind   listOpKind
+	values []string
+	start  int
+	stop   int
+}
+
+// ListPatch records list operations in the order they happened. Because
+// list positions shift with every push and pop, the operations are replayed
+// rather than merged.
+type ListPatch struct {
+	ops []listOp
+}
+
+// Push records values pushed onto the head (left) or tail of the list
+func (p *ListPatch) Push(left bool, values ...string) {
+	kind := listPushRight
+	if left {
+		kind = listPushLeft
+	}
+	p.ops = append(p.ops, listOp{kind: kind, values: values})
+}
+
+// Pop records values popped from the head (left) or tail of the list, in
+// the order they were popped
+func (p *ListPatch) Pop(left bool, values ...string) {
+	kind := listPopRight
+	if left {
+		kind = listPopLeft
+	}
+	p.ops = append(p.ops, listOp{kind: kind, values: values})
+}
+
+// Trim records the list being trimmed to the range start..stop
+func (p *ListPatch) Trim(start, stop int) {
+	p.ops = append(p.ops, listOp{kind: listTrim, start: start, stop: stop})
+}
+
+// Type returns TypeList
+func (p *ListPatch) Type() ValueType {
+	return TypeList
+}
+
+// Conflicts reports whether replaying the patch on v would pop elements
+// other than the recorded ones, which happens when the list was changed
+// after the patch was recorded. It replays the patch on a copy of the list,
+// so it costs time proportional to the length of the list, and only when
+// the patch pops.
+func (p *ListPatch) Conflicts(v Value) bool {
+	pops := false
+	for _, op := range p.ops {
+		pops = pops || op.kind == listPopLeft || op.kind == listPopRight
+	}
+	if !pops {
+		return false
+	}
+
+	list, _ := v.(List)
+	_, ok := p.replay(append(List(nil), list...))
+	return !ok
+}
+
+// Apply replays the recorded operations. An empty list is returned as nil.
+// Pops remove as many elements as were recorded, whichever they are, so
+// callers check Conflicts first.
+func (p *ListPatch) Apply(v Value) Value {
+	list, _ := v.(List)
+	list, _ = p.replay(list)
+
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// replay applies the recorded operations to list and reports whether every
+// pop removed the elements it recorded
+func (p *ListPatch) replay(list List) (List, bool) {
+	ok := true
+	for _, op := range p.ops {
+		switch op.kind {
+		case listPushLeft:
+			head := make(List, 0, len(op.values)+len(list))
+			for i := len(op.values) - 1; i >= 0; i-- {
+				head = append(head, op.values[i])
+			}
+			list = append(head, list...)
+		case listPushRight:
+			list = append(list, op.values...)
+		case listPopLeft:
+			count := min(len(op.values), len(list))
+			for i := 0; i < count && ok; i++ {
+				ok = list[i] == op.values[i]
+			}
+			ok = ok && count == len(op.values)
+			list = list[count:]
+		case listPopRight:
+			count := min(len(op.values), len(list))
+			for i := 0; i < count && ok; i++ {
+				ok = list[len(list)-1-i] == op.values[i]
+			}
+			ok = ok && count == len(op.values)
+			list = list[:len(list)-count]
+		case listTrim:
+			list = append(List(nil), list.Range(op.start, op.stop)...)
+		}
+	}
+	return list, ok
+}
+
+// Merge appends the operations of a later list patch
+func (p *ListPatch) Merge(later Patch) {
+	p.ops = append(p.ops, later.(*ListPatch).ops...)
+}
+
+// Clone returns a copy of the patch
+func (p *ListPatch) Clone() Patch {
+	return &ListPatch{ops: append([]listOp(nil), p.ops...)}
+}
@@ -0,0 +1,64 @@
+package storage
+
+// Set is a value holding an unordered collection of unique members
+type Set map[string]struct{}
+
+// Type returns TypeSet
+func (s Set) Type() ValueType {
+	return TypeSet
+}
+
+// Clone returns a copy of the set
+func (s Set) Clone() Value {
+	clone := make(Set, len(s))
+	for member := range s {
+		clone[member] = struct{}{}
+	}
+	return clone
+}
+
+// SetPatch records members added to (true) or removed from (false) a set
+type SetPatch map[string]bool
+
+// Type returns TypeSet
+func (p SetPatch) Type() ValueType {
+	return TypeSet
+}
+
+// Apply adds and removes the patched members. A set left without members
+// is returned as nil.
+func (p SetPatch) Apply(v Value) Value {
+	set, _ := v.(Set)
+	if set == nil {
+		set = make(Set)
+	}
+
+	for member, added := range p {
+		if added {
+			set[member] = struct{}{}
+		} else {
+			delete(set, member)
+		}
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// Merge folds the member changes of a later set patch into this one
+func (p SetPatch) Merge(later Patch) {
+	for member, added := range later.(SetPatch) {
+		p[member] = added
+	}
+}
+
+// Clone returns a copy of the patch
+func (p SetPatch) Clone() Patch {
+	clone := make(SetPatch, len(p))
+	for member, added := range p {
+		clone[member] = added
+	}
+	return clone
+}
@@ -0,0 +1,192 @@
+package storage
+
+import "math/rand/v2"
+
+const (
+	skipListMaxLevel    = 32
+	skipListProbability = 0.25
+)
+
+// skipList is an ordered set of items kept sorted by less. Every link also
+// records how many items it skips so positions can be found in O(log n).
+type skipList[T any] struct {
+	less   func(a, b T) bool
+	head   *skipNode[T]
+	level  int
+	length int
+}
+
+// skipNode is a single item in a skipList
+type skipNode[T any] struct {
+	item  T
+	next  []*skipNode[T]
+	spans []int
+}
+
+// newSkipList creates an empty skip list ordered by less
+func newSkipList[T any](less func(a, b T) bool) *skipList[T] {
+	return &skipList[T]{
+		less: less,
+		head: &skipNode[T]{
+			next:  make([]*skipNode[T], skipListMaxLevel),
+			spans: make([]int, skipListMaxLevel),
+		},
+		level: 1,
+	}
+}
+
+// Len returns the number of items in the list
+func (sl *skipList[T]) Len() int {
+	return sl.length
+}
+
+// Insert adds an item, returning false if an equal item is already present
+func (sl *skipList[T]) Insert(item T) bool {
+	var update [skipListMaxLevel]*skipNode[T]
+	var rank [skipListMaxLevel]int
+
+	node := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for node.next[i] != nil && sl.less(node.next[i].item, item) {
+			rank[i] += node.spans[i]
+			node = node.next[i]
+		}
+		update[i] = node
+	}
+
+	if next := node.next[0]; next != nil && !sl.less(item, next.item) {
+		return false
+	}
+
+	level := randomLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = sl.head
+			update[i].spans[i] = sl.length
+		}
+		sl.level = level
+	}
+
+	inserted := &skipNode[T]{
+		item:  item,
+		next:  make([]*skipNode[T], level),
+		spans: make([]int, level),
+	}
+	for i := 0; i < level; i++ {
+		inserted.next[i] = update[i].next[i]
+		update[i].next[i] = inserted
+		inserted.spans[i] = update[i].spans[i] - (rank[0] - rank[i])
+		update[i].spans[i] = rank[0] - rank[i] + 1
+	}
+	for i := level; i < sl.level; i++ {
+		update[i].spans[i]++
+	}
+
+	sl.length++
+	return true
+}
+
+// Delete removes an item, returning false if it was not present
+func (sl *skipList[T]) Delete(item T) bool {
+	var update [skipListMaxLevel]*skipNode[T]
+
+	node := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && sl.less(node.next[i].item, item) {
+			node = node.next[i]
+		}
+		update[i] = node
+	}
+
+	target := node.next[0]
+	if target == nil || sl.less(item, target.item) {
+		return false
+	}
+
+	for i := 0; i < sl.level; i++ {
+		if update[i].next[i] == target {
+			update[i].spans[i] += target.spans[i] - 1
+			update[i].next[i] = target.next[i]
+		} else {
+			update[i].spans[i]--
+		}
+	}
+	for sl.level > 1 && sl.head.next[sl.level-1] == nil {
+		sl.level--
+	}
+
+	sl.length--
+	return true
+}
+
+// Seek returns the first node whose item is not less than item, or nil
+func (sl *skipList[T]) Seek(item T) *skipNode[T] {
+	node := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && sl.less(node.next[i].item, item) {
+			node = node.next[i]
+		}
+	}
+	return node.next[0]
+}
+
+// First returns the smallest node, or nil if the list is empty
+func (sl *skipList[T]) First() *skipNode[T] {
+	return sl.head.next[0]
+}
+
+// Rank returns the zero-based position of item, or -1 if it is not present
+func (sl *skipList[T]) Rank(item T) int {
+	rank := 0
+	node := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && sl.less(node.next[i].item, item) {
+			rank += node.spans[i]
+			node = node.next[i]
+		}
+	}
+
+	next := node.next[0]
+	if next == nil || sl.less(item, next.item) {
+		return -1
+	}
+	return rank
+}
+
+// At returns the node at the zero-based position rank, or nil if out of range
+func (sl *skipList[T]) At(rank int) *skipNode[T] {
+	if rank < 0 || rank >= sl.length {
+		return nil
+	}
+
+	traversed := 0
+	node := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && traversed+node.spans[i] <= rank+1 {
+			traversed += node.spans[i]
+			node = node.next[i]
+		}
+		if traversed == rank+1 {
+			return node
+		}
+	}
+	return nil
+}
+
+// Next returns the node following n, or nil at the end of the list
+func (n *skipNode[T]) Next() *skipNode[T] {
+	return n.next[0]
+}
+
+// randomLevel picks the height of a new node
+func randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Float64() < skipListProbability {
+		level++
+	}
+	return level
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestSkipListOrdering(t *testing.T) {
+	sl := newSkipList(func(a, b int) bool { return a < b })
+
+	for _, n := range []int{50, 10, 40, 20, 30, 10} {
+		sl.Insert(n)
+	}
+
+	if got := sl.Len(); got != 5 {
+		t.Errorf("Expected 5 items, got %d", got)
+	}
+
+	var items []int
+	for node := sl.First(); node != nil; node = node.Next() {
+		items = append(items, node.item)
+	}
+	if !sort.IntsAreSorted(items) || len(items) != 5 {
+		t.Errorf("Expected 5 sorted items, got %v", items)
+	}
+
+	if node := sl.Seek(25); node == nil || node.item != 30 {
+		t.Errorf("Expected seek to land on 30, got %v", node)
+	}
+
+	if !sl.Delete(30) || sl.Delete(30) {
+		t.Error("Expected 30 to be deleted exactly once")
+	}
+
+	if node := sl.Seek(25); node == nil || node.item != 40 {
+		t.Errorf("Expected seek to land on 40, got %v", node)
+	}
+}
+
+func TestSkipListRanks(t *testing.T) {
+	sl := newSkipList(func(a, b string) bool { return a < b })
+
+	var keys []string
+	for i := 0; i < 500; i++ {
+		key := strconv.Itoa(i * 7 % 500)
+		keys = append(keys, key)
+		sl.Insert(key)
+	}
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		if got := sl.Rank(key); got != i {
+			t.Errorf("Expected rank %d for '%s', got %d", i, key, got)
+		}
+		if node := sl.At(i); node == nil || node.item != key {
+			t.Errorf("Expected '%s' at %d, got %v", key, i, node)
+		}
+	}
+
+	// Remove every other key and check positions are still consistent
+	var remaining []string
+	for i, key := range keys {
+		if i%2 == 0 {
+			sl.Delete(key)
+		} else {
+			remaining = append(remaining, key)
+		}
+	}
+
+	for i, key := range remaining {
+		if got := sl.Rank(key); got != i {
+			t.Errorf("Expected rank %d for '%s', got %d", i, key, got)
+		}
+		if node := sl.At(i); node == nil || node.item != key {
+			t.Errorf("Expected '%s' at %d, got %v", key, i, node)
+		}
+	}
+
+	if got := sl.Rank(keys[0]); got != -1 {
+		t.Errorf("Expected -1 for deleted key, got %d", got)
+	}
+
+	if node := sl.At(len(remaining)); node != nil {
+		t.Errorf("Expected nil past the end, got %v", node.item)
+	}
+}
@@ -1,25 +1,79 @@
 package storage
 
-// Storage handles the core key-value storage and value counting
+import (
+	"math"
+	"strconv"
+)
+
+// Storage handles the core key-value storage and value indexing.
+// Plain strings live in data and take part in the value index; structured
+// values such as hashes live in objects. A key is in at most one of them.
 type Storage struct {
-	data        map[string]string
-	valueCounts map[string]int
+	data          map[string]string
+	objects       map[string]Value
+	keys          *skipList[string]
+	valueKeys     map[string]map[string]struct{}
+	lexValues     *skipList[string]
+	numericValues *skipList[numericValue]
+}
+
+// numericValue is a distinct value that parses as a number, ordered by
+// its numeric value first and its text second
+type numericValue struct {
+	number float64
+	text   string
+}
+
+// ParseNumber parses value as a number for the numeric value index.
+// NaN is not considered a number.
+func ParseNumber(value string) (float64, bool) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) {
+		return 0, false
+	}
+	return number, true
+}
+
+// Indexed reports whether value takes part in the value index.
+// HyperLogLog encodings are left out: nobody looks them up by value, and
+// indexing 12KB of registers would add to the cost of every PFADD.
+func Indexed(value string) bool {
+	return !isHyperLogLog(value)
 }
 
 // New creates a new storage instance
 func New() *Storage {
 	return &Storage{
-		data:        make(map[string]string),
-		valueCounts: make(map[string]int),
+		data:      make(map[string]string),
+		objects:   make(map[string]Value),
+		keys:      newSkipList(lessString),
+		valueKeys: make(map[string]map[string]struct{}),
+		lexValues: newSkipList(lessString),
+		numericValues: newSkipList(func(a, b numericValue) bool {
+			if a.number != b.number {
+				return a.number < b.number
+			}
+			return a.text < b.text
+		}),
 	}
 }
 
-// Set stores a key-value pair
+// lessString orders strings lexicographically
+func lessString(a, b string) bool {
+	return a < b
+}
+
+// Set stores a key-value pair, replacing any structured value at key
 func (s *Storage) Set(key, value string) {
+	if !s.exists(key) {
+		s.keys.Insert(key)
+	}
+	delete(s.objects, key)
 	s.data[key] = value
 }
 
-// Get retrieves a value by key, returns "NULL" if not found
+// Get retrieves a value by key, returns "NULL" if not found or if the key
+// holds a structured value
 func (s *Storage) Get(key string) string {
 	if value, exists := s.data[key]; exists {
 		return value
@@ -27,33 +81,141 @@ func (s *Storage) Get(key string) string {
 	return "NULL"
 }
 
-// Unset removes a key-value pair
+// SetObject stores a structured value, replacing any string at key.
+// Callers are responsible for removing a replaced string from the value index.
+func (s *Storage) SetObject(key string, value Value) {
+	if !s.exists(key) {
+		s.keys.Insert(key)
+	}
+	delete(s.data, key)
+	s.objects[key] = value
+}
+
+// GetObject retrieves the structured value at key, or nil if there is none
+func (s *Storage) GetObject(key string) Value {
+	return s.objects[key]
+}
+
+// Type returns the type of the value stored at key
+func (s *Storage) Type(key string) ValueType {
+	if _, exists := s.data[key]; exists {
+		return TypeString
+	}
+	if value, exists := s.objects[key]; exists {
+		return value.Type()
+	}
+	return TypeNone
+}
+
+// Unset removes a key and whatever value it holds
 func (s *Storage) Unset(key string) {
+	if s.exists(key) {
+		s.keys.Delete(key)
+	}
 	delete(s.data, key)
+	delete(s.objects, key)
+}
+
+// exists reports whether key holds a value of any type
+func (s *Storage) exists(key string) bool {
+	if _, exists := s.data[key]; exists {
+		return true
+	}
+	_, exists := s.objects[key]
+	return exists
+}
+
+// Len returns the number of keys holding a value of any type
+func (s *Storage) Len() int {
+	return s.keys.Len()
+}
+
+// Ascend calls fn for every key not less than from, in sorted order,
+// until fn returns false
+func (s *Storage) Ascend(from string, fn func(key string) bool) {
+	for node := s.keys.Seek(from); node != nil; node = node.Next() {
+		if !fn(node.item) {
+			return
+		}
+	}
 }
 
 // GetValueCount returns the count of keys with the given value
 func (s *Storage) GetValueCount(value string) int {
-	return s.valueCounts[value]
+	return len(s.valueKeys[value])
+}
+
+// EachValueCount calls fn with every distinct stored value and its count
+func (s *Storage) EachValueCount(fn func(value string, count int)) {
+	for value, keys := range s.valueKeys {
+		fn(value, len(keys))
+	}
 }
 
-// UpdateValueCount handles value count changes when a key is updated
-func (s *Storage) UpdateValueCount(oldValue, newValue string) {
+// GetValueKeys returns the keys holding the given value, in no particular order
+func (s *Storage) GetValueKeys(value string) []string {
+	keys := make([]string, 0, len(s.valueKeys[value]))
+	for key := range s.valueKeys[value] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// UpdateValueIndex moves key from oldValue to newValue in the value index
+func (s *Storage) UpdateValueIndex(key, oldValue, newValue string) {
 	if oldValue != "NULL" {
-		s.DecrementValueCount(oldValue)
+		s.RemoveValueKey(key, oldValue)
 	}
-	s.IncrementValueCount(newValue)
+	s.AddValueKey(key, newValue)
 }
 
-// IncrementValueCount increases the count for a value
-func (s *Storage) IncrementValueCount(value string) {
-	s.valueCounts[value]++
+// AddValueKey records that key holds value, unless value is left out of
+// the value index
+func (s *Storage) AddValueKey(key, value string) {
+	if !Indexed(value) {
+		return
+	}
+	keys, exists := s.valueKeys[value]
+	if !exists {
+		keys = make(map[string]struct{})
+		s.valueKeys[value] = keys
+		s.lexValues.Insert(value)
+		if number, ok := ParseNumber(value); ok {
+			s.numericValues.Insert(numericValue{number: number, text: value})
+		}
+	}
+	keys[key] = struct{}{}
 }
 
-// DecrementValueCount decreases the count for a value
-func (s *Storage) DecrementValueCount(value string) {
-	s.valueCounts[value]--
-	if s.valueCounts[value] <= 0 {
-		delete(s.valueCounts, value)
+// RemoveValueKey records that key no longer holds value
+func (s *Storage) RemoveValueKey(key, value string) {
+	keys, exists := s.valueKeys[value]
+	if !exists {
+		return
 	}
-}
\ No newline at end of file
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(s.valueKeys, value)
+		s.lexValues.Delete(value)
+		if number, ok := ParseNumber(value); ok {
+			s.numericValues.Delete(numericValue{number: number, text: value})
+		}
+	}
+}
+
+// EachValueInLexRange calls fn for every distinct stored value between min
+// and max (both inclusive) in lexicographic order
+func (s *Storage) EachValueInLexRange(min, max string, fn func(value string)) {
+	for node := s.lexValues.Seek(min); node != nil && node.item <= max; node = node.Next() {
+		fn(node.item)
+	}
+}
+
+// EachValueInNumericRange calls fn for every distinct stored value that is a
+// number between min and max (both inclusive) in numeric order
+func (s *Storage) EachValueInNumericRange(min, max float64, fn func(value string)) {
+	start := numericValue{number: min}
+	for node := s.numericValues.Seek(start); node != nil && node.item.number <= max; node = node.Next() {
+		fn(node.item.text)
+	}
+}
@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamID identifies a stream entry: a millisecond timestamp and a
+// sequence number telling apart entries added in the same millisecond
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// MaxStreamID is the largest possible entry ID
+var MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+// ParseStreamID parses "ms-seq", or "ms" with seq set to defaultSeq
+func ParseStreamID(s string, defaultSeq uint64) (StreamID, bool) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, false
+	}
+	if !hasSeq {
+		return StreamID{Ms: ms, Seq: defaultSeq}, true
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return StreamID{}, false
+	}
+	return StreamID{Ms: ms, Seq: seq}, true
+}
+
+// String formats the ID as "ms-seq"
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// Less reports whether id sorts before other
+func (id StreamID) Less(other StreamID) bool {
+	if id.Ms != other.Ms {
+		return id.Ms < other.Ms
+	}
+	return id.Seq < other.Seq
+}
+
+// Next returns the smallest ID greater than id
+func (id StreamID) Next() StreamID {
+	if id.Seq == math.MaxUint64 {
+		return StreamID{Ms: id.Ms + 1}
+	}
+	return StreamID{Ms: id.Ms, Seq: id.Seq + 1}
+}
+
+// StreamEntry is a single stream entry. Fields alternate between field
+// names and values, in the order they were added.
+type StreamEntry struct {
+	ID     StreamID
+	Fields []string
+}
+
+// PendingEntry is an entry delivered to a consumer of a group and not yet
+// acknowledged
+type PendingEntry struct {
+	ID            StreamID
+	Consumer      string
+	DeliveryCount int
+	DeliveredAt   time.Time
+}
+
+// consumerGroup tracks what a group of consumers has been given
+type consumerGroup struct {
+	lastDelivered StreamID
+	pending       map[StreamID]PendingEntry
+}
+
+// Stream is an append-only log of entries with increasing IDs, plus the
+// consumer groups reading it
+type Stream struct {
+	entries []StreamEntry
+	lastID  StreamID
+	groups  map[string]*consumerGroup
+}
+
+// NewStream creates an empty stream
+func NewStream() *Stream {
+	return &Stream{groups: make(map[string]*consumerGroup)}
+}
+
+// Type returns TypeStream
+func (s *Stream) Type() ValueType {
+	return TypeStream
+}
+
+// Clone returns a copy of the stream. Entries are never modified once
+// added, so their fields are shared.
+func (s *Stream) Clone() Value {
+	clone := &Stream{
+		entries: append([]StreamEntry(nil), s.entries...),
+		lastID:  s.lastID,
+		groups:  make(map[string]*consumerGroup, len(s.groups)),
+	}
+	for name, group := range s.groups {
+		pending := make(map[StreamID]PendingEntry, len(group.pending))
+		for id, entry := range group.pending {
+			pending[id] = entry
+		}
+		clone.groups[name] = &consumerGroup{lastDelivered: group.lastDelivered, pending: pending}
+	}
+	return clone
+}
+
+// Len returns the number of entries
+func (s *Stream) Len() int {
+	return len(s.entries)
+}
+
+// LastID returns the greatest ID ever added, even if it was trimmed since
+func (s *Stream) LastID() StreamID {
+	return s.lastID
+}
+
+// Range returns up to count entries with IDs from start to end
+// (inclusive). A negative count means no limit.
+func (s *Stream) Range(start, end StreamID, count int) []StreamEntry {
+	var entries []StreamEntry
+	for i := s.search(start); i < len(s.entries) && !end.Less(s.entries[i].ID) && count != 0; i++ {
+		entries = append(entries, s.entries[i])
+		count--
+	}
+	return entries
+}
+
+// After returns up to count entries with IDs greater than id. A negative
+// count means no limit.
+func (s *Stream) After(id StreamID, count int) []StreamEntry {
+	if id == MaxStreamID {
+		return nil
+	}
+	return s.Range(id.Next(), MaxStreamID, count)
+}
+
+// CountBefore returns the number of entries with IDs less than id
+func (s *Stream) CountBefore(id StreamID) int {
+	return s.search(id)
+}
+
+// HasGroup reports whether the stream has a consumer group called name
+func (s *Stream) HasGroup(name string) bool {
+	_, exists := s.groups[name]
+	return exists
+}
+
+// LastDelivered returns the greatest ID delivered to a consumer group
+func (s *Stream) LastDelivered(group string) StreamID {
+	return s.groups[group].lastDelivered
+}
+
+// Pending returns the unacknowledged entries of a consumer group, sorted
+// by ID
+func (s *Stream) Pending(group string) []PendingEntry {
+	var pending []PendingEntry
+	if g, exists := s.groups[group]; exists {
+		for _, entry := range g.pending {
+			pending = append(pending, entry)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID.Less(pending[j].ID)
+	})
+	return pending
+}
+
+// search returns the index of the first entry with an ID not less than id
+func (s *Stream) search(id StreamID) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return !s.entries[i].ID.Less(id)
+	})
+}
+
+// streamOpKind identifies a recorded stream operation
+type streamOpKind int
+
+const (
+	streamAdd streamOpKind = iota
+	streamTrimMaxLen
+	streamTrimMinID
+	streamCreateGroup
+	streamDeliver
+	streamAck
+)
+
+// streamOp is a single recorded stream operation
+type streamOp struct {
+	kind     streamOpKind
+	id       StreamID
+	fields   []string
+	maxLen   int
+	group    string
+	consumer string
+	ids      []StreamID
+	at       time.Time
+}
+
+// StreamPatch records stream operations in the order they happened, to be
+// replayed like a ListPatch
+type StreamPatch struct {
+	ops []streamOp
+}
+
+// Add records an entry appended with id
+func (p *StreamPatch) Add(id StreamID, fields []string) {
+	p.ops = append(p.ops, streamOp{kind: streamAdd, id: id, fields: fields})
+}
+
+// TrimMaxLen records the oldest entries being removed until at most
+// maxLen remain
+func (p *StreamPatch) TrimMaxLen(maxLen int) {
+	p.ops = append(p.ops, streamOp{kind: streamTrimMaxLen, maxLen: maxLen})
+}
+
+// TrimMinID records the entries with IDs less than id being removed
+func (p *StreamPatch) TrimMinID(id StreamID) {
+	p.ops = append(p.ops, streamOp{kind: streamTrimMinID, id: id})
+}
+
+// CreateGroup records a consumer group being created, starting after id
+func (p *StreamPatch) CreateGroup(group string, id StreamID) {
+	p.ops = append(p.ops, streamOp{kind: streamCreateGroup, group: group, id: id})
+}
+
+// Deliver records entries being delivered to a consumer of a group at the
+// given time. Each becomes pending for that consumer, with its delivery
+// count raised by one.
+func (p *StreamPatch) Deliver(group, consumer string, ids []StreamID, at time.Time) {
+	p.ops = append(p.ops, streamOp{kind: streamDeliver, group: group, consumer: consumer, ids: ids, at: at})
+}
+
+// Ack records entries being acknowledged by a group
+func (p *StreamPatch) Ack(group string, ids []StreamID) {
+	p.ops = append(p.ops, streamOp{kind: streamAck, group: group, ids: ids})
+}
+
+// Type returns TypeStream
+func (p *StreamPatch) Type() ValueType {
+	return TypeStream
+}
+
+// Conflicts reports whether replaying the patch on v would add an entry
+// whose ID is not greater than the last one, which happens when entries
+// were added to the stream after the patch was recorded
+func (p *StreamPatch) Conflicts(v Value) bool {
+	var last StreamID
+	if stream, ok := v.(*Stream); ok {
+		last = stream.lastID
+	}
+
+	for _, op := range p.ops {
+		if op.kind != streamAdd {
+			continue
+		}
+		if !last.Less(op.id) {
+			return true
+		}
+		last = op.id
+	}
+	return false
+}
+
+// Apply replays the recorded operations. Streams are kept even when
+// empty, so the result is never nil. Entries are added with their recorded
+// IDs, so callers check Conflicts first.
+func (p *StreamPatch) Apply(v Value) Value {
+	stream, _ := v.(*Stream)
+	if stream == nil {
+		stream = NewStream()
+	}
+
+	for _, op := range p.ops {
+		switch op.kind {
+		case streamAdd:
+			stream.entries = append(stream.entries, StreamEntry{ID: op.id, Fields: op.fields})
+			stream.lastID = op.id
+		case streamTrimMaxLen:
+			if excess := len(stream.entries) - op.maxLen; excess > 0 {
+				stream.entries = append([]StreamEntry(nil), stream.entries[excess:]...)
+			}
+		case streamTrimMinID:
+			stream.entries = append([]StreamEntry(nil), stream.entries[stream.search(op.id):]...)
+		case streamCreateGroup:
+			if !stream.HasGroup(op.group) {
+				stream.groups[op.group] = &consumerGroup{lastDelivered: op.id, pending: make(map[StreamID]PendingEntry)}
+			}
+		case streamDeliver:
+			group, exists := stream.groups[op.group]
+			if !exists {
+				continue
+			}
+			for _, id := range op.ids {
+				entry := group.pending[id]
+				group.pending[id] = PendingEntry{ID: id, Consumer: op.consumer, DeliveryCount: entry.DeliveryCount + 1, DeliveredAt: op.at}
+				if group.lastDelivered.Less(id) {
+					group.lastDelivered = id
+				}
+			}
+		case streamAck:
+			if group, exists := stream.groups[op.group]; exists {
+				for _, id := range op.ids {
+					delete(group.pending, id)
+				}
+			}
+		}
+	}
+	return stream
+}
+
+// Merge appends the operations of a later stream patch
+func (p *StreamPatch) Merge(later Patch) {
+	p.ops = append(p.ops, later.(*StreamPatch).ops...)
+}
+
+// Clone returns a copy of the patch
+func (p *StreamPatch) Clone() Patch {
+	return &StreamPatch{ops: append([]streamOp(nil), p.ops...)}
+}
@@ -0,0 +1,57 @@
+package storage
+
+// ValueType identifies the kind of value stored at a key
+type ValueType int
+
+const (
+	TypeNone ValueType = iota
+	TypeString
+	TypeHash
+	TypeList
+	TypeSet
+	TypeSortedSet
+	TypeStream
+	TypeJSON
+)
+
+// String returns the name reported by the TYPE command
+func (t ValueType) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeHash:
+		return "hash"
+	case TypeList:
+		return "list"
+	case TypeSet:
+		return "set"
+	case TypeSortedSet:
+		return "zset"
+	case TypeStream:
+		return "stream"
+	case TypeJSON:
+		return "json"
+	}
+	return "none"
+}
+
+// Value is a structured value stored at a key. Plain strings are kept
+// separately so they can take part in the value index.
+type Value interface {
+	Type() ValueType
+	Clone() Value
+}
+
+// Patch is a set of partial changes to a structured value, such as the
+// fields written to or removed from a hash. Patches let transaction layers
+// stage just the parts of a value they touched.
+type Patch interface {
+	Type() ValueType
+	// Apply applies the changes to v, which may be nil for a missing key,
+	// and returns the result, or nil if nothing is left. Apply may modify v,
+	// so callers must pass a value they own.
+	Apply(v Value) Value
+	// Merge folds a later patch of the same type into this one
+	Merge(later Patch)
+	Clone() Patch
+}
@@ -0,0 +1,192 @@
+package storage
+
+// ScoredMember is a sorted set member together with its score
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// lessScoredMember orders members by score, breaking ties by member
+func lessScoredMember(a, b ScoredMember) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.Member < b.Member
+}
+
+// SortedSet is a value holding unique members ordered by score. The map
+// answers score lookups and the skip list answers ordered and rank queries.
+type SortedSet struct {
+	scores map[string]float64
+	index  *skipList[ScoredMember]
+}
+
+// NewSortedSet creates an empty sorted set
+func NewSortedSet() *SortedSet {
+	return &SortedSet{
+		scores: make(map[string]float64),
+		index:  newSkipList(lessScoredMember),
+	}
+}
+
+// Type returns TypeSortedSet
+func (z *SortedSet) Type() ValueType {
+	return TypeSortedSet
+}
+
+// Clone returns a copy of the sorted set
+func (z *SortedSet) Clone() Value {
+	clone := NewSortedSet()
+	for node := z.index.First(); node != nil; node = node.Next() {
+		clone.Add(node.item.Member, node.item.Score)
+	}
+	return clone
+}
+
+// Add sets the score of member, inserting it if needed
+func (z *SortedSet) Add(member string, score float64) {
+	if old, exists := z.scores[member]; exists {
+		if old == score {
+			return
+		}
+		z.index.Delete(ScoredMember{Member: member, Score: old})
+	}
+	z.scores[member] = score
+	z.index.Insert(ScoredMember{Member: member, Score: score})
+}
+
+// Remove deletes member, returning false if it was not present
+func (z *SortedSet) Remove(member string) bool {
+	score, exists := z.scores[member]
+	if !exists {
+		return false
+	}
+	delete(z.scores, member)
+	z.index.Delete(ScoredMember{Member: member, Score: score})
+	return true
+}
+
+// Score returns the score of member
+func (z *SortedSet) Score(member string) (float64, bool) {
+	score, exists := z.scores[member]
+	return score, exists
+}
+
+// Len returns the number of members
+func (z *SortedSet) Len() int {
+	return len(z.scores)
+}
+
+// Rank returns the zero-based position of member in score order
+func (z *SortedSet) Rank(member string) (int, bool) {
+	score, exists := z.scores[member]
+	if !exists {
+		return 0, false
+	}
+	return z.index.Rank(ScoredMember{Member: member, Score: score}), true
+}
+
+// Range returns the members between two positions (inclusive) in score
+// order. Negative positions count from the end, as in List.Range. With
+// reverse set the positions count from the highest score down.
+func (z *SortedSet) Range(start, stop int, reverse bool) []ScoredMember {
+	length := z.Len()
+	start, stop, ok := normalizeRange(start, stop, length)
+	if !ok {
+		return nil
+	}
+	if reverse {
+		start, stop = length-1-stop, length-1-start
+	}
+
+	members := make([]ScoredMember, 0, stop-start+1)
+	for node := z.index.At(start); node != nil && len(members) <= stop-start; node = node.Next() {
+		members = append(members, node.item)
+	}
+	if reverse {
+		reverseMembers(members)
+	}
+	return members
+}
+
+// RangeByScore returns the members scored between min and max (inclusive)
+// in score order, skipping the first offset matches and returning at most
+// count of them. A negative count means no limit.
+func (z *SortedSet) RangeByScore(min, max float64, offset, count int) []ScoredMember {
+	var members []ScoredMember
+
+	node := z.index.Seek(ScoredMember{Score: min})
+	for ; node != nil && node.item.Score <= max && count != 0; node = node.Next() {
+		if offset > 0 {
+			offset--
+			continue
+		}
+		members = append(members, node.item)
+		count--
+	}
+	return members
+}
+
+// reverseMembers reverses a slice of members in place
+func reverseMembers(members []ScoredMember) {
+	for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+		members[i], members[j] = members[j], members[i]
+	}
+}
+
+// SortedSetPatch records score writes and member removals on a sorted set.
+// A nil entry means the member was removed.
+type SortedSetPatch map[string]*float64
+
+// SetScore records a write of score to member
+func (p SortedSetPatch) SetScore(member string, score float64) {
+	p[member] = &score
+}
+
+// RemoveMember records the removal of member
+func (p SortedSetPatch) RemoveMember(member string) {
+	p[member] = nil
+}
+
+// Type returns TypeSortedSet
+func (p SortedSetPatch) Type() ValueType {
+	return TypeSortedSet
+}
+
+// Apply writes and removes the patched members. A sorted set left
+// without members is returned as nil.
+func (p SortedSetPatch) Apply(v Value) Value {
+	zset, _ := v.(*SortedSet)
+	if zset == nil {
+		zset = NewSortedSet()
+	}
+
+	for member, score := range p {
+		if score == nil {
+			zset.Remove(member)
+		} else {
+			zset.Add(member, *score)
+		}
+	}
+
+	if zset.Len() == 0 {
+		return nil
+	}
+	return zset
+}
+
+// Merge folds the member changes of a later sorted set patch into this one
+func (p SortedSetPatch) Merge(later Patch) {
+	for member, score := range later.(SortedSetPatch) {
+		p[member] = score
+	}
+}
+
+// Clone returns a copy of the patch
+func (p SortedSetPatch) Clone() Patch {
+	clone := make(SortedSetPatch, len(p))
+	for member, score := range p {
+		clone[member] = score
+	}
+	return clone
+}